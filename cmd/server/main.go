@@ -9,14 +9,17 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 	a2aserver "github.com/techbuzzz/agent-shaker/internal/a2a/server"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
 	"github.com/techbuzzz/agent-shaker/internal/database"
 	"github.com/techbuzzz/agent-shaker/internal/handlers"
 	"github.com/techbuzzz/agent-shaker/internal/mcp"
 	"github.com/techbuzzz/agent-shaker/internal/middleware"
+	"github.com/techbuzzz/agent-shaker/internal/scheduler"
 	"github.com/techbuzzz/agent-shaker/internal/task"
 	"github.com/techbuzzz/agent-shaker/internal/websocket"
 )
@@ -54,6 +57,52 @@ func main() {
 	hub := websocket.NewHub()
 	go hub.Run()
 
+	// Release task claims whose lease expired without renewal
+	if db != nil {
+		go scheduler.NewLeaseReaper(db, hub,
+			durationEnv("LEASE_REAPER_INTERVAL", scheduler.DefaultLeaseReaperInterval)).Run()
+	}
+
+	// Announce open tasks that passed their due date
+	if db != nil {
+		go scheduler.NewOverdueDetector(db, hub,
+			durationEnv("OVERDUE_CHECK_INTERVAL", scheduler.DefaultOverdueCheckInterval)).Run()
+	}
+
+	// Mark agents idle and offline when their heartbeats stop
+	if db != nil {
+		go scheduler.NewPresenceTracker(db, hub,
+			durationEnv("PRESENCE_CHECK_INTERVAL", scheduler.DefaultPresenceCheckInterval)).Run()
+	}
+
+	// Roll heartbeats into activity buckets and delete them after the retention window
+	if db != nil {
+		go scheduler.NewHeartbeatRollup(db,
+			durationEnv("HEARTBEAT_ROLLUP_INTERVAL", scheduler.DefaultHeartbeatRollupInterval),
+			durationEnv("HEARTBEAT_RETENTION", scheduler.DefaultHeartbeatRetention),
+			durationEnv("ACTIVITY_HOURLY_RETENTION", scheduler.DefaultHourlyActivityRetention),
+		).Run()
+	}
+
+	// Require an API key on the REST, MCP, A2A and WebSocket endpoints. The
+	// admin key is for operators and the web UI; agents use their own keys.
+	adminKey := os.Getenv("ADMIN_API_KEY")
+	if adminKey == "change-me" {
+		log.Fatal("ADMIN_API_KEY is set to the placeholder change-me, set it to a random secret")
+	}
+	authenticator := auth.NewAuthenticator(adminKey, func(hash string) (auth.Identity, error) {
+		if db == nil {
+			return auth.Identity{}, auth.ErrInvalidKey
+		}
+		return database.AuthenticateAPIKey(db, hash)
+	})
+	if os.Getenv("AUTH_DISABLED") == "true" {
+		authenticator.Disabled = true
+		log.Println("WARNING: authentication is disabled, X-Agent-ID headers are trusted")
+	} else if adminKey == "" {
+		log.Println("ADMIN_API_KEY is not set, only agent API keys are accepted")
+	}
+
 	// Create handlers
 	projectHandler := handlers.NewProjectHandler(db, hub)
 	agentHandler := handlers.NewAgentHandler(db, hub)
@@ -62,6 +111,7 @@ func main() {
 	standupHandler := handlers.NewStandupHandler(db, hub)
 	wsHandler := handlers.NewWebSocketHandler(hub)
 	dashboardHandler := handlers.NewDashboardHandler(db)
+	searchHandler := handlers.NewSearchHandler(db)
 	mcpHandler := mcp.NewMCPHandler(db, hub)
 
 	// A2A Protocol Setup
@@ -96,12 +146,30 @@ func main() {
 	// Dashboard
 	api.HandleFunc("/dashboard", dashboardHandler.GetDashboardStats).Methods("GET")
 
+	// Search
+	api.HandleFunc("/search", searchHandler.Search).Methods("GET")
+
 	// Projects
 	api.HandleFunc("/projects", projectHandler.CreateProject).Methods("POST")
 	api.HandleFunc("/projects", projectHandler.ListProjects).Methods("GET")
+	api.HandleFunc("/projects/import", projectHandler.ImportProject).Methods("POST")
 	api.HandleFunc("/projects/{id}", projectHandler.GetProject).Methods("GET")
+	api.HandleFunc("/projects/{id}", projectHandler.UpdateProject).Methods("PATCH")
 	api.HandleFunc("/projects/{id}", projectHandler.DeleteProject).Methods("DELETE")
 	api.HandleFunc("/projects/{id}/status", projectHandler.UpdateProjectStatus).Methods("PUT")
+	api.HandleFunc("/projects/{id}/lease", projectHandler.UpdateProjectLease).Methods("PUT")
+	api.HandleFunc("/projects/{id}/sla", projectHandler.UpdateProjectSLA).Methods("PUT")
+	api.HandleFunc("/projects/{id}/fields", projectHandler.ListCustomFields).Methods("GET")
+	api.HandleFunc("/projects/{id}/fields", projectHandler.CreateCustomField).Methods("POST")
+	api.HandleFunc("/projects/{id}/fields/{name}", projectHandler.DeleteCustomField).Methods("DELETE")
+	api.HandleFunc("/projects/{id}/export", projectHandler.ExportProject).Methods("GET")
+	api.HandleFunc("/projects/{id}/template", projectHandler.UpdateProjectTemplate).Methods("PUT")
+	api.HandleFunc("/projects/{id}/clone", projectHandler.CloneProject).Methods("POST")
+	api.HandleFunc("/projects/{id}/milestones", projectHandler.ListMilestones).Methods("GET")
+	api.HandleFunc("/projects/{id}/milestones", projectHandler.CreateMilestone).Methods("POST")
+	api.HandleFunc("/milestones/{id}", projectHandler.GetMilestone).Methods("GET")
+	api.HandleFunc("/milestones/{id}", projectHandler.UpdateMilestone).Methods("PUT")
+	api.HandleFunc("/milestones/{id}", projectHandler.DeleteMilestone).Methods("DELETE")
 
 	// Agents
 	api.HandleFunc("/agents", agentHandler.CreateAgent).Methods("POST")
@@ -109,15 +177,37 @@ func main() {
 	api.HandleFunc("/agents/{id}", agentHandler.GetAgent).Methods("GET")
 	api.HandleFunc("/agents/{id}", agentHandler.DeleteAgent).Methods("DELETE")
 	api.HandleFunc("/agents/{id}/status", agentHandler.UpdateAgentStatus).Methods("PUT")
+	api.HandleFunc("/agents/{id}/access", agentHandler.UpdateAgentAccess).Methods("PUT")
+	api.HandleFunc("/agents/{id}/profile", agentHandler.UpdateAgentProfile).Methods("PUT")
+	api.HandleFunc("/agents/{id}/keys", agentHandler.ListAPIKeys).Methods("GET")
+	api.HandleFunc("/agents/{id}/keys", agentHandler.CreateAPIKey).Methods("POST")
+	api.HandleFunc("/agents/{id}/keys/{keyId}", agentHandler.RevokeAPIKey).Methods("DELETE")
+	api.HandleFunc("/agents/{id}/keys/{keyId}/rotate", agentHandler.RotateAPIKey).Methods("POST")
 
 	// Tasks
 	api.HandleFunc("/tasks", taskHandler.CreateTask).Methods("POST")
 	api.HandleFunc("/tasks", taskHandler.ListTasks).Methods("GET")
+	api.HandleFunc("/tasks/next", taskHandler.NextTask).Methods("POST")
 	api.HandleFunc("/tasks/{id}", taskHandler.GetTask).Methods("GET")
 	api.HandleFunc("/tasks/{id}", taskHandler.UpdateTask).Methods("PUT")
 	api.HandleFunc("/tasks/{id}", taskHandler.DeleteTask).Methods("DELETE")
 	api.HandleFunc("/tasks/{id}/status", taskHandler.UpdateTaskStatus).Methods("PUT")
 	api.HandleFunc("/tasks/{id}/reassign", taskHandler.ReassignTask).Methods("PUT")
+	api.HandleFunc("/tasks/{id}/due", taskHandler.UpdateTaskDueDate).Methods("PUT")
+	api.HandleFunc("/tasks/{id}/labels", taskHandler.UpdateTaskLabels).Methods("PUT")
+	api.HandleFunc("/tasks/{id}/fields", taskHandler.UpdateTaskCustomFields).Methods("PUT")
+	api.HandleFunc("/tasks/{id}/milestone", taskHandler.UpdateTaskMilestone).Methods("PUT")
+	api.HandleFunc("/tasks/{id}/reopen", taskHandler.ReopenTask).Methods("POST")
+	api.HandleFunc("/tasks/{id}/history", taskHandler.GetTaskHistory).Methods("GET")
+	api.HandleFunc("/tasks/{id}/comments", taskHandler.ListTaskComments).Methods("GET")
+	api.HandleFunc("/tasks/{id}/comments", taskHandler.CreateTaskComment).Methods("POST")
+	api.HandleFunc("/tasks/{id}/comments/{commentId}", taskHandler.UpdateTaskComment).Methods("PUT")
+	api.HandleFunc("/tasks/{id}/comments/{commentId}", taskHandler.DeleteTaskComment).Methods("DELETE")
+	api.HandleFunc("/tasks/{id}/children", taskHandler.ListTaskChildren).Methods("GET")
+	api.HandleFunc("/tasks/{id}/progress", taskHandler.GetTaskProgress).Methods("GET")
+	api.HandleFunc("/tasks/{id}/dependencies", taskHandler.ListTaskDependencies).Methods("GET")
+	api.HandleFunc("/tasks/{id}/dependencies", taskHandler.AddTaskDependency).Methods("POST")
+	api.HandleFunc("/tasks/{id}/dependencies/{dependsOnId}", taskHandler.RemoveTaskDependency).Methods("DELETE")
 
 	// Contexts
 	api.HandleFunc("/contexts", contextHandler.CreateContext).Methods("POST")
@@ -125,6 +215,12 @@ func main() {
 	api.HandleFunc("/contexts/{id}", contextHandler.GetContext).Methods("GET")
 	api.HandleFunc("/contexts/{id}", contextHandler.UpdateContext).Methods("PUT")
 	api.HandleFunc("/contexts/{id}", contextHandler.DeleteContext).Methods("DELETE")
+	api.HandleFunc("/contexts/{id}/revisions", contextHandler.ListContextRevisions).Methods("GET")
+	api.HandleFunc("/contexts/{id}/revisions/{revision}", contextHandler.GetContextRevision).Methods("GET")
+	api.HandleFunc("/contexts/{id}/revisions/{revision}/restore", contextHandler.RestoreContextRevision).Methods("POST")
+	api.HandleFunc("/contexts/{id}/diff", contextHandler.DiffContextRevisions).Methods("GET")
+	api.HandleFunc("/contexts/{id}/links", contextHandler.GetContextLinks).Methods("GET")
+	api.HandleFunc("/projects/{id}/contexts/import", contextHandler.ImportContexts).Methods("POST")
 
 	// Daily Standups
 	api.HandleFunc("/standups", standupHandler.CreateStandup).Methods("POST")
@@ -136,6 +232,7 @@ func main() {
 	// Agent Heartbeats
 	api.HandleFunc("/heartbeats", standupHandler.RecordHeartbeat).Methods("POST")
 	api.HandleFunc("/agents/{id}/heartbeats", standupHandler.GetAgentHeartbeats).Methods("GET")
+	api.HandleFunc("/agents/{id}/activity", standupHandler.GetAgentActivity).Methods("GET")
 
 	// A2A Protocol routes
 	a2aserver.RegisterA2ARoutes(r, a2aHandler, streamingHandler, artifactHandler, agentCardHandler)
@@ -199,22 +296,22 @@ func main() {
 	// Setup CORS for API routes only
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
+		ExposedHeaders:   []string{"ETag"},
 		AllowCredentials: true,
 	})
 
 	// Create a custom handler that routes WebSocket without middleware
 	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		// WebSocket requests bypass all middleware
+		// WebSocket requests bypass all middleware except authentication
 		if req.URL.Path == "/ws" {
-			wsHandler.HandleWebSocket(w, req)
+			authenticator.Middleware(http.HandlerFunc(wsHandler.HandleWebSocket)).ServeHTTP(w, req)
 			return
 		}
 
-		// A2A Protocol routes - handle with CORS
-		if req.URL.Path == "/.well-known/agent-card.json" ||
-			strings.HasPrefix(req.URL.Path, "/a2a/") {
+		// A2A discovery stays public so other agents can find this server
+		if req.URL.Path == "/.well-known/agent-card.json" {
 			middleware.Recovery(
 				middleware.Logger(
 					c.Handler(r),
@@ -223,9 +320,19 @@ func main() {
 			return
 		}
 
+		// A2A Protocol routes - handle with CORS
+		if strings.HasPrefix(req.URL.Path, "/a2a/") {
+			middleware.Recovery(
+				middleware.Logger(
+					c.Handler(authenticator.Middleware(r)),
+				),
+			).ServeHTTP(w, req)
+			return
+		}
+
 		// MCP Protocol requests (root, /mcp, /mcp/message) - handle with CORS
 		if req.URL.Path == "/" || req.URL.Path == "/mcp" || len(req.URL.Path) >= 4 && req.URL.Path[:4] == "/mcp" {
-			c.Handler(http.HandlerFunc(mcpHandler.HandleMCP)).ServeHTTP(w, req)
+			c.Handler(authenticator.Middleware(http.HandlerFunc(mcpHandler.HandleMCP))).ServeHTTP(w, req)
 			return
 		}
 
@@ -233,8 +340,8 @@ func main() {
 		if len(req.URL.Path) >= 4 && req.URL.Path[:4] == "/api" {
 			middleware.Recovery(
 				middleware.Logger(
-					middleware.RequestSizeLimit(10*1024*1024)(
-						c.Handler(api),
+					middleware.RequestSizeLimit(apiBodyLimit(req.URL.Path))(
+						c.Handler(authenticator.Middleware(api)),
 					),
 				),
 			).ServeHTTP(w, req)
@@ -281,6 +388,21 @@ func main() {
 	}
 }
 
+// durationEnv reads a duration such as 30s or 168h from an environment
+// variable, falling back to def when it is unset or invalid
+func durationEnv(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("Invalid %s %q, using %s", name, v, def)
+		return def
+	}
+	return d
+}
+
 func runMigrations(db *database.DB) error {
 	log.Println("Running database migrations...")
 
@@ -440,6 +562,18 @@ func runMigrations(db *database.DB) error {
 	return nil
 }
 
+// apiBodyLimit returns the largest request body accepted on an API path.
+// Archive imports check their own, larger limit.
+func apiBodyLimit(path string) int64 {
+	switch {
+	case strings.HasSuffix(path, "/contexts/import"):
+		return handlers.MaxImportSize
+	case path == "/api/projects/import":
+		return handlers.MaxBundleSize
+	}
+	return 10 * 1024 * 1024
+}
+
 func getPort() string {
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// runExport downloads a project bundle to a file or stdout
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	server := fs.String("server", defaultServer(), "Agent Shaker server URL (env SHAKER_URL)")
+	project := fs.String("project", "", "project ID to export (required)")
+	format := fs.String("format", "json", "bundle format: json or zip")
+	output := fs.String("o", "", "file to write the bundle to (default: the name suggested by the server, - for stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: shaker export -project <id> [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *project == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("-project is required")
+	}
+
+	endpoint := apiURL(*server, fmt.Sprintf("/projects/%s/export?format=%s", url.PathEscape(*project), url.QueryEscape(*format)))
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := send(req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if *output == "-" {
+		_, err := io.Copy(os.Stdout, resp.Body)
+		return err
+	}
+
+	path := *output
+	if path == "" {
+		path = "project-" + *project + "." + *format
+		// The server suggests a name made of the project name and export time
+		if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+			path = filepath.Base(params["filename"])
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported project %s to %s (%d bytes)\n", *project, path, n)
+	return nil
+}
+
+// runImport uploads a bundle file and creates a new project from it
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	server := fs.String("server", defaultServer(), "Agent Shaker server URL (env SHAKER_URL)")
+	name := fs.String("name", "", "name of the imported project (default: the name in the bundle)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: shaker import [flags] <bundle.json|bundle.zip>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("a bundle file is required")
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	endpoint := apiURL(*server, "/projects/import")
+	if *name != "" {
+		endpoint += "?name=" + url.QueryEscape(*name)
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(data, []byte("PK")) {
+		req.Header.Set("Content-Type", "application/zip")
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := send(req, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result models.ProjectImportResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	fmt.Printf("Imported project %q as %s: %d agents, %d tasks, %d comments, %d contexts, %d standups\n",
+		result.Project.Name, result.Project.ID, result.Agents, result.Tasks, result.TaskComments, result.Contexts, result.Standups)
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+// send performs req, authenticated with the key in SHAKER_API_KEY if set,
+// and returns the response if the server answered with the wanted status, or
+// an error with the server's message otherwise
+func send(req *http.Request, want int) (*http.Response, error) {
+	if key := os.Getenv("SHAKER_API_KEY"); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != want {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// apiURL joins the server URL and an API path
+func apiURL(server, path string) string {
+	return strings.TrimRight(server, "/") + "/api" + path
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/techbuzzz/agent-shaker/internal/docimport"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// runImportDocs packs the markdown files of a directory into a tar.gz and
+// uploads it to the project's context import endpoint
+func runImportDocs(args []string) error {
+	fs := flag.NewFlagSet("import-docs", flag.ContinueOnError)
+	server := fs.String("server", defaultServer(), "Agent Shaker server URL (env SHAKER_URL)")
+	project := fs.String("project", "", "project ID to import into (required)")
+	agent := fs.String("agent", "", "agent ID recorded as the author of the contexts (default: the agent of the API key)")
+	verbose := fs.Bool("v", false, "list the outcome of every file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: shaker import-docs -project <id> [-agent <id>] [flags] <dir>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *project == "" || fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("-project and a directory are required")
+	}
+
+	var archive bytes.Buffer
+	count, err := docimport.WriteTarGz(&archive, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("no markdown files found in %s", fs.Arg(0))
+	}
+
+	endpoint := apiURL(*server, fmt.Sprintf("/projects/%s/contexts/import", url.PathEscape(*project)))
+	if *agent != "" {
+		endpoint += "?agent_id=" + url.QueryEscape(*agent)
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, &archive)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+
+	resp, err := send(req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result models.ContextImportResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if *verbose {
+		for _, file := range result.Files {
+			line := fmt.Sprintf("%-8s %s", file.Action, file.Path)
+			if file.Reason != "" {
+				line += " (" + file.Reason + ")"
+			}
+			fmt.Println(line)
+		}
+	}
+	fmt.Printf("Imported %d files: %d created, %d updated, %d skipped\n",
+		len(result.Files), result.Created, result.Updated, result.Skipped)
+	return nil
+}
@@ -0,0 +1,79 @@
+// Command shaker is a command line client for an Agent Shaker server.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// command is a shaker subcommand. run receives the arguments after its name.
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"export": {
+		usage: "download a project bundle",
+		run:   runExport,
+	},
+	"import": {
+		usage: "create a project from a bundle",
+		run:   runImport,
+	},
+	"import-docs": {
+		usage: "import a directory of markdown files as project contexts",
+		run:   runImportDocs,
+	},
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		printUsage()
+		return
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "shaker: unknown command %q\n\n", os.Args[1])
+		printUsage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:]); errors.Is(err, flag.ErrHelp) {
+		return
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "shaker %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func printUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "Usage: shaker <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'shaker <command> -h' for the flags of a command.")
+	fmt.Fprintln(os.Stderr, "Requests are authenticated with the API key in SHAKER_API_KEY.")
+}
+
+// defaultServer is the server URL used when -server is not given
+func defaultServer() string {
+	if url := os.Getenv("SHAKER_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return "http://localhost:8080"
+}
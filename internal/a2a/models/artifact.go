@@ -4,7 +4,7 @@ package models
 type Artifact struct {
 	ID          string         `json:"id"`
 	Name        string         `json:"name"`
-	Type        string         `json:"type"` // "markdown", "json", "binary"
+	Type        string         `json:"type"` // "markdown", "html", "json", "binary"
 	ContentType string         `json:"content_type"`
 	Content     string         `json:"content,omitempty"`
 	URL         string         `json:"url,omitempty"`
@@ -66,10 +66,9 @@ func (h *AgentCardHandler) generateAgentCard() models.AgentCard {
 
 		AuthSchemes: []models.AuthScheme{
 			{
-				Scheme:      "none",
-				Description: "Public endpoints require no authentication (suitable for development and testing)",
+				Scheme:      "bearer",
+				Description: "Send an agent API key as Authorization: Bearer <key>. Unless the server runs with AUTH_DISABLED=true, every endpoint except this card requires it.",
 			},
-			// TODO: Add apiKey, oauth2, or bearer schemes for production deployments
 		},
 
 		// Optional fields
@@ -3,9 +3,17 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/techbuzzz/agent-shaker/internal/a2a/models"
+	"github.com/techbuzzz/agent-shaker/internal/markdown"
+)
+
+// Content types an artifact can be served as
+const (
+	ContentTypeMarkdown = "text/markdown"
+	ContentTypeHTML     = "text/html"
 )
 
 // ContextStorage defines the interface for retrieving contexts
@@ -20,6 +28,7 @@ type ContextData struct {
 	Name        string
 	Content     string
 	Tags        []string
+	FrontMatter map[string]any // YAML front matter of the content
 	Description string
 	CreatedAt   string
 	UpdatedAt   string
@@ -58,9 +67,15 @@ func (h *ArtifactHandler) ListArtifacts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	contentType := requestedContentType(r)
 	artifacts := make([]models.Artifact, len(contexts))
 	for i, ctx := range contexts {
-		artifacts[i] = h.contextToArtifact(&ctx)
+		artifact, err := h.contextToArtifact(&ctx, contentType)
+		if err != nil {
+			h.writeError(w, "Failed to render artifact: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		artifacts[i] = artifact
 	}
 
 	resp := models.ArtifactListResponse{
@@ -97,27 +112,73 @@ func (h *ArtifactHandler) GetArtifact(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	artifact := h.contextToArtifact(ctx)
+	artifact, err := h.contextToArtifact(ctx, requestedContentType(r))
+	if err != nil {
+		h.writeError(w, "Failed to render artifact: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 	h.writeJSON(w, artifact, http.StatusOK)
 }
 
-// contextToArtifact converts a context to an A2A artifact
-func (h *ArtifactHandler) contextToArtifact(ctx *ContextData) models.Artifact {
-	return models.Artifact{
+// requestedContentType picks the artifact content type from ?format=html or
+// an Accept header asking for text/html. Markdown is the default.
+func requestedContentType(r *http.Request) string {
+	switch r.URL.Query().Get("format") {
+	case "html":
+		return ContentTypeHTML
+	case "markdown":
+		return ContentTypeMarkdown
+	}
+	if strings.Contains(r.Header.Get("Accept"), ContentTypeHTML) {
+		return ContentTypeHTML
+	}
+	return ContentTypeMarkdown
+}
+
+// contextToArtifact converts a context to an A2A artifact. As text/html the
+// content is sanitized HTML and the metadata carries its table of contents
+// and code blocks.
+func (h *ArtifactHandler) contextToArtifact(ctx *ContextData, contentType string) (models.Artifact, error) {
+	url := h.baseURL + "/a2a/v1/artifacts/" + ctx.ID
+	artifact := models.Artifact{
 		ID:          ctx.ID,
 		Name:        ctx.Name,
 		Type:        "markdown",
-		ContentType: "text/markdown",
+		ContentType: ContentTypeMarkdown,
 		Content:     ctx.Content,
-		URL:         h.baseURL + "/a2a/v1/artifacts/" + ctx.ID,
+		URL:         url,
 		Size:        int64(len(ctx.Content)),
 		CreatedAt:   ctx.CreatedAt,
 		Metadata: map[string]any{
 			"tags":        ctx.Tags,
 			"description": ctx.Description,
 			"updated_at":  ctx.UpdatedAt,
+			"alternates": map[string]string{
+				ContentTypeMarkdown: url + "?format=markdown",
+				ContentTypeHTML:     url + "?format=html",
+			},
 		},
 	}
+
+	if len(ctx.FrontMatter) > 0 {
+		artifact.Metadata["front_matter"] = ctx.FrontMatter
+	}
+
+	if contentType != ContentTypeHTML {
+		return artifact, nil
+	}
+
+	doc, err := markdown.Render(ctx.Content)
+	if err != nil {
+		return artifact, err
+	}
+	artifact.Type = "html"
+	artifact.ContentType = ContentTypeHTML
+	artifact.Content = doc.HTML
+	artifact.Size = int64(len(doc.HTML))
+	artifact.Metadata["toc"] = doc.TOC
+	artifact.Metadata["code_blocks"] = doc.CodeBlocks
+	return artifact, nil
 }
 
 // writeJSON writes a JSON response with the given status code
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/techbuzzz/agent-shaker/internal/database"
@@ -23,7 +24,7 @@ func (s *DatabaseContextStorage) ListContexts() ([]ContextData, error) {
 	}
 
 	rows, err := s.db.Query(`
-		SELECT id, title, content, tags, created_at, updated_at
+		SELECT id, title, content, tags, front_matter, created_at, updated_at
 		FROM contexts
 		ORDER BY created_at DESC
 	`)
@@ -35,11 +36,12 @@ func (s *DatabaseContextStorage) ListContexts() ([]ContextData, error) {
 	var contexts []ContextData
 	for rows.Next() {
 		var ctx ContextData
-		var tags []byte
+		var tags, frontMatter []byte
 
-		if err := rows.Scan(&ctx.ID, &ctx.Name, &ctx.Content, &tags, &ctx.CreatedAt, &ctx.UpdatedAt); err != nil {
+		if err := rows.Scan(&ctx.ID, &ctx.Name, &ctx.Content, &tags, &frontMatter, &ctx.CreatedAt, &ctx.UpdatedAt); err != nil {
 			continue
 		}
+		_ = json.Unmarshal(frontMatter, &ctx.FrontMatter)
 
 		// Parse tags if present
 		if len(tags) > 0 {
@@ -60,17 +62,18 @@ func (s *DatabaseContextStorage) GetContext(id string) (*ContextData, error) {
 	}
 
 	var ctx ContextData
-	var tags []byte
+	var tags, frontMatter []byte
 
 	err := s.db.QueryRow(`
-		SELECT id, title, content, tags, created_at, updated_at
+		SELECT id, title, content, tags, front_matter, created_at, updated_at
 		FROM contexts
 		WHERE id = $1
-	`, id).Scan(&ctx.ID, &ctx.Name, &ctx.Content, &tags, &ctx.CreatedAt, &ctx.UpdatedAt)
+	`, id).Scan(&ctx.ID, &ctx.Name, &ctx.Content, &tags, &frontMatter, &ctx.CreatedAt, &ctx.UpdatedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("context not found: %w", err)
 	}
+	_ = json.Unmarshal(frontMatter, &ctx.FrontMatter)
 
 	// Parse tags if present
 	if len(tags) > 0 {
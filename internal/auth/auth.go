@@ -0,0 +1,159 @@
+// Package auth authenticates requests with bearer API keys and carries the
+// resulting identity in the request context.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// KeyPrefix starts every agent API key
+const KeyPrefix = "shk_"
+
+// displayPrefixLength is how many leading characters of a key are kept to identify it
+const displayPrefixLength = 12
+
+// ErrInvalidKey is returned for a missing, unknown or revoked key
+var ErrInvalidKey = errors.New("invalid or revoked API key")
+
+// Identity is who an authenticated request acts as. The operator, who
+// authenticates with the admin key, has no agent.
+type Identity struct {
+	AgentID   *uuid.UUID
+	ProjectID uuid.UUID         // Project of the agent
+	Role      models.AccessRole // Access role of the agent
+	KeyID     uuid.UUID
+}
+
+// IsAdmin reports whether the identity is the operator rather than an agent
+func (i Identity) IsAdmin() bool {
+	return i.AgentID == nil
+}
+
+// GenerateKey returns a new random API key
+func GenerateKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return KeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// HashKey returns the hex SHA-256 hash under which a key is stored
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// DisplayPrefix returns the leading characters that identify a key in listings
+func DisplayPrefix(key string) string {
+	if len(key) <= displayPrefixLength {
+		return key
+	}
+	return key[:displayPrefixLength]
+}
+
+// Lookup resolves the hash of an agent key to its identity. It returns
+// ErrInvalidKey if no active key has that hash.
+type Lookup func(hash string) (Identity, error)
+
+// Authenticator checks the bearer key of requests against the admin key and
+// the agent keys known to lookup. A disabled authenticator lets every request
+// through without an identity.
+type Authenticator struct {
+	adminKeyHash string
+	lookup       Lookup
+	Disabled     bool
+}
+
+// NewAuthenticator creates an authenticator. An empty adminKey disables
+// operator access, leaving only agent keys.
+func NewAuthenticator(adminKey string, lookup Lookup) *Authenticator {
+	a := &Authenticator{lookup: lookup}
+	if adminKey != "" {
+		a.adminKeyHash = HashKey(adminKey)
+	}
+	return a
+}
+
+// Authenticate returns the identity of the key presented by r
+func (a *Authenticator) Authenticate(r *http.Request) (Identity, error) {
+	key := BearerToken(r)
+	if key == "" {
+		return Identity{}, ErrInvalidKey
+	}
+
+	hash := HashKey(key)
+	if a.adminKeyHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(a.adminKeyHash)) == 1 {
+		return Identity{}, nil
+	}
+	if !strings.HasPrefix(key, KeyPrefix) || a.lookup == nil {
+		return Identity{}, ErrInvalidKey
+	}
+	return a.lookup(hash)
+}
+
+// Middleware rejects requests without a valid key with 401 Unauthorized and
+// stores the identity of the others in their context. CORS preflight
+// requests pass through, as they never carry credentials.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.Disabled || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity, err := a.Authenticate(r)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidKey) {
+				log.Printf("Failed to authenticate request: %v", err)
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="agent-shaker"`)
+			http.Error(w, "Unauthorized: a valid API key is required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), identity)))
+	})
+}
+
+// BearerToken returns the key of the Authorization: Bearer header. Browsers
+// cannot set headers on WebSocket connections, so upgrade requests may pass
+// the key as the access_token query parameter instead.
+func BearerToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return ""
+		}
+		return strings.TrimSpace(token)
+	}
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return r.URL.Query().Get("access_token")
+	}
+	return ""
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying identity
+func NewContext(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+// FromContext returns the identity stored in ctx. It returns false when the
+// request was not authenticated, which only happens with authentication disabled.
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(contextKey{}).(Identity)
+	return identity, ok
+}
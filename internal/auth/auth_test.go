@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGenerateKey(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	other, _ := GenerateKey()
+	if !strings.HasPrefix(key, KeyPrefix) || key == other {
+		t.Errorf("Expected distinct keys starting with %s, got %s and %s", KeyPrefix, key, other)
+	}
+	if HashKey(key) != HashKey(key) || HashKey(key) == HashKey(other) || len(HashKey(key)) != 64 {
+		t.Errorf("Unexpected hash %s", HashKey(key))
+	}
+	if prefix := DisplayPrefix(key); len(prefix) != displayPrefixLength || !strings.HasPrefix(key, prefix) {
+		t.Errorf("Unexpected display prefix %s", prefix)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		upgrade string
+		url     string
+		want    string
+	}{
+		{name: "bearer", header: "Bearer shk_abc", url: "/api/tasks", want: "shk_abc"},
+		{name: "lowercase scheme", header: "bearer shk_abc", url: "/api/tasks", want: "shk_abc"},
+		{name: "basic", header: "Basic dXNlcjpwYXNz", url: "/api/tasks", want: ""},
+		{name: "query ignored", url: "/api/tasks?access_token=shk_abc", want: ""},
+		{name: "websocket query", upgrade: "websocket", url: "/ws?access_token=shk_abc", want: "shk_abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			if tt.upgrade != "" {
+				r.Header.Set("Upgrade", tt.upgrade)
+			}
+			if got := BearerToken(r); got != tt.want {
+				t.Errorf("BearerToken() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	agentKey, _ := GenerateKey()
+	revokedKey, _ := GenerateKey()
+	agentID, projectID := uuid.New(), uuid.New()
+	lookup := func(hash string) (Identity, error) {
+		if hash == HashKey(agentKey) {
+			return Identity{AgentID: &agentID, ProjectID: projectID}, nil
+		}
+		return Identity{}, ErrInvalidKey
+	}
+	a := NewAuthenticator("operator-secret", lookup)
+
+	var seen *Identity
+	handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := FromContext(r.Context())
+		if ok {
+			seen = &identity
+		}
+	}))
+	call := func(method, key string) int {
+		seen = nil
+		r := httptest.NewRequest(method, "/api/tasks", nil)
+		if key != "" {
+			r.Header.Set("Authorization", "Bearer "+key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := call(http.MethodGet, ""); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a key, got %d", code)
+	}
+	if code := call(http.MethodGet, revokedKey); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an unknown key, got %d", code)
+	}
+	if code := call(http.MethodGet, agentKey); code != http.StatusOK || seen == nil || seen.AgentID == nil || *seen.AgentID != agentID {
+		t.Errorf("Expected the agent identity, got %d %+v", code, seen)
+	}
+	if code := call(http.MethodGet, "operator-secret"); code != http.StatusOK || seen == nil || !seen.IsAdmin() {
+		t.Errorf("Expected the operator identity, got %d %+v", code, seen)
+	}
+	if code := call(http.MethodOptions, ""); code != http.StatusOK || seen != nil {
+		t.Errorf("Expected preflight requests to pass without an identity, got %d %+v", code, seen)
+	}
+
+	a.Disabled = true
+	if code := call(http.MethodGet, ""); code != http.StatusOK || seen != nil {
+		t.Errorf("Expected requests to pass when disabled, got %d %+v", code, seen)
+	}
+}
+
+func TestAuthenticateWithoutAdminKey(t *testing.T) {
+	a := NewAuthenticator("", func(string) (Identity, error) { return Identity{}, errors.New("unexpected lookup") })
+	r := httptest.NewRequest(http.MethodGet, "/api/tasks", nil)
+	r.Header.Set("Authorization", "Bearer ")
+	if _, err := a.Authenticate(r); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey for an empty key, got %v", err)
+	}
+	r.Header.Set("Authorization", "Bearer not-an-agent-key")
+	if _, err := a.Authenticate(r); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey without the agent key prefix, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// Permission is an action that only some access roles may take. Reading
+// needs no permission.
+type Permission string
+
+const (
+	// PermWorkOnTasks covers claiming tasks, changing the status of the
+	// agent's own tasks and commenting on tasks
+	PermWorkOnTasks Permission = "work on tasks"
+	// PermWriteContexts covers adding, editing, importing and deleting contexts
+	PermWriteContexts Permission = "write contexts"
+	// PermManageTasks covers creating, editing, reassigning, reopening and
+	// deleting any task of the project
+	PermManageTasks Permission = "manage tasks"
+	// PermManageProject covers deleting the project and granting access roles
+	PermManageProject Permission = "manage the project"
+)
+
+// rolePermissions lists what each access role may do. Observers only read.
+var rolePermissions = map[models.AccessRole][]Permission{
+	models.AccessCoordinator: {PermWorkOnTasks, PermWriteContexts, PermManageTasks, PermManageProject},
+	models.AccessWorker:      {PermWorkOnTasks, PermWriteContexts},
+}
+
+// Can reports whether an access role grants a permission
+func Can(role models.AccessRole, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// ForbiddenError is returned when an identity lacks the access an action needs
+type ForbiddenError struct {
+	Reason string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Reason
+}
+
+// Authorize checks that the identity may take an action needing perm in a
+// project. The operator may do anything; agents act only in their own
+// project and within their access role.
+func (i Identity) Authorize(projectID uuid.UUID, perm Permission) error {
+	if i.IsAdmin() {
+		return nil
+	}
+	if projectID != i.ProjectID {
+		return &ForbiddenError{Reason: "agents can only act in their own project"}
+	}
+	if !Can(i.Role, perm) {
+		return &ForbiddenError{Reason: fmt.Sprintf("%s agents cannot %s", i.Role, perm)}
+	}
+	return nil
+}
+
+// AuthorizeTask checks that the identity may work on a task of a project
+// that is assigned to assignedTo. Agents that cannot manage tasks may only
+// work on their own tasks, or claim unassigned ones when claiming is set.
+func (i Identity) AuthorizeTask(projectID uuid.UUID, assignedTo *uuid.UUID, claiming bool) error {
+	if err := i.Authorize(projectID, PermWorkOnTasks); err != nil {
+		return err
+	}
+	if i.IsAdmin() || Can(i.Role, PermManageTasks) {
+		return nil
+	}
+	if assignedTo == nil && claiming {
+		return nil
+	}
+	if assignedTo == nil || *assignedTo != *i.AgentID {
+		return &ForbiddenError{Reason: fmt.Sprintf("%s agents can only work on their own tasks", i.Role)}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+func TestCan(t *testing.T) {
+	tests := []struct {
+		role models.AccessRole
+		perm Permission
+		want bool
+	}{
+		{models.AccessCoordinator, PermManageProject, true},
+		{models.AccessCoordinator, PermManageTasks, true},
+		{models.AccessWorker, PermWorkOnTasks, true},
+		{models.AccessWorker, PermWriteContexts, true},
+		{models.AccessWorker, PermManageTasks, false},
+		{models.AccessWorker, PermManageProject, false},
+		{models.AccessObserver, PermWorkOnTasks, false},
+		{models.AccessObserver, PermWriteContexts, false},
+		{"", PermWorkOnTasks, false},
+	}
+	for _, tt := range tests {
+		if got := Can(tt.role, tt.perm); got != tt.want {
+			t.Errorf("Can(%q, %q) = %v, want %v", tt.role, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	agentID, projectID := uuid.New(), uuid.New()
+	worker := Identity{AgentID: &agentID, ProjectID: projectID, Role: models.AccessWorker}
+
+	if err := worker.Authorize(projectID, PermWorkOnTasks); err != nil {
+		t.Errorf("Expected workers to work on tasks, got %v", err)
+	}
+	var forbidden *ForbiddenError
+	if err := worker.Authorize(projectID, PermManageTasks); !errors.As(err, &forbidden) || forbidden.Reason != "worker agents cannot manage tasks" {
+		t.Errorf("Expected a ForbiddenError for managing tasks, got %v", err)
+	}
+	if err := worker.Authorize(uuid.New(), PermWorkOnTasks); err == nil {
+		t.Error("Expected agents to be confined to their project")
+	}
+	if err := (Identity{}).Authorize(uuid.New(), PermManageProject); err != nil {
+		t.Errorf("Expected the operator to be allowed everything, got %v", err)
+	}
+}
+
+func TestAuthorizeTask(t *testing.T) {
+	agentID, otherID, projectID := uuid.New(), uuid.New(), uuid.New()
+	worker := Identity{AgentID: &agentID, ProjectID: projectID, Role: models.AccessWorker}
+	coordinator := Identity{AgentID: &agentID, ProjectID: projectID, Role: models.AccessCoordinator}
+	observer := Identity{AgentID: &agentID, ProjectID: projectID, Role: models.AccessObserver}
+
+	tests := []struct {
+		name       string
+		identity   Identity
+		assignedTo *uuid.UUID
+		claiming   bool
+		wantErr    bool
+	}{
+		{"worker on own task", worker, &agentID, false, false},
+		{"worker on another agent's task", worker, &otherID, false, true},
+		{"worker claiming another agent's task", worker, &otherID, true, true},
+		{"worker claiming an unassigned task", worker, nil, true, false},
+		{"worker updating an unassigned task", worker, nil, false, true},
+		{"coordinator on another agent's task", coordinator, &otherID, false, false},
+		{"observer on own task", observer, &agentID, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.identity.AuthorizeTask(projectID, tt.assignedTo, tt.claiming)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AuthorizeTask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
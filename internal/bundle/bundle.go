@@ -0,0 +1,330 @@
+// Package bundle reads, writes and remaps project bundles, the portable
+// snapshots produced by project export and consumed by project import.
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// FormatVersion is the bundle format written by this server. Bundles with a
+// newer format are rejected on import.
+const FormatVersion = 1
+
+// FileName is the name of the bundle document inside a zip bundle
+const FileName = "bundle.json"
+
+// MaxSize is the largest bundle document Decode reads from a zip bundle
+const MaxSize = 64 << 20
+
+// Formats a bundle can be written in
+const (
+	FormatJSON = "json"
+	FormatZip  = "zip"
+)
+
+// Encode writes b as indented JSON, or as a zip archive holding it as
+// FileName when format is FormatZip
+func Encode(w io.Writer, b models.ProjectBundle, format string) error {
+	switch format {
+	case FormatJSON, "":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(b)
+	case FormatZip:
+		zw := zip.NewWriter(w)
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: FileName, Method: zip.Deflate, Modified: b.ExportedAt})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(b); err != nil {
+			return err
+		}
+		return zw.Close()
+	default:
+		return fmt.Errorf("unsupported bundle format %q, must be one of: json, zip", format)
+	}
+}
+
+// Decode reads a bundle written by Encode in either format
+func Decode(data []byte) (models.ProjectBundle, error) {
+	var b models.ProjectBundle
+
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return b, fmt.Errorf("invalid zip bundle: %w", err)
+		}
+		f, err := zr.Open(FileName)
+		if err != nil {
+			return b, fmt.Errorf("zip bundle has no %s", FileName)
+		}
+		defer f.Close()
+		data, err = io.ReadAll(io.LimitReader(f, MaxSize+1))
+		if err != nil {
+			return b, fmt.Errorf("invalid zip bundle: %w", err)
+		}
+		if len(data) > MaxSize {
+			return b, fmt.Errorf("%s is too large", FileName)
+		}
+	}
+
+	if err := json.Unmarshal(data, &b); err != nil {
+		return b, fmt.Errorf("invalid bundle: %w", err)
+	}
+	if b.FormatVersion == 0 {
+		return b, errors.New("invalid bundle: format_version is missing")
+	}
+	if b.FormatVersion > FormatVersion {
+		return b, fmt.Errorf("bundle format %d is newer than the supported format %d", b.FormatVersion, FormatVersion)
+	}
+	return b, nil
+}
+
+// contextIDLink matches agent-shaker://contexts/{id} links in context content
+var contextIDLink = regexp.MustCompile(`agent-shaker://contexts/([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})`)
+
+// Remap gives every project, agent, milestone, task, comment, context and standup of b
+// a new ID and rewrites the references between them, including ID links in
+// context content, so the bundle can be imported next to the project it was
+// exported from. Tasks and comments are reordered so parents come before
+// their children. It returns the old to new ID mapping, or an error if the
+// bundle references records it does not contain or its task parents or
+// dependencies form a cycle.
+func Remap(b *models.ProjectBundle) (map[uuid.UUID]uuid.UUID, error) {
+	ids := map[uuid.UUID]uuid.UUID{b.Project.ID: uuid.New()}
+	assign := func(kind string, id uuid.UUID) error {
+		if _, ok := ids[id]; ok {
+			return fmt.Errorf("duplicate %s id %s", kind, id)
+		}
+		ids[id] = uuid.New()
+		return nil
+	}
+	for _, a := range b.Agents {
+		if err := assign("agent", a.ID); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range b.Milestones {
+		if err := assign("milestone", m.ID); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range b.Tasks {
+		if err := assign("task", t.ID); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range b.TaskComments {
+		if err := assign("task comment", c.ID); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range b.Contexts {
+		if err := assign("context", c.ID); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range b.Standups {
+		if err := assign("standup", s.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	agents := map[uuid.UUID]bool{}
+	for _, a := range b.Agents {
+		agents[a.ID] = true
+	}
+	milestones := map[uuid.UUID]bool{}
+	for _, m := range b.Milestones {
+		milestones[m.ID] = true
+	}
+	tasks := map[uuid.UUID]bool{}
+	for _, t := range b.Tasks {
+		tasks[t.ID] = true
+	}
+
+	// required maps a reference that must point at a record of the bundle
+	required := func(kind string, set map[uuid.UUID]bool, id uuid.UUID) (uuid.UUID, error) {
+		if !set[id] {
+			return uuid.Nil, fmt.Errorf("bundle references unknown %s %s", kind, id)
+		}
+		return ids[id], nil
+	}
+	// optional maps a nullable reference, dropping it if its target is not in the bundle
+	optional := func(set map[uuid.UUID]bool, id *uuid.UUID) *uuid.UUID {
+		if id == nil || !set[*id] {
+			return nil
+		}
+		mapped := ids[*id]
+		return &mapped
+	}
+
+	var err error
+	projectID := ids[b.Project.ID]
+	b.Project.ID = projectID
+
+	for i := range b.Agents {
+		b.Agents[i].ID = ids[b.Agents[i].ID]
+		b.Agents[i].ProjectID = projectID
+	}
+
+	for i := range b.CustomFields {
+		b.CustomFields[i].ProjectID = projectID
+	}
+
+	for i := range b.Milestones {
+		b.Milestones[i].ID = ids[b.Milestones[i].ID]
+		b.Milestones[i].ProjectID = projectID
+	}
+
+	for i := range b.Tasks {
+		t := &b.Tasks[i]
+		if t.CreatedBy, err = required("agent", agents, t.CreatedBy); err != nil {
+			return nil, err
+		}
+		t.ID = ids[t.ID]
+		t.ProjectID = projectID
+		t.ParentID = optional(tasks, t.ParentID)
+		t.AssignedTo = optional(agents, t.AssignedTo)
+		t.MilestoneID = optional(milestones, t.MilestoneID)
+	}
+	if b.Tasks, err = parentsFirst(b.Tasks, func(t models.Task) (uuid.UUID, *uuid.UUID) { return t.ID, t.ParentID }); err != nil {
+		return nil, fmt.Errorf("task parents: %w", err)
+	}
+
+	deps := b.TaskDependencies[:0]
+	for _, d := range b.TaskDependencies {
+		if !tasks[d.TaskID] || !tasks[d.DependsOnID] {
+			continue
+		}
+		d.TaskID, d.DependsOnID = ids[d.TaskID], ids[d.DependsOnID]
+		deps = append(deps, d)
+	}
+	b.TaskDependencies = deps
+	if hasDependencyCycle(deps) {
+		return nil, errors.New("task dependencies: cycle detected")
+	}
+
+	comments := map[uuid.UUID]bool{}
+	for _, c := range b.TaskComments {
+		comments[c.ID] = true
+	}
+	for i := range b.TaskComments {
+		c := &b.TaskComments[i]
+		if c.TaskID, err = required("task", tasks, c.TaskID); err != nil {
+			return nil, err
+		}
+		if c.AuthorID, err = required("agent", agents, c.AuthorID); err != nil {
+			return nil, err
+		}
+		c.ID = ids[c.ID]
+		c.ProjectID = projectID
+		c.ReplyToID = optional(comments, c.ReplyToID)
+		c.Replies = nil
+	}
+	if b.TaskComments, err = parentsFirst(b.TaskComments, func(c models.TaskComment) (uuid.UUID, *uuid.UUID) { return c.ID, c.ReplyToID }); err != nil {
+		return nil, fmt.Errorf("task comment replies: %w", err)
+	}
+
+	contexts := map[string]string{}
+	for _, c := range b.Contexts {
+		contexts[c.ID.String()] = ids[c.ID].String()
+	}
+	for i := range b.Contexts {
+		c := &b.Contexts[i]
+		if c.AgentID, err = required("agent", agents, c.AgentID); err != nil {
+			return nil, err
+		}
+		c.ID = ids[c.ID]
+		c.ProjectID = projectID
+		c.TaskID = optional(tasks, c.TaskID)
+		c.Content = contextIDLink.ReplaceAllStringFunc(c.Content, func(link string) string {
+			old := contextIDLink.FindStringSubmatch(link)[1]
+			if mapped, ok := contexts[strings.ToLower(old)]; ok {
+				return "agent-shaker://contexts/" + mapped
+			}
+			return link
+		})
+	}
+
+	for i := range b.Standups {
+		s := &b.Standups[i]
+		if s.AgentID, err = required("agent", agents, s.AgentID); err != nil {
+			return nil, err
+		}
+		s.ID = ids[s.ID]
+		s.ProjectID = projectID
+	}
+
+	heartbeats := b.Heartbeats[:0]
+	for _, hb := range b.Heartbeats {
+		if agents[hb.AgentID] {
+			hb.AgentID = ids[hb.AgentID]
+			heartbeats = append(heartbeats, hb)
+		}
+	}
+	b.Heartbeats = heartbeats
+
+	return ids, nil
+}
+
+// hasDependencyCycle reports whether deps contain a task that depends on
+// itself, directly or through other tasks
+func hasDependencyCycle(deps []models.TaskDependency) bool {
+	// Repeatedly drop the tasks no remaining edge depends on; edges left over form a cycle
+	pending := deps
+	for len(pending) > 0 {
+		prerequisites := map[uuid.UUID]bool{}
+		for _, d := range pending {
+			prerequisites[d.DependsOnID] = true
+		}
+		var next []models.TaskDependency
+		for _, d := range pending {
+			if prerequisites[d.TaskID] {
+				next = append(next, d)
+			}
+		}
+		if len(next) == len(pending) {
+			return true
+		}
+		pending = next
+	}
+	return false
+}
+
+// parentsFirst orders items so every item comes after its parent. Parents
+// must be part of items or nil.
+func parentsFirst[T any](items []T, key func(T) (uuid.UUID, *uuid.UUID)) ([]T, error) {
+	ordered := make([]T, 0, len(items))
+	placed := map[uuid.UUID]bool{}
+	pending := items
+	for len(pending) > 0 {
+		var next []T
+		for _, item := range pending {
+			id, parent := key(item)
+			if parent == nil || placed[*parent] {
+				ordered = append(ordered, item)
+				placed[id] = true
+			} else {
+				next = append(next, item)
+			}
+		}
+		if len(next) == len(pending) {
+			return nil, errors.New("cycle detected")
+		}
+		pending = next
+	}
+	return ordered, nil
+}
@@ -0,0 +1,192 @@
+package bundle
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+func testBundle() models.ProjectBundle {
+	projectID, agentID, otherAgent := uuid.New(), uuid.New(), uuid.New()
+	parentID, childID := uuid.New(), uuid.New()
+	commentID, replyID := uuid.New(), uuid.New()
+	linkedID, milestoneID, otherMilestone := uuid.New(), uuid.New(), uuid.New()
+	target := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	return models.ProjectBundle{
+		FormatVersion: FormatVersion,
+		ExportedAt:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Project:       models.Project{ID: projectID, Name: "Shaker"},
+		Agents:        []models.Agent{{ID: agentID, ProjectID: projectID, Name: "backend"}},
+		CustomFields:  []models.CustomFieldDefinition{{ProjectID: projectID, Name: "points"}},
+		Milestones:    []models.Milestone{{ID: milestoneID, ProjectID: projectID, Name: "Beta", TargetDate: &target}},
+		// The child comes first to check that parents are moved before it
+		Tasks: []models.Task{
+			{ID: childID, ProjectID: projectID, ParentID: &parentID, CreatedBy: agentID, AssignedTo: &otherAgent, MilestoneID: &otherMilestone},
+			{ID: parentID, ProjectID: projectID, CreatedBy: agentID, AssignedTo: &agentID, MilestoneID: &milestoneID},
+		},
+		TaskDependencies: []models.TaskDependency{{TaskID: childID, DependsOnID: parentID}, {TaskID: childID, DependsOnID: uuid.New()}},
+		TaskComments: []models.TaskComment{
+			{ID: replyID, TaskID: parentID, AuthorID: agentID, ReplyToID: &commentID},
+			{ID: commentID, TaskID: parentID, AuthorID: agentID},
+		},
+		Contexts: []models.Context{
+			{ID: linkedID, ProjectID: projectID, AgentID: agentID, TaskID: &childID, Title: "Target"},
+			{ID: uuid.New(), ProjectID: projectID, AgentID: agentID, Title: "Source",
+				Content: "See agent-shaker://contexts/" + linkedID.String() + " and agent-shaker://contexts/" + otherAgent.String()},
+		},
+		Standups:   []models.DailyStandup{{ID: uuid.New(), AgentID: agentID, ProjectID: projectID}},
+		Heartbeats: []models.HeartbeatSummary{{AgentID: agentID, Count: 3}, {AgentID: otherAgent, Count: 1}},
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatZip} {
+		b := testBundle()
+		var buf bytes.Buffer
+		if err := Encode(&buf, b, format); err != nil {
+			t.Fatalf("Encode(%s) failed: %v", format, err)
+		}
+
+		decoded, err := Decode(buf.Bytes())
+		if err != nil {
+			t.Fatalf("Decode(%s) failed: %v", format, err)
+		}
+		if decoded.Project.ID != b.Project.ID || len(decoded.Tasks) != 2 || len(decoded.Contexts) != 2 {
+			t.Errorf("%s bundle did not round trip: %+v", format, decoded)
+		}
+	}
+
+	if err := Encode(&bytes.Buffer{}, testBundle(), "tar"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestDecodeRejectsFormatVersion(t *testing.T) {
+	tests := map[string]string{
+		"missing": `{"project": {"name": "x"}}`,
+		"newer":   `{"format_version": 99}`,
+		"invalid": `{"format_version": `,
+	}
+	for name, data := range tests {
+		if _, err := Decode([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRemap(t *testing.T) {
+	original := testBundle()
+	// Remap rewrites the bundle in place, so work on a copy of the original
+	var buf bytes.Buffer
+	if err := Encode(&buf, original, FormatJSON); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	b, err := Decode(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	ids, err := Remap(&b)
+	if err != nil {
+		t.Fatalf("Remap failed: %v", err)
+	}
+
+	projectID := ids[original.Project.ID]
+	if projectID == original.Project.ID || b.Project.ID != projectID {
+		t.Fatalf("Expected a new project ID, got %s", b.Project.ID)
+	}
+	agentID := ids[original.Agents[0].ID]
+	if b.Agents[0].ID != agentID || b.Agents[0].ProjectID != projectID || b.CustomFields[0].ProjectID != projectID {
+		t.Errorf("Agent or custom field not remapped: %+v %+v", b.Agents[0], b.CustomFields[0])
+	}
+
+	parentID, childID := ids[original.Tasks[1].ID], ids[original.Tasks[0].ID]
+	if b.Tasks[0].ID != parentID || b.Tasks[1].ID != childID {
+		t.Fatalf("Expected the parent task before its child, got %s, %s", b.Tasks[0].ID, b.Tasks[1].ID)
+	}
+	if b.Tasks[1].ParentID == nil || *b.Tasks[1].ParentID != parentID || b.Tasks[1].CreatedBy != agentID {
+		t.Errorf("Child task references not remapped: %+v", b.Tasks[1])
+	}
+	if b.Tasks[1].AssignedTo != nil {
+		t.Errorf("Expected an assignee outside the bundle to be dropped, got %s", b.Tasks[1].AssignedTo)
+	}
+	if b.Tasks[0].AssignedTo == nil || *b.Tasks[0].AssignedTo != agentID {
+		t.Errorf("Expected the parent task to stay assigned to %s", agentID)
+	}
+
+	milestoneID := ids[original.Milestones[0].ID]
+	if b.Milestones[0].ID != milestoneID || b.Milestones[0].ProjectID != projectID {
+		t.Errorf("Milestone not remapped: %+v", b.Milestones[0])
+	}
+	if b.Tasks[0].MilestoneID == nil || *b.Tasks[0].MilestoneID != milestoneID {
+		t.Errorf("Expected the parent task to stay in milestone %s", milestoneID)
+	}
+	if b.Tasks[1].MilestoneID != nil {
+		t.Errorf("Expected a milestone outside the bundle to be dropped, got %s", b.Tasks[1].MilestoneID)
+	}
+
+	if len(b.TaskDependencies) != 1 || b.TaskDependencies[0].TaskID != childID || b.TaskDependencies[0].DependsOnID != parentID {
+		t.Errorf("Expected only the dependency inside the bundle, got %+v", b.TaskDependencies)
+	}
+
+	commentID := ids[original.TaskComments[1].ID]
+	if b.TaskComments[0].ID != commentID || b.TaskComments[1].ReplyToID == nil || *b.TaskComments[1].ReplyToID != commentID {
+		t.Errorf("Expected the comment before its reply, got %+v", b.TaskComments)
+	}
+
+	linkedID := ids[original.Contexts[0].ID]
+	if b.Contexts[0].TaskID == nil || *b.Contexts[0].TaskID != childID {
+		t.Errorf("Context task not remapped: %+v", b.Contexts[0])
+	}
+	content := b.Contexts[1].Content
+	if !strings.Contains(content, "agent-shaker://contexts/"+linkedID.String()) {
+		t.Errorf("Expected the context link to be rewritten, got %q", content)
+	}
+	if !strings.Contains(content, original.Tasks[0].AssignedTo.String()) {
+		t.Errorf("Expected links outside the bundle to be kept, got %q", content)
+	}
+
+	if b.Standups[0].AgentID != agentID || b.Standups[0].ProjectID != projectID {
+		t.Errorf("Standup not remapped: %+v", b.Standups[0])
+	}
+	if len(b.Heartbeats) != 1 || b.Heartbeats[0].AgentID != agentID {
+		t.Errorf("Expected the heartbeat summary of the bundled agent, got %+v", b.Heartbeats)
+	}
+}
+
+func TestRemapRejectsBrokenBundles(t *testing.T) {
+	unknownAuthor := testBundle()
+	unknownAuthor.Tasks[1].CreatedBy = uuid.New()
+
+	duplicate := testBundle()
+	duplicate.Contexts[1].ID = duplicate.Contexts[0].ID
+
+	cycle := testBundle()
+	childID := cycle.Tasks[0].ID
+	cycle.Tasks[1].ParentID = &childID
+
+	dependencyCycle := testBundle()
+	dependencyCycle.TaskDependencies = append(dependencyCycle.TaskDependencies, models.TaskDependency{
+		TaskID: dependencyCycle.Tasks[1].ID, DependsOnID: dependencyCycle.Tasks[0].ID,
+	})
+
+	selfDependency := testBundle()
+	selfDependency.TaskDependencies = []models.TaskDependency{{TaskID: selfDependency.Tasks[0].ID, DependsOnID: selfDependency.Tasks[0].ID}}
+
+	for name, b := range map[string]models.ProjectBundle{
+		"unknown author":   unknownAuthor,
+		"duplicate":        duplicate,
+		"cycle":            cycle,
+		"dependency cycle": dependencyCycle,
+		"self dependency":  selfDependency,
+	} {
+		if _, err := Remap(&b); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
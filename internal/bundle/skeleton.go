@@ -0,0 +1,103 @@
+package bundle
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// SkeletonOptions selects what a cloned project keeps of its source
+type SkeletonOptions struct {
+	Name         string
+	Description  string
+	IncludeTasks bool
+	// KeepContext reports whether a context is copied; nil copies none
+	KeepContext func(models.Context) bool
+}
+
+// Skeleton reduces an exported project to the starting point of a new
+// project: the agent roster, custom fields, milestones without their target
+// dates, the selected contexts and, optionally, the task tree with statuses,
+// assignments and outputs reset.
+// Comments, standups and heartbeats are dropped. Everything is dated now, and
+// due dates are recomputed from the project's SLA. Remap the result before
+// importing it.
+func Skeleton(b *models.ProjectBundle, opts SkeletonOptions, now time.Time) {
+	b.Project.Name = opts.Name
+	b.Project.Description = opts.Description
+	b.Project.Status = "active"
+	b.Project.IsTemplate = false
+	b.Project.CreatedAt, b.Project.UpdatedAt = now, now
+
+	for i := range b.Agents {
+		b.Agents[i].Status = "offline"
+		b.Agents[i].LastSeen, b.Agents[i].CreatedAt = now, now
+	}
+
+	for i := range b.CustomFields {
+		b.CustomFields[i].CreatedAt = now
+	}
+
+	for i := range b.Milestones {
+		b.Milestones[i].TargetDate = nil
+		b.Milestones[i].CreatedAt, b.Milestones[i].UpdatedAt = now, now
+	}
+
+	if opts.IncludeTasks {
+		for i := range b.Tasks {
+			t := &b.Tasks[i]
+			t.Status = models.StatusPending
+			t.AssignedTo = nil
+			t.Output = ""
+			t.ClaimExpiresAt = nil
+			t.DueAt = b.Project.SLAHours.DueAt(t.Priority, now)
+			t.CreatedAt, t.UpdatedAt = now, now
+		}
+		for i := range b.TaskDependencies {
+			b.TaskDependencies[i].CreatedAt = now
+		}
+	} else {
+		b.Tasks = nil
+		b.TaskDependencies = nil
+	}
+
+	var contexts []models.Context
+	for _, c := range b.Contexts {
+		if opts.KeepContext == nil || !opts.KeepContext(c) {
+			continue
+		}
+		c.CreatedAt, c.UpdatedAt = now, now
+		contexts = append(contexts, c)
+	}
+	b.Contexts = contexts
+
+	b.TaskComments = nil
+	b.Standups = nil
+	b.Heartbeats = nil
+}
+
+// SelectContexts returns a KeepContext function matching contexts by ID or by
+// any of the tags
+func SelectContexts(ids []uuid.UUID, tags []string) func(models.Context) bool {
+	wantIDs := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		wantIDs[id] = true
+	}
+	wantTags := map[string]bool{}
+	for _, tag := range tags {
+		wantTags[tag] = true
+	}
+
+	return func(c models.Context) bool {
+		if wantIDs[c.ID] {
+			return true
+		}
+		for _, tag := range c.Tags {
+			if wantTags[tag] {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,83 @@
+package bundle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+func TestSkeleton(t *testing.T) {
+	b := testBundle()
+	b.Project.IsTemplate = true
+	b.Project.SLAHours = models.SLAHours{"high": 24}
+	b.Tasks[0].Status = models.StatusCompleted
+	b.Tasks[0].Output = "done"
+	b.Tasks[0].Priority = "high"
+	b.Contexts[1].Tags = []string{"onboarding"}
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	Skeleton(&b, SkeletonOptions{
+		Name:         "Copy",
+		IncludeTasks: true,
+		KeepContext:  SelectContexts(nil, []string{"onboarding"}),
+	}, now)
+
+	if b.Project.Name != "Copy" || b.Project.IsTemplate || b.Project.Status != "active" {
+		t.Errorf("Unexpected project %+v", b.Project)
+	}
+	if b.Agents[0].Status != "offline" || !b.Agents[0].CreatedAt.Equal(now) {
+		t.Errorf("Expected the agent to be reset, got %+v", b.Agents[0])
+	}
+
+	task := b.Tasks[0]
+	if task.Status != models.StatusPending || task.Output != "" || task.AssignedTo != nil {
+		t.Errorf("Expected the task to be reset, got %+v", task)
+	}
+	if task.DueAt == nil || !task.DueAt.Equal(now.Add(24*time.Hour)) {
+		t.Errorf("Expected the due date from the SLA, got %v", task.DueAt)
+	}
+	if b.Tasks[1].DueAt != nil {
+		t.Errorf("Expected no due date without an SLA, got %v", b.Tasks[1].DueAt)
+	}
+	if len(b.Milestones) != 1 || b.Milestones[0].TargetDate != nil || b.Tasks[1].MilestoneID == nil {
+		t.Errorf("Expected the milestone to be kept without its target date, got %+v", b.Milestones)
+	}
+	if len(b.TaskDependencies) != 2 {
+		t.Errorf("Expected dependencies to be kept, got %+v", b.TaskDependencies)
+	}
+
+	if len(b.Contexts) != 1 || b.Contexts[0].Title != "Source" {
+		t.Errorf("Expected only the selected context, got %+v", b.Contexts)
+	}
+	if b.TaskComments != nil || b.Standups != nil || b.Heartbeats != nil {
+		t.Error("Expected comments, standups and heartbeats to be dropped")
+	}
+
+	// The skeleton must still remap cleanly
+	if _, err := Remap(&b); err != nil {
+		t.Fatalf("Remap failed: %v", err)
+	}
+}
+
+func TestSkeletonWithoutTasks(t *testing.T) {
+	b := testBundle()
+	keep := b.Contexts[0].ID
+	Skeleton(&b, SkeletonOptions{Name: "Copy", KeepContext: SelectContexts([]uuid.UUID{keep}, nil)}, time.Now())
+
+	if b.Tasks != nil || b.TaskDependencies != nil {
+		t.Errorf("Expected no tasks, got %+v", b.Tasks)
+	}
+	if len(b.Contexts) != 1 || b.Contexts[0].ID != keep {
+		t.Fatalf("Expected the context selected by ID, got %+v", b.Contexts)
+	}
+
+	// The context's task is not copied, so the reference is dropped
+	if _, err := Remap(&b); err != nil {
+		t.Fatalf("Remap failed: %v", err)
+	}
+	if b.Contexts[0].TaskID != nil {
+		t.Errorf("Expected the context's task to be dropped, got %s", b.Contexts[0].TaskID)
+	}
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// hourlyActivity selects the hourly activity of agents computed from the raw
+// heartbeats they sent after their last rolled up hour and that match
+// filter. Each heartbeat counts as activity until the next one, the end of
+// its hour or the idle threshold of the agent's project, whichever is first.
+func hourlyActivity(filter string) string {
+	return `
+		SELECT agent_id, bucket_start, COUNT(*) AS heartbeats,
+		       COALESCE(SUM(EXTRACT(EPOCH FROM LEAST(next_time, bucket_start + INTERVAL '1 hour', heartbeat_time + idle_after) - heartbeat_time)), 0)::int AS active_seconds
+		FROM (
+			SELECT h.agent_id, h.heartbeat_time, date_trunc('hour', h.heartbeat_time) AS bucket_start,
+			       LEAD(h.heartbeat_time) OVER (PARTITION BY h.agent_id ORDER BY h.heartbeat_time) AS next_time,
+			       ` + idleAfter + ` AS idle_after
+			FROM agent_heartbeats h
+			JOIN agents a ON a.id = h.agent_id
+			JOIN projects p ON p.id = a.project_id
+			WHERE h.heartbeat_time >= COALESCE((
+				SELECT MAX(r.bucket_start) + INTERVAL '1 hour' FROM agent_activity r
+				WHERE r.agent_id = h.agent_id AND r.granularity = 'hour'
+			), '-infinity')
+			  AND ` + filter + `
+		) beats
+		GROUP BY agent_id, bucket_start`
+}
+
+// HeartbeatRollup reports what a rollup pass did
+type HeartbeatRollup struct {
+	HoursRolled  int
+	RawDeleted   int64
+	HoursDeleted int64
+}
+
+// RollupHeartbeats rolls the raw heartbeats of every completed hour into
+// hourly activity buckets, refreshes the daily buckets of the affected days,
+// then deletes raw heartbeats older than rawRetention and hourly buckets
+// older than hourlyRetention. Daily buckets are kept. Run it in a
+// transaction.
+func RollupHeartbeats(q Querier, rawRetention, hourlyRetention time.Duration) (HeartbeatRollup, error) {
+	var result HeartbeatRollup
+
+	rolled, err := scanAll(q, func(row RowScanner) (time.Time, error) {
+		var start time.Time
+		err := row.Scan(&start)
+		return start, err
+	}, `
+		INSERT INTO agent_activity (agent_id, granularity, bucket_start, heartbeats, active_seconds)
+		SELECT agent_id, 'hour', bucket_start, heartbeats, active_seconds
+		FROM (`+hourlyActivity(`h.heartbeat_time < date_trunc('hour', NOW())`)+`) hourly
+		ON CONFLICT (agent_id, granularity, bucket_start)
+		DO UPDATE SET heartbeats = EXCLUDED.heartbeats, active_seconds = EXCLUDED.active_seconds
+		RETURNING bucket_start
+	`)
+	if err != nil {
+		return result, fmt.Errorf("failed to roll up heartbeats: %w", err)
+	}
+	result.HoursRolled = len(rolled)
+
+	if len(rolled) > 0 {
+		since := rolled[0]
+		for _, start := range rolled {
+			if start.Before(since) {
+				since = start
+			}
+		}
+		if _, err := q.Exec(`
+			INSERT INTO agent_activity (agent_id, granularity, bucket_start, heartbeats, active_seconds)
+			SELECT agent_id, 'day', date_trunc('day', bucket_start), SUM(heartbeats), SUM(active_seconds)
+			FROM agent_activity
+			WHERE granularity = 'hour' AND bucket_start >= date_trunc('day', $1::timestamp)
+			GROUP BY agent_id, date_trunc('day', bucket_start)
+			ON CONFLICT (agent_id, granularity, bucket_start)
+			DO UPDATE SET heartbeats = EXCLUDED.heartbeats, active_seconds = EXCLUDED.active_seconds
+		`, since); err != nil {
+			return result, fmt.Errorf("failed to roll up daily activity: %w", err)
+		}
+	}
+
+	// Only completed hours are deleted, and those were just rolled up
+	deleted, err := q.Exec(`
+		DELETE FROM agent_heartbeats
+		WHERE heartbeat_time < NOW() - $1::int * INTERVAL '1 second' AND heartbeat_time < date_trunc('hour', NOW())
+	`, int64(rawRetention.Seconds()))
+	if err != nil {
+		return result, fmt.Errorf("failed to delete raw heartbeats: %w", err)
+	}
+	result.RawDeleted, _ = deleted.RowsAffected()
+
+	deleted, err = q.Exec(`
+		DELETE FROM agent_activity WHERE granularity = 'hour' AND bucket_start < NOW() - $1::int * INTERVAL '1 second'
+	`, int64(hourlyRetention.Seconds()))
+	if err != nil {
+		return result, fmt.Errorf("failed to delete hourly activity: %w", err)
+	}
+	result.HoursDeleted, _ = deleted.RowsAffected()
+	return result, nil
+}
+
+// ListAgentActivity returns the activity buckets of an agent starting in the
+// buckets of granularity g between from and to, oldest first. Hours that are
+// not rolled up yet are computed from the raw heartbeats, so the current
+// hour is included.
+func ListAgentActivity(q Querier, agentID uuid.UUID, g models.ActivityGranularity, from, to time.Time) ([]models.ActivityBucket, error) {
+	return scanAll(q, func(row RowScanner) (models.ActivityBucket, error) {
+		var b models.ActivityBucket
+		err := row.Scan(&b.Start, &b.Heartbeats, &b.ActiveSeconds)
+		return b, err
+	}, `
+		SELECT date_trunc($2, bucket_start) AS start, SUM(heartbeats)::int, SUM(active_seconds)::int
+		FROM (
+			SELECT bucket_start, heartbeats, active_seconds
+			FROM agent_activity
+			WHERE agent_id = $1 AND granularity = $2
+			UNION ALL
+			SELECT bucket_start, heartbeats, active_seconds
+			FROM (`+hourlyActivity(`h.agent_id = $1`)+`) live
+		) buckets
+		WHERE bucket_start >= date_trunc($2, $3::timestamp) AND bucket_start < $4
+		GROUP BY 1
+		ORDER BY 1
+	`, agentID, string(g), from, to)
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// AgentColumns is the column list read by ScanAgent
+const AgentColumns = `id, project_id, name, role, access_role, team, skills, capacity, status, last_seen, created_at`
+
+// ScanAgent scans a row selected with AgentColumns into an Agent
+func ScanAgent(row RowScanner) (models.Agent, error) {
+	var a models.Agent
+	err := row.Scan(&a.ID, &a.ProjectID, &a.Name, &a.Role, &a.AccessRole, &a.Team, &a.Skills, &a.Capacity, &a.Status, &a.LastSeen, &a.CreatedAt)
+	return a, err
+}
+
+// GetAgent loads a single agent by ID. It returns sql.ErrNoRows if the agent does not exist.
+func GetAgent(q Querier, id uuid.UUID) (models.Agent, error) {
+	return ScanAgent(q.QueryRow(`SELECT `+AgentColumns+` FROM agents WHERE id = $1`, id))
+}
+
+// ListAssignmentCandidates returns the agents of a project with the number
+// of open (pending, in progress or blocked) tasks assigned to each
+func ListAssignmentCandidates(q Querier, projectID uuid.UUID) ([]models.AssignmentCandidate, error) {
+	return scanAll(q, func(row RowScanner) (models.AssignmentCandidate, error) {
+		var c models.AssignmentCandidate
+		var err error
+		c.Agent, err = ScanAgent(appendScanner{row: row, extra: []interface{}{&c.Load}})
+		return c, err
+	}, `
+		SELECT `+AgentColumns+`,
+		       (SELECT COUNT(*) FROM tasks t WHERE t.assigned_to = agents.id AND t.status IN ($2, $3, $4))
+		FROM agents
+		WHERE project_id = $1
+	`, projectID, models.StatusPending, models.StatusInProgress, models.StatusBlocked)
+}
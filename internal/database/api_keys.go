@@ -0,0 +1,101 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// APIKeyColumns is the column list read by ScanAPIKey
+const APIKeyColumns = `id, agent_id, name, prefix, created_at, last_used_at, revoked_at`
+
+// ScanAPIKey scans a row selected with APIKeyColumns into an APIKey
+func ScanAPIKey(row RowScanner) (models.APIKey, error) {
+	var k models.APIKey
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&k.ID, &k.AgentID, &k.Name, &k.Prefix, &k.CreatedAt, &lastUsedAt, &revokedAt)
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	return k, err
+}
+
+// CreateAPIKey issues a new key for an agent. The returned secret is not
+// stored and cannot be retrieved later.
+func CreateAPIKey(q Querier, agentID uuid.UUID, name string) (models.IssuedAPIKey, error) {
+	key, err := auth.GenerateKey()
+	if err != nil {
+		return models.IssuedAPIKey{}, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	issued := models.IssuedAPIKey{Key: key}
+	issued.APIKey, err = ScanAPIKey(q.QueryRow(`
+		INSERT INTO agent_api_keys (id, agent_id, name, prefix, key_hash)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+APIKeyColumns,
+		uuid.New(), agentID, name, auth.DisplayPrefix(key), auth.HashKey(key)))
+	return issued, err
+}
+
+// ListAPIKeys returns every key of an agent, revoked ones included, oldest first
+func ListAPIKeys(q Querier, agentID uuid.UUID) ([]models.APIKey, error) {
+	return scanAll(q, ScanAPIKey, `
+		SELECT `+APIKeyColumns+` FROM agent_api_keys WHERE agent_id = $1 ORDER BY created_at, id
+	`, agentID)
+}
+
+// RevokeAPIKey revokes an active key of an agent. It returns sql.ErrNoRows
+// if the agent has no such active key.
+func RevokeAPIKey(q Querier, agentID, keyID uuid.UUID) (models.APIKey, error) {
+	return ScanAPIKey(q.QueryRow(`
+		UPDATE agent_api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND agent_id = $2 AND revoked_at IS NULL
+		RETURNING `+APIKeyColumns,
+		keyID, agentID))
+}
+
+// RotateAPIKey revokes an active key of an agent and issues a replacement
+// with the same name. It returns sql.ErrNoRows if the agent has no such
+// active key. Run it in a transaction.
+func RotateAPIKey(q Querier, agentID, keyID uuid.UUID) (models.IssuedAPIKey, error) {
+	revoked, err := RevokeAPIKey(q, agentID, keyID)
+	if err != nil {
+		return models.IssuedAPIKey{}, err
+	}
+	return CreateAPIKey(q, agentID, revoked.Name)
+}
+
+// AuthenticateAPIKey resolves the hash of a key to the identity of its
+// agent and records that the key was used. It returns auth.ErrInvalidKey if
+// no active key has that hash.
+func AuthenticateAPIKey(q Querier, hash string) (auth.Identity, error) {
+	var identity auth.Identity
+	var agentID uuid.UUID
+	err := q.QueryRow(`
+		SELECT k.id, k.agent_id, a.project_id, a.access_role
+		FROM agent_api_keys k
+		JOIN agents a ON a.id = k.agent_id
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL
+	`, hash).Scan(&identity.KeyID, &agentID, &identity.ProjectID, &identity.Role)
+	if err == sql.ErrNoRows {
+		return identity, auth.ErrInvalidKey
+	} else if err != nil {
+		return identity, fmt.Errorf("failed to look up API key: %w", err)
+	}
+	identity.AgentID = &agentID
+
+	// Usage is recorded at most once a minute to keep authentication read-mostly
+	if _, err := q.Exec(`
+		UPDATE agent_api_keys SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`, identity.KeyID); err != nil {
+		return identity, fmt.Errorf("failed to record API key use: %w", err)
+	}
+	return identity, nil
+}
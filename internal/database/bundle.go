@@ -0,0 +1,295 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/techbuzzz/agent-shaker/internal/bundle"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// ExportProject reads everything a project bundle holds. The caller sets the
+// bundle's format version. It returns sql.ErrNoRows if the project does not exist.
+func ExportProject(q Querier, projectID uuid.UUID) (models.ProjectBundle, error) {
+	b := models.ProjectBundle{ExportedAt: time.Now()}
+
+	var err error
+	if b.Project, err = GetProject(q, projectID); err != nil {
+		return b, err
+	}
+
+	if b.Agents, err = scanAll(q, ScanAgent, `
+		SELECT `+AgentColumns+` FROM agents WHERE project_id = $1 ORDER BY created_at, id
+	`, projectID); err != nil {
+		return b, fmt.Errorf("failed to export agents: %w", err)
+	}
+
+	if b.CustomFields, err = ListCustomFieldDefinitions(q, projectID); err != nil {
+		return b, err
+	}
+
+	if b.Milestones, err = scanAll(q, ScanMilestone, `
+		SELECT `+MilestoneColumns+` FROM milestones WHERE project_id = $1 ORDER BY created_at, id
+	`, projectID); err != nil {
+		return b, fmt.Errorf("failed to export milestones: %w", err)
+	}
+
+	if b.Tasks, err = scanAll(q, ScanTask, `
+		SELECT `+TaskColumns+` FROM tasks WHERE project_id = $1 ORDER BY created_at, id
+	`, projectID); err != nil {
+		return b, fmt.Errorf("failed to export tasks: %w", err)
+	}
+
+	if b.TaskDependencies, err = scanAll(q, func(row RowScanner) (models.TaskDependency, error) {
+		var d models.TaskDependency
+		err := row.Scan(&d.TaskID, &d.DependsOnID, &d.CreatedAt)
+		return d, err
+	}, `
+		SELECT d.task_id, d.depends_on_id, d.created_at
+		FROM task_dependencies d
+		JOIN tasks t ON t.id = d.task_id
+		WHERE t.project_id = $1
+		ORDER BY d.created_at
+	`, projectID); err != nil {
+		return b, fmt.Errorf("failed to export task dependencies: %w", err)
+	}
+
+	if b.TaskComments, err = scanAll(q, ScanTaskComment, `
+		SELECT `+TaskCommentColumns+` FROM task_comments WHERE project_id = $1 ORDER BY created_at, id
+	`, projectID); err != nil {
+		return b, fmt.Errorf("failed to export task comments: %w", err)
+	}
+
+	if b.Contexts, err = scanAll(q, ScanContext, `
+		SELECT `+ContextColumns+` FROM contexts WHERE project_id = $1 ORDER BY created_at, id
+	`, projectID); err != nil {
+		return b, fmt.Errorf("failed to export contexts: %w", err)
+	}
+
+	if b.Standups, err = scanAll(q, func(row RowScanner) (models.DailyStandup, error) {
+		var s models.DailyStandup
+		err := row.Scan(&s.ID, &s.AgentID, &s.ProjectID, &s.StandupDate, &s.Did, &s.Doing, &s.Done,
+			&s.Blockers, &s.Challenges, &s.ReferenceLinks, &s.CreatedAt, &s.UpdatedAt)
+		return s, err
+	}, `
+		SELECT id, agent_id, project_id, standup_date, did, doing, done,
+		       COALESCE(blockers, ''), COALESCE(challenges, ''), COALESCE(reference_links, ''), created_at, updated_at
+		FROM daily_standups
+		WHERE project_id = $1
+		ORDER BY standup_date, created_at
+	`, projectID); err != nil {
+		return b, fmt.Errorf("failed to export standups: %w", err)
+	}
+
+	if b.Heartbeats, err = scanAll(q, func(row RowScanner) (models.HeartbeatSummary, error) {
+		var hb models.HeartbeatSummary
+		err := row.Scan(&hb.AgentID, &hb.Count, &hb.FirstAt, &hb.LastAt, &hb.LastStatus)
+		return hb, err
+	}, `
+		SELECT h.agent_id, COUNT(*), MIN(h.heartbeat_time), MAX(h.heartbeat_time),
+		       COALESCE((ARRAY_AGG(h.status ORDER BY h.heartbeat_time DESC))[1], '')
+		FROM agent_heartbeats h
+		JOIN agents a ON a.id = h.agent_id
+		WHERE a.project_id = $1
+		GROUP BY h.agent_id
+		ORDER BY h.agent_id
+	`, projectID); err != nil {
+		return b, fmt.Errorf("failed to export heartbeats: %w", err)
+	}
+
+	return b, nil
+}
+
+// ImportProject creates the records of a bundle already remapped to new IDs.
+// Tasks and comments must come before their children. Each context starts a
+// new history with its bundled content as revision 1.
+func ImportProject(q Querier, b models.ProjectBundle) error {
+	p := b.Project
+	if p.SLAHours == nil {
+		p.SLAHours = models.SLAHours{}
+	}
+	if p.ClaimLeaseSeconds <= 0 {
+		p.ClaimLeaseSeconds = models.DefaultClaimLeaseSeconds
+	}
+	slaJSON, err := json.Marshal(p.SLAHours)
+	if err != nil {
+		return fmt.Errorf("failed to serialize sla_hours: %w", err)
+	}
+	settingsJSON, err := ProjectSettingsJSON(p.Settings)
+	if err != nil {
+		return fmt.Errorf("failed to serialize settings: %w", err)
+	}
+	if _, err := q.Exec(`
+		INSERT INTO projects (id, name, description, status, claim_lease_seconds, priority_sla_hours, is_template, settings, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, p.ID, p.Name, p.Description, p.Status, p.ClaimLeaseSeconds, slaJSON, p.IsTemplate, settingsJSON, p.CreatedAt, p.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to import project: %w", err)
+	}
+
+	for _, a := range b.Agents {
+		// Bundles exported before access roles existed have none
+		if !a.AccessRole.Valid() {
+			a.AccessRole = models.AccessWorker
+		}
+		if _, err := q.Exec(`
+			INSERT INTO agents (id, project_id, name, role, access_role, team, skills, capacity, status, last_seen, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`, a.ID, a.ProjectID, a.Name, a.Role, a.AccessRole, a.Team, pq.Array(models.NormalizeSkills(a.Skills)), a.Capacity, a.Status, a.LastSeen, a.CreatedAt); err != nil {
+			return fmt.Errorf("failed to import agent %q: %w", a.Name, err)
+		}
+	}
+
+	for _, f := range b.CustomFields {
+		if _, err := q.Exec(`
+			INSERT INTO custom_field_definitions (project_id, name, field_type, options, required, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, f.ProjectID, f.Name, f.Type, pq.Array(f.Options), f.Required, f.CreatedAt); err != nil {
+			return fmt.Errorf("failed to import custom field %q: %w", f.Name, err)
+		}
+	}
+
+	for _, m := range b.Milestones {
+		if _, err := q.Exec(`
+			INSERT INTO milestones (id, project_id, name, description, target_date, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, m.ID, m.ProjectID, m.Name, m.Description, m.TargetDate, m.CreatedAt, m.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to import milestone %q: %w", m.Name, err)
+		}
+	}
+
+	for _, t := range b.Tasks {
+		customFields := t.CustomFields
+		if customFields == nil {
+			customFields = models.CustomFields{}
+		}
+		customFieldsJSON, err := json.Marshal(customFields)
+		if err != nil {
+			return fmt.Errorf("failed to serialize custom fields of task %q: %w", t.Title, err)
+		}
+		if _, err := q.Exec(`
+			INSERT INTO tasks (id, project_id, parent_id, title, description, status, priority, role, team, created_by, assigned_to,
+			                   output, claim_expires_at, due_at, labels, custom_fields, milestone_id, required_skills, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		`, t.ID, t.ProjectID, t.ParentID, t.Title, t.Description, t.Status, t.Priority, t.Role, t.Team, t.CreatedBy, t.AssignedTo,
+			t.Output, t.ClaimExpiresAt, t.DueAt, pq.Array(t.Labels), customFieldsJSON, t.MilestoneID, pq.Array(models.NormalizeSkills(t.RequiredSkills)), t.CreatedAt, t.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to import task %q: %w", t.Title, err)
+		}
+	}
+
+	for _, d := range b.TaskDependencies {
+		if _, err := q.Exec(`
+			INSERT INTO task_dependencies (task_id, depends_on_id, created_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING
+		`, d.TaskID, d.DependsOnID, d.CreatedAt); err != nil {
+			return fmt.Errorf("failed to import task dependency: %w", err)
+		}
+	}
+
+	for _, c := range b.TaskComments {
+		if err := InsertTaskComment(q, c); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range b.Contexts {
+		// Front matter is parsed again rather than trusted from the bundle
+		frontMatter, err := FrontMatterJSON(c.Content)
+		if err != nil {
+			frontMatter = []byte("{}")
+		}
+		saved, err := ScanContext(q.QueryRow(`
+			INSERT INTO contexts (id, project_id, agent_id, task_id, title, content, tags, front_matter, source_path, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING `+ContextColumns,
+			c.ID, c.ProjectID, c.AgentID, c.TaskID, c.Title, c.Content, pq.Array(c.Tags), frontMatter, c.SourcePath, c.CreatedAt, c.UpdatedAt))
+		if err != nil {
+			return fmt.Errorf("failed to import context %q: %w", c.Title, err)
+		}
+		if _, err := RecordContextRevision(q, saved, &saved.AgentID); err != nil {
+			return err
+		}
+		if err := ReplaceContextLinks(q, saved); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range b.Standups {
+		if _, err := q.Exec(`
+			INSERT INTO daily_standups (id, agent_id, project_id, standup_date, did, doing, done,
+			                            blockers, challenges, reference_links, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		`, s.ID, s.AgentID, s.ProjectID, s.StandupDate, s.Did, s.Doing, s.Done,
+			s.Blockers, s.Challenges, s.ReferenceLinks, s.CreatedAt, s.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to import standup: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CloneProject creates a new project from the skeleton of sourceID: its
+// agents, custom fields, the contexts selected by req and, unless excluded,
+// its tasks reset to pending. It returns sql.ErrNoRows if the source project
+// does not exist.
+func CloneProject(q Querier, sourceID uuid.UUID, req models.CloneProjectRequest) (models.ProjectImportResult, error) {
+	b, err := ExportProject(q, sourceID)
+	if err != nil {
+		return models.ProjectImportResult{}, err
+	}
+
+	description := b.Project.Description
+	if req.Description != nil {
+		description = *req.Description
+	}
+	bundle.Skeleton(&b, bundle.SkeletonOptions{
+		Name:         req.Name,
+		Description:  description,
+		IncludeTasks: req.IncludeTasks == nil || *req.IncludeTasks,
+		KeepContext:  bundle.SelectContexts(req.ContextIDs, req.ContextTags),
+	}, time.Now())
+
+	ids, err := bundle.Remap(&b)
+	if err != nil {
+		return models.ProjectImportResult{}, fmt.Errorf("failed to remap project: %w", err)
+	}
+	if err := ImportProject(q, b); err != nil {
+		return models.ProjectImportResult{}, err
+	}
+
+	project, err := GetProject(q, b.Project.ID)
+	if err != nil {
+		return models.ProjectImportResult{}, err
+	}
+	return models.ProjectImportResult{
+		Project:    project,
+		Agents:     len(b.Agents),
+		Milestones: len(b.Milestones),
+		Tasks:      len(b.Tasks),
+		Contexts:   len(b.Contexts),
+		IDMap:      ids,
+	}, nil
+}
+
+// scanAll runs a query and scans every row with scan. It returns an empty
+// slice rather than nil when there are no rows.
+func scanAll[T any](q Querier, scan func(RowScanner) (T, error), query string, args ...interface{}) ([]T, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []T{}
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// resolvedContextLinksQuery selects links with their source and resolved
+// target. Title links resolve case-insensitively to the most recently updated
+// context of the project with that title; ID links only resolve within the project.
+const resolvedContextLinksQuery = `
+	SELECT l.source_id, s.title, l.kind, l.target, t.id, t.title
+	FROM context_links l
+	JOIN contexts s ON s.id = l.source_id
+	LEFT JOIN LATERAL (
+		SELECT c.id, c.title
+		FROM contexts c
+		WHERE c.project_id = l.project_id
+		  AND ((l.kind = 'id' AND c.id::text = l.target)
+		    OR (l.kind = 'title' AND lower(c.title) = lower(l.target)))
+		ORDER BY c.updated_at DESC
+		LIMIT 1
+	) t ON true`
+
+// ReplaceContextLinks re-parses the content of a context and replaces its
+// stored outgoing links. Call it in the transaction that saves the context.
+func ReplaceContextLinks(q Querier, c models.Context) error {
+	if _, err := q.Exec(`DELETE FROM context_links WHERE source_id = $1`, c.ID); err != nil {
+		return fmt.Errorf("failed to clear context links: %w", err)
+	}
+	for _, ref := range models.ParseContextLinks(c.Content) {
+		_, err := q.Exec(`
+			INSERT INTO context_links (source_id, project_id, kind, target)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING
+		`, c.ID, c.ProjectID, ref.Kind, ref.Target)
+		if err != nil {
+			return fmt.Errorf("failed to store context link: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListContextLinks returns the links from a context to other contexts
+func ListContextLinks(q Querier, contextID uuid.UUID) ([]models.ContextLink, error) {
+	return queryContextLinks(q, resolvedContextLinksQuery+`
+		WHERE l.source_id = $1
+		ORDER BY l.kind, l.target`, contextID)
+}
+
+// ListContextBacklinks returns the links from other contexts that resolve to a context
+func ListContextBacklinks(q Querier, contextID uuid.UUID) ([]models.ContextLink, error) {
+	return queryContextLinks(q, resolvedContextLinksQuery+`
+		WHERE t.id = $1 AND l.source_id <> $1
+		ORDER BY s.title`, contextID)
+}
+
+// ListBrokenContextLinks returns the links in a project whose target does not exist
+func ListBrokenContextLinks(q Querier, projectID uuid.UUID) ([]models.ContextLink, error) {
+	return queryContextLinks(q, resolvedContextLinksQuery+`
+		WHERE l.project_id = $1 AND t.id IS NULL
+		ORDER BY s.title, l.target`, projectID)
+}
+
+func queryContextLinks(q Querier, query string, args ...interface{}) ([]models.ContextLink, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query context links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []models.ContextLink{}
+	for rows.Next() {
+		var link models.ContextLink
+		var targetID uuid.NullUUID
+		var targetTitle *string
+		if err := rows.Scan(&link.SourceID, &link.SourceTitle, &link.Kind, &link.Target, &targetID, &targetTitle); err != nil {
+			return nil, fmt.Errorf("failed to scan context link: %w", err)
+		}
+		if targetID.Valid {
+			link.TargetID = &targetID.UUID
+			link.TargetTitle = *targetTitle
+		} else {
+			link.Broken = true
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
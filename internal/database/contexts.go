@@ -0,0 +1,202 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/techbuzzz/agent-shaker/internal/markdown"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// ContextColumns is the column list read by ScanContext
+const ContextColumns = `id, project_id, agent_id, task_id, title, content, tags, front_matter, source_path, version, created_at, updated_at`
+
+// ScanContext scans a row selected with ContextColumns into a Context
+func ScanContext(row RowScanner) (models.Context, error) {
+	var c models.Context
+	var frontMatterJSON []byte
+	err := row.Scan(&c.ID, &c.ProjectID, &c.AgentID, &c.TaskID, &c.Title, &c.Content, &c.Tags, &frontMatterJSON, &c.SourcePath, &c.Version, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return c, err
+	}
+	c.FrontMatter = markdown.FrontMatter{}
+	if len(frontMatterJSON) > 0 {
+		_ = json.Unmarshal(frontMatterJSON, &c.FrontMatter)
+	}
+	return c, nil
+}
+
+// FrontMatterJSON parses the front matter of context content into the JSON
+// stored in the front_matter column
+func FrontMatterJSON(content string) ([]byte, error) {
+	fm, _, err := markdown.SplitFrontMatter(content)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fm)
+}
+
+// FrontMatterFilter returns a SQL predicate matching contexts whose front
+// matter key named by placeholder $keyArg has the text value of placeholder
+// $valueArg, or is a list containing it
+func FrontMatterFilter(keyArg, valueArg int) string {
+	return fmt.Sprintf("(front_matter->>$%d::text = $%d OR front_matter->$%d::text @> to_jsonb($%d::text))", keyArg, valueArg, keyArg, valueArg)
+}
+
+// FrontMatterKeyFilter returns a SQL predicate matching contexts whose front
+// matter has the key named by placeholder $keyArg
+func FrontMatterKeyFilter(keyArg int) string {
+	return fmt.Sprintf("front_matter ? $%d::text", keyArg)
+}
+
+// GetContext loads a single context by ID. It returns sql.ErrNoRows if the context does not exist.
+func GetContext(q Querier, id uuid.UUID) (models.Context, error) {
+	return ScanContext(q.QueryRow(`SELECT `+ContextColumns+` FROM contexts WHERE id = $1`, id))
+}
+
+// LockContext loads a context and locks its row until the end of the
+// transaction, so concurrent writers number their revisions in order
+func LockContext(q Querier, id uuid.UUID) (models.Context, error) {
+	return ScanContext(q.QueryRow(`SELECT `+ContextColumns+` FROM contexts WHERE id = $1 FOR UPDATE`, id))
+}
+
+// contextRevisionColumns is the column list read by scanContextRevision
+const contextRevisionColumns = `id, context_id, revision, author_id, task_id, title, content, tags, created_at`
+
+func scanContextRevision(row RowScanner) (models.ContextRevision, error) {
+	var r models.ContextRevision
+	var authorID, taskID uuid.NullUUID
+	err := row.Scan(&r.ID, &r.ContextID, &r.Revision, &authorID, &taskID, &r.Title, &r.Content, &r.Tags, &r.CreatedAt)
+	if err != nil {
+		return r, err
+	}
+	if authorID.Valid {
+		r.AuthorID = &authorID.UUID
+	}
+	if taskID.Valid {
+		r.TaskID = &taskID.UUID
+	}
+	return r, nil
+}
+
+// RecordContextRevision stores the current state of a context as its next
+// revision. Callers updating an existing context should hold the row lock
+// taken by LockContext. An author that is not a known agent is recorded as
+// no author.
+func RecordContextRevision(q Querier, c models.Context, authorID *uuid.UUID) (models.ContextRevision, error) {
+	revision, err := scanContextRevision(q.QueryRow(`
+		INSERT INTO context_revisions (id, context_id, revision, author_id, task_id, title, content, tags, created_at)
+		SELECT $1, $2, COALESCE(MAX(revision), 0) + 1, (SELECT id FROM agents WHERE id = $3), $4, $5, $6, $7, $8
+		FROM context_revisions
+		WHERE context_id = $2
+		RETURNING `+contextRevisionColumns,
+		uuid.New(), c.ID, authorID, c.TaskID, c.Title, c.Content, c.Tags, c.UpdatedAt))
+	if err != nil {
+		return revision, fmt.Errorf("failed to record context revision: %w", err)
+	}
+	return revision, nil
+}
+
+// ListContextRevisions returns the revisions of a context, newest first
+func ListContextRevisions(q Querier, contextID uuid.UUID) ([]models.ContextRevision, error) {
+	rows, err := q.Query(`
+		SELECT `+contextRevisionColumns+`
+		FROM context_revisions
+		WHERE context_id = $1
+		ORDER BY revision DESC
+	`, contextID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query context revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.ContextRevision{}
+	for rows.Next() {
+		r, err := scanContextRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan context revision: %w", err)
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
+
+// GetContextRevision loads one revision of a context. It returns
+// sql.ErrNoRows if the revision does not exist.
+func GetContextRevision(q Querier, contextID uuid.UUID, revision int) (models.ContextRevision, error) {
+	return scanContextRevision(q.QueryRow(`
+		SELECT `+contextRevisionColumns+`
+		FROM context_revisions
+		WHERE context_id = $1 AND revision = $2
+	`, contextID, revision))
+}
+
+// LatestContextRevision returns the number of the newest revision of a context, 0 if none
+func LatestContextRevision(q Querier, contextID uuid.UUID) (int, error) {
+	var revision int
+	err := q.QueryRow(`SELECT COALESCE(MAX(revision), 0) FROM context_revisions WHERE context_id = $1`, contextID).Scan(&revision)
+	return revision, err
+}
+
+// LockContextBySourcePath loads the context imported from a path and locks
+// its row. It returns sql.ErrNoRows if no context has that path.
+func LockContextBySourcePath(q Querier, projectID uuid.UUID, sourcePath string) (models.Context, error) {
+	return ScanContext(q.QueryRow(`
+		SELECT `+ContextColumns+`
+		FROM contexts
+		WHERE project_id = $1 AND source_path = $2
+		FOR UPDATE
+	`, projectID, sourcePath))
+}
+
+// InsertContext stores a new context, starting its revision history and
+// indexing its links. ID, timestamps and front matter are set here.
+func InsertContext(q Querier, c models.Context) (models.Context, error) {
+	frontMatter, err := FrontMatterJSON(c.Content)
+	if err != nil {
+		return c, err
+	}
+
+	now := time.Now()
+	saved, err := ScanContext(q.QueryRow(`
+		INSERT INTO contexts (id, project_id, agent_id, task_id, title, content, tags, front_matter, source_path, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING `+ContextColumns,
+		uuid.New(), c.ProjectID, c.AgentID, c.TaskID, c.Title, c.Content, pq.Array(c.Tags), frontMatter, c.SourcePath, now, now))
+	if err != nil {
+		return saved, fmt.Errorf("failed to create context: %w", err)
+	}
+
+	if _, err := RecordContextRevision(q, saved, &saved.AgentID); err != nil {
+		return saved, err
+	}
+	return saved, ReplaceContextLinks(q, saved)
+}
+
+// UpdateContextContent replaces the task, title, content and tags of a
+// context locked by the caller, recording a revision by authorID and
+// re-indexing links
+func UpdateContextContent(q Querier, id uuid.UUID, taskID *uuid.UUID, title, content string, tags []string, authorID *uuid.UUID) (models.Context, error) {
+	frontMatter, err := FrontMatterJSON(content)
+	if err != nil {
+		return models.Context{}, err
+	}
+
+	saved, err := ScanContext(q.QueryRow(`
+		UPDATE contexts
+		SET task_id = $1, title = $2, content = $3, tags = $4, front_matter = $5, updated_at = $6
+		WHERE id = $7
+		RETURNING `+ContextColumns,
+		taskID, title, content, pq.Array(tags), frontMatter, time.Now(), id))
+	if err != nil {
+		return saved, fmt.Errorf("failed to update context: %w", err)
+	}
+
+	if _, err := RecordContextRevision(q, saved, authorID); err != nil {
+		return saved, err
+	}
+	return saved, ReplaceContextLinks(q, saved)
+}
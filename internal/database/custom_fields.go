@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// CustomFieldColumns is the column list read by ScanCustomFieldDefinition
+const CustomFieldColumns = `project_id, name, field_type, options, required, created_at`
+
+// ScanCustomFieldDefinition scans a row selected with CustomFieldColumns
+func ScanCustomFieldDefinition(row RowScanner) (models.CustomFieldDefinition, error) {
+	var def models.CustomFieldDefinition
+	err := row.Scan(&def.ProjectID, &def.Name, &def.Type, &def.Options, &def.Required, &def.CreatedAt)
+	return def, err
+}
+
+// ListCustomFieldDefinitions returns a project's custom field schema ordered by name
+func ListCustomFieldDefinitions(q Querier, projectID uuid.UUID) ([]models.CustomFieldDefinition, error) {
+	rows, err := q.Query(`
+		SELECT `+CustomFieldColumns+`
+		FROM custom_field_definitions
+		WHERE project_id = $1
+		ORDER BY name
+	`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query custom fields: %w", err)
+	}
+	defer rows.Close()
+
+	defs := []models.CustomFieldDefinition{}
+	for rows.Next() {
+		def, err := ScanCustomFieldDefinition(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan custom field: %w", err)
+		}
+		defs = append(defs, def)
+	}
+	return defs, rows.Err()
+}
+
+// CustomFieldFilter returns a SQL predicate matching tasks whose custom field
+// named by placeholder $nameArg has the text value of placeholder $valueArg
+func CustomFieldFilter(nameArg, valueArg int) string {
+	return fmt.Sprintf("custom_fields->>$%d = $%d", nameArg, valueArg)
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// claimLeaseExpiry computes a new lease expiry for a task joined with its project (aliased as p)
+const claimLeaseExpiry = `NOW() + make_interval(secs => p.claim_lease_seconds)`
+
+// ClaimTask assigns a task to an agent, moves it to in_progress, starts a
+// claim lease using the project's lease duration and records the change in
+// the task history. Only open tasks that are unassigned, already held by the
+// agent or whose lease has expired can be claimed. It returns sql.ErrNoRows
+// if the task does not exist, ErrTaskAlreadyClaimed if it cannot be claimed
+// and ErrWIPLimitReached if the project's WIP limits do not allow another
+// task. Run it in a transaction so the WIP limits hold under concurrent claims.
+func ClaimTask(q Querier, taskID, agentID uuid.UUID) (models.Task, error) {
+	if err := CheckWIPLimits(q, taskID, agentID); err != nil {
+		return models.Task{}, err
+	}
+
+	// The guard is evaluated again on the latest row version when a
+	// concurrent claim updated the task first, so only one of them succeeds
+	before, after, err := scanTaskChange(q.QueryRow(`
+		UPDATE tasks t
+		SET assigned_to = $1, status = $2, claim_expires_at = `+claimLeaseExpiry+`, updated_at = NOW()
+		FROM projects p, tasks old
+		WHERE p.id = t.project_id AND old.id = t.id AND t.id = $3
+		  AND (t.assigned_to IS NULL OR t.assigned_to = $1 OR t.claim_expires_at < NOW())
+		  AND t.status IN ($4, $5, $2)
+		RETURNING `+qualifiedTaskColumns("t")+`, `+previousTaskColumns,
+		agentID, models.StatusInProgress, taskID, models.StatusPending, models.StatusBlocked))
+	if err == sql.ErrNoRows {
+		// The task exists, CheckWIPLimits found it
+		return after, ErrTaskAlreadyClaimed
+	} else if err != nil {
+		return after, err
+	}
+	return after, RecordTaskEvents(q, models.TaskChangeEvents(before, after, &agentID)...)
+}
+
+// ErrTaskAlreadyClaimed is returned when claiming a task that another agent
+// holds under an active lease, or that is closed
+var ErrTaskAlreadyClaimed = errors.New("task is already claimed by another agent or is closed")
+
+// ErrWIPLimitReached is returned when starting a task would exceed the
+// project's limits on tasks in progress
+var ErrWIPLimitReached = errors.New("WIP limit reached")
+
+// CheckWIPLimits returns ErrWIPLimitReached if the agent or the project of
+// the task already has as many tasks in progress as the project allows. It
+// locks the project row so that concurrent transactions starting tasks in
+// the project wait for each other; call it in the transaction that moves the
+// task to in_progress. Pass uuid.Nil for a task without an assignee.
+func CheckWIPLimits(q Querier, taskID, agentID uuid.UUID) error {
+	var projectID uuid.UUID
+	var settingsJSON []byte
+	err := q.QueryRow(`
+		SELECT p.id, p.settings
+		FROM tasks target
+		JOIN projects p ON p.id = target.project_id
+		WHERE target.id = $1
+		FOR NO KEY UPDATE OF p
+	`, taskID).Scan(&projectID, &settingsJSON)
+	if err != nil {
+		return err
+	}
+
+	var agentInProgress, projectInProgress int
+	err = q.QueryRow(`
+		SELECT COUNT(*) FILTER (WHERE assigned_to = $3), COUNT(*)
+		FROM tasks
+		WHERE project_id = $1 AND status = $4 AND id <> $2
+	`, projectID, taskID, agentID, models.StatusInProgress).Scan(&agentInProgress, &projectInProgress)
+	if err != nil {
+		return fmt.Errorf("failed to count tasks in progress: %w", err)
+	}
+
+	var settings models.ProjectSettings
+	_ = json.Unmarshal(settingsJSON, &settings)
+	if settings.WIPLimits.Reached(agentInProgress, projectInProgress) {
+		return fmt.Errorf("%w: %d in progress for the agent (limit %d), %d for the project (limit %d)", ErrWIPLimitReached,
+			agentInProgress, settings.WIPLimits.PerAgent, projectInProgress, settings.WIPLimits.Project)
+	}
+	return nil
+}
+
+// RenewTaskClaim extends the claim lease of a task held by agentID. It returns
+// sql.ErrNoRows if the agent does not hold an active claim on the task.
+func RenewTaskClaim(q Querier, taskID, agentID uuid.UUID) (models.Task, error) {
+	return ScanTask(q.QueryRow(`
+		UPDATE tasks t
+		SET claim_expires_at = `+claimLeaseExpiry+`
+		FROM projects p
+		WHERE p.id = t.project_id AND t.id = $1 AND t.assigned_to = $2 AND t.status = $3
+		RETURNING `+qualifiedTaskColumns("t"),
+		taskID, agentID, models.StatusInProgress))
+}
+
+// RenewAgentClaims extends every active claim lease held by an agent and
+// returns how many leases were renewed
+func RenewAgentClaims(q Querier, agentID uuid.UUID) (int64, error) {
+	result, err := q.Exec(`
+		UPDATE tasks t
+		SET claim_expires_at = `+claimLeaseExpiry+`
+		FROM projects p
+		WHERE p.id = t.project_id AND t.assigned_to = $1 AND t.status = $2 AND t.claim_expires_at IS NOT NULL
+	`, agentID, models.StatusInProgress)
+	if err != nil {
+		return 0, fmt.Errorf("failed to renew claims: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ReleaseExpiredClaims returns every task whose claim lease has expired to
+// pending, clears its assignee and records the change in the task history.
+// It returns the released tasks.
+func ReleaseExpiredClaims(q Querier) ([]models.Task, error) {
+	rows, err := q.Query(`
+		UPDATE tasks t
+		SET status = $1, assigned_to = NULL, claim_expires_at = NULL, updated_at = NOW()
+		FROM tasks old
+		WHERE old.id = t.id AND t.status = $2 AND t.claim_expires_at < NOW()
+		RETURNING `+qualifiedTaskColumns("t")+`, `+previousTaskColumns,
+		models.StatusPending, models.StatusInProgress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release expired claims: %w", err)
+	}
+	defer rows.Close()
+
+	var released []models.Task
+	var events []models.TaskEvent
+	for rows.Next() {
+		before, after, err := scanTaskChange(rows)
+		if err != nil {
+			return released, fmt.Errorf("failed to scan released task: %w", err)
+		}
+		released = append(released, after)
+		events = append(events, models.TaskChangeEvents(before, after, nil)...)
+	}
+	if err := rows.Err(); err != nil {
+		return released, err
+	}
+	return released, RecordTaskEvents(q, events...)
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// MilestoneColumns is the column list read by ScanMilestone
+const MilestoneColumns = `id, project_id, name, description, target_date, created_at, updated_at`
+
+// ScanMilestone scans a row selected with MilestoneColumns into a Milestone
+func ScanMilestone(row RowScanner) (models.Milestone, error) {
+	var m models.Milestone
+	var targetDate sql.NullTime
+	err := row.Scan(&m.ID, &m.ProjectID, &m.Name, &m.Description, &targetDate, &m.CreatedAt, &m.UpdatedAt)
+	if targetDate.Valid {
+		m.TargetDate = &targetDate.Time
+	}
+	return m, err
+}
+
+// GetMilestone loads a single milestone by ID. It returns sql.ErrNoRows if the milestone does not exist.
+func GetMilestone(q Querier, id uuid.UUID) (models.Milestone, error) {
+	return ScanMilestone(q.QueryRow(`SELECT `+MilestoneColumns+` FROM milestones WHERE id = $1`, id))
+}
+
+// milestoneProgressColumns selects a milestone (aliased as m) with the
+// counts of its tasks (aliased as t) and the time the last of them was
+// closed. Queries select from milestoneTasks and group by m.id.
+const milestoneProgressColumns = `m.id, m.project_id, m.name, m.description, m.target_date, m.created_at, m.updated_at,
+	COUNT(t.id),
+	COUNT(t.id) FILTER (WHERE t.status = 'completed'),
+	COUNT(t.id) FILTER (WHERE t.status = 'cancelled'),
+	MAX(COALESCE(closed.at, t.updated_at)) FILTER (WHERE t.status IN ` + closedStatuses + `)`
+
+// milestoneTasks joins milestones with their tasks and the time each task
+// last moved to its current status, taken from the task history. Tasks
+// without such an event, created before the history was kept, fall back to
+// their update time.
+const milestoneTasks = `milestones m
+		LEFT JOIN tasks t ON t.milestone_id = m.id
+		LEFT JOIN LATERAL (
+			SELECT MAX(e.created_at) AS at FROM task_events e
+			WHERE e.task_id = t.id AND e.event_type = 'status_changed' AND e.new_value = t.status
+		) closed ON true`
+
+// scanMilestoneProgress scans a row selected with milestoneProgressColumns
+// and evaluates the milestone's progress at now
+func scanMilestoneProgress(row RowScanner, now time.Time) (models.MilestoneProgress, error) {
+	var p models.MilestoneProgress
+	var targetDate, lastClosedAt sql.NullTime
+	err := row.Scan(&p.ID, &p.ProjectID, &p.Name, &p.Description, &targetDate, &p.CreatedAt, &p.UpdatedAt,
+		&p.TotalTasks, &p.CompletedTasks, &p.CancelledTasks, &lastClosedAt)
+	if err != nil {
+		return p, err
+	}
+	if targetDate.Valid {
+		p.TargetDate = &targetDate.Time
+	}
+	var closed *time.Time
+	if lastClosedAt.Valid {
+		closed = &lastClosedAt.Time
+	}
+	p.Evaluate(closed, now)
+	return p, nil
+}
+
+// ListMilestoneProgress returns the milestones of a project with their
+// progress, ordered by target date. Without a project every milestone is
+// returned; openOnly leaves out completed milestones.
+func ListMilestoneProgress(q Querier, projectID *uuid.UUID, openOnly bool) ([]models.MilestoneProgress, error) {
+	query := `
+		SELECT ` + milestoneProgressColumns + `
+		FROM ` + milestoneTasks + `
+		WHERE ($1::uuid IS NULL OR m.project_id = $1)
+		GROUP BY m.id
+		ORDER BY m.target_date NULLS LAST, m.created_at`
+	rows, err := q.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query milestones: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	milestones := []models.MilestoneProgress{}
+	for rows.Next() {
+		p, err := scanMilestoneProgress(rows, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan milestone: %w", err)
+		}
+		if openOnly && p.Status == models.MilestoneCompleted {
+			continue
+		}
+		milestones = append(milestones, p)
+	}
+	return milestones, rows.Err()
+}
+
+// GetMilestoneProgress returns one milestone with its progress. It returns
+// sql.ErrNoRows if the milestone does not exist.
+func GetMilestoneProgress(q Querier, id uuid.UUID) (models.MilestoneProgress, error) {
+	return scanMilestoneProgress(q.QueryRow(`
+		SELECT `+milestoneProgressColumns+`
+		FROM `+milestoneTasks+`
+		WHERE m.id = $1
+		GROUP BY m.id
+	`, id), time.Now())
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// presenceAfter is the SQL interval of a presence threshold of the project
+// aliased p, falling back to the default when the project does not set it
+func presenceAfter(setting string, defaultSeconds int) string {
+	return fmt.Sprintf(`(COALESCE(NULLIF((p.settings->'presence'->>'%s')::int, 0), %d) * INTERVAL '1 second')`, setting, defaultSeconds)
+}
+
+var (
+	idleAfter    = presenceAfter("idle_after_seconds", models.DefaultIdleAfterSeconds)
+	offlineAfter = presenceAfter("offline_after_seconds", models.DefaultOfflineAfterSeconds)
+)
+
+// RecordAgentSeen records that an agent sent a heartbeat at the given time
+// reporting the given status, and moves it to the status it has after the
+// heartbeat. The returned presence holds the status before and after. It
+// returns sql.ErrNoRows if the agent does not exist.
+func RecordAgentSeen(q Querier, agentID uuid.UUID, at time.Time, reported string) (models.AgentPresence, error) {
+	presence := models.AgentPresence{AgentID: agentID, LastSeen: at}
+	err := q.QueryRow(`SELECT project_id, status FROM agents WHERE id = $1`, agentID).Scan(&presence.ProjectID, &presence.PreviousStatus)
+	if err != nil {
+		return presence, err
+	}
+
+	presence.Status = models.StatusAfterHeartbeat(presence.PreviousStatus, reported)
+	if _, err := q.Exec(`UPDATE agents SET last_seen = $2, status = $3 WHERE id = $1`, agentID, at, presence.Status); err != nil {
+		return presence, fmt.Errorf("failed to record agent presence: %w", err)
+	}
+	return presence, nil
+}
+
+// MarkSilentAgents marks agents that stopped sending heartbeats: active and
+// working agents silent for longer than their project's idle threshold
+// become idle, and any agent silent for longer than the offline threshold
+// becomes offline. It returns the changed agents.
+func MarkSilentAgents(q Querier) ([]models.AgentPresence, error) {
+	presences, err := scanAll(q, func(row RowScanner) (models.AgentPresence, error) {
+		var p models.AgentPresence
+		err := row.Scan(&p.AgentID, &p.ProjectID, &p.Status, &p.PreviousStatus, &p.LastSeen)
+		return p, err
+	}, `
+		UPDATE agents a
+		SET status = CASE WHEN a.last_seen <= NOW() - `+offlineAfter+` THEN $1 ELSE $2 END
+		FROM projects p, agents previous
+		WHERE p.id = a.project_id AND previous.id = a.id AND a.status <> $1
+		  AND (a.last_seen <= NOW() - `+offlineAfter+`
+		       OR (a.status IN ($3, 'working') AND a.last_seen <= NOW() - `+idleAfter+`))
+		RETURNING a.id, a.project_id, a.status, previous.status, a.last_seen
+	`, models.PresenceOffline, models.PresenceIdle, models.PresenceActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark silent agents: %w", err)
+	}
+	return presences, nil
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// ProjectColumns is the column list read by ScanProject
+const ProjectColumns = `id, name, description, status, claim_lease_seconds, priority_sla_hours, is_template, settings, created_at, updated_at`
+
+// ScanProject scans a row selected with ProjectColumns into a Project
+func ScanProject(row RowScanner) (models.Project, error) {
+	var p models.Project
+	var slaJSON, settingsJSON []byte
+	err := row.Scan(&p.ID, &p.Name, &p.Description, &p.Status, &p.ClaimLeaseSeconds, &slaJSON, &p.IsTemplate, &settingsJSON, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return p, err
+	}
+
+	p.SLAHours = models.SLAHours{}
+	if len(slaJSON) > 0 {
+		_ = json.Unmarshal(slaJSON, &p.SLAHours)
+	}
+	if len(settingsJSON) > 0 {
+		_ = json.Unmarshal(settingsJSON, &p.Settings)
+	}
+	p.Settings.ClaimLeaseSeconds = p.ClaimLeaseSeconds
+	if p.Settings.AllowedAgentRoles == nil {
+		p.Settings.AllowedAgentRoles = []models.AgentRole{}
+	}
+	return p, nil
+}
+
+// ProjectSettingsJSON serializes settings for the settings column. The claim
+// lease is left out because it is stored in its own column.
+func ProjectSettingsJSON(settings models.ProjectSettings) ([]byte, error) {
+	settings.ClaimLeaseSeconds = 0
+	return json.Marshal(settings)
+}
+
+// GetProject loads a single project by ID. It returns sql.ErrNoRows if the project does not exist.
+func GetProject(q Querier, id uuid.UUID) (models.Project, error) {
+	return ScanProject(q.QueryRow(`SELECT `+ProjectColumns+` FROM projects WHERE id = $1`, id))
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// searchHeadlineOptions marks matched terms in snippets with markdown bold
+const searchHeadlineOptions = `StartSel=**, StopSel=**, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … "`
+
+// searchSources selects the searchable fields of each record type. Every
+// query reads the tsquery from $1 and may filter on project_id.
+var searchSources = map[models.SearchResultType]string{
+	models.SearchTypeTask: `
+		SELECT 'task', id, project_id, title,
+			ts_headline('english', coalesce(description, '') || ' ' || coalesce(output, ''), query, '` + searchHeadlineOptions + `'),
+			ts_rank(search_vector, query), created_at
+		FROM tasks, websearch_to_tsquery('english', $1) query
+		WHERE search_vector @@ query`,
+	models.SearchTypeContext: `
+		SELECT 'context', id, project_id, title,
+			ts_headline('english', coalesce(content, ''), query, '` + searchHeadlineOptions + `'),
+			ts_rank(search_vector, query), created_at
+		FROM contexts, websearch_to_tsquery('english', $1) query
+		WHERE search_vector @@ query`,
+	models.SearchTypeStandup: `
+		SELECT 'standup', id, project_id, 'Standup ' || to_char(standup_date, 'YYYY-MM-DD'),
+			ts_headline('english', concat_ws(' ', did, doing, done, blockers, challenges), query, '` + searchHeadlineOptions + `'),
+			ts_rank(search_vector, query), created_at
+		FROM daily_standups, websearch_to_tsquery('english', $1) query
+		WHERE search_vector @@ query`,
+}
+
+// Search runs a ranked full-text search across tasks, contexts and standups
+func Search(q Querier, search models.SearchQuery) ([]models.SearchResult, error) {
+	types := search.Types
+	if len(types) == 0 {
+		types = models.SearchResultTypes
+	}
+
+	args := []interface{}{search.Text}
+	projectFilter := ""
+	if search.ProjectID != nil {
+		args = append(args, *search.ProjectID)
+		projectFilter = " AND project_id = $2"
+	}
+
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		source, ok := searchSources[t]
+		if !ok {
+			return nil, fmt.Errorf("unknown search type %q", t)
+		}
+		parts = append(parts, source+projectFilter)
+	}
+
+	limit := search.Limit
+	if limit <= 0 {
+		limit = models.DefaultSearchLimit
+	}
+	args = append(args, limit)
+
+	rows, err := q.Query(strings.Join(parts, "\n\t\tUNION ALL")+
+		fmt.Sprintf("\n\t\tORDER BY 6 DESC, 7 DESC\n\t\tLIMIT $%d", len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var r models.SearchResult
+		if err := rows.Scan(&r.Type, &r.ID, &r.ProjectID, &r.Title, &r.Snippet, &r.Rank, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// TaskCommentColumns is the column list read by ScanTaskComment
+const TaskCommentColumns = `id, task_id, project_id, author_id, reply_to_id, body, created_at, updated_at`
+
+// ScanTaskComment scans a row selected with TaskCommentColumns into a TaskComment
+func ScanTaskComment(row RowScanner) (models.TaskComment, error) {
+	var c models.TaskComment
+	var replyToID uuid.NullUUID
+
+	err := row.Scan(&c.ID, &c.TaskID, &c.ProjectID, &c.AuthorID, &replyToID, &c.Body, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return c, err
+	}
+	if replyToID.Valid {
+		c.ReplyToID = &replyToID.UUID
+	}
+	return c, nil
+}
+
+// GetTaskComment loads a comment on a task. It returns sql.ErrNoRows if the
+// comment does not exist or belongs to another task.
+func GetTaskComment(q Querier, taskID, commentID uuid.UUID) (models.TaskComment, error) {
+	return ScanTaskComment(q.QueryRow(`
+		SELECT `+TaskCommentColumns+`
+		FROM task_comments
+		WHERE id = $1 AND task_id = $2
+	`, commentID, taskID))
+}
+
+// ListTaskComments returns the comments on a task, oldest first
+func ListTaskComments(q Querier, taskID uuid.UUID) ([]models.TaskComment, error) {
+	rows, err := q.Query(`
+		SELECT `+TaskCommentColumns+`
+		FROM task_comments
+		WHERE task_id = $1
+		ORDER BY created_at, id
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query task comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := []models.TaskComment{}
+	for rows.Next() {
+		c, err := ScanTaskComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan task comment: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+// InsertTaskComment stores a new comment
+func InsertTaskComment(q Querier, c models.TaskComment) error {
+	_, err := q.Exec(`
+		INSERT INTO task_comments (id, task_id, project_id, author_id, reply_to_id, body, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, c.ID, c.TaskID, c.ProjectID, c.AuthorID, c.ReplyToID, c.Body, c.CreatedAt, c.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert task comment: %w", err)
+	}
+	return nil
+}
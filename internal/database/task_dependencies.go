@@ -0,0 +1,135 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// Querier is implemented by both *sql.DB and *sql.Tx so helpers can run
+// inside or outside of a transaction
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// completedStatuses lists the task statuses that satisfy a dependency
+const completedStatuses = `('completed')`
+
+// ListTaskPrerequisites returns the tasks that taskID depends on
+func ListTaskPrerequisites(q Querier, taskID uuid.UUID) ([]models.TaskRef, error) {
+	return queryTaskRefs(q, `
+		SELECT t.id, t.title, t.status
+		FROM task_dependencies d
+		INNER JOIN tasks t ON t.id = d.depends_on_id
+		WHERE d.task_id = $1
+		ORDER BY d.created_at
+	`, taskID)
+}
+
+// ListTaskDependents returns the tasks that depend on taskID
+func ListTaskDependents(q Querier, taskID uuid.UUID) ([]models.TaskRef, error) {
+	return queryTaskRefs(q, `
+		SELECT t.id, t.title, t.status
+		FROM task_dependencies d
+		INNER JOIN tasks t ON t.id = d.task_id
+		WHERE d.depends_on_id = $1
+		ORDER BY d.created_at
+	`, taskID)
+}
+
+// UnmetTaskDependencies returns the prerequisites of taskID that are not completed yet.
+// An empty result means the task is ready to be started.
+func UnmetTaskDependencies(q Querier, taskID uuid.UUID) ([]models.TaskRef, error) {
+	return queryTaskRefs(q, `
+		SELECT t.id, t.title, t.status
+		FROM task_dependencies d
+		INNER JOIN tasks t ON t.id = d.depends_on_id
+		WHERE d.task_id = $1 AND t.status NOT IN `+completedStatuses+`
+		ORDER BY d.created_at
+	`, taskID)
+}
+
+// LockTaskForStart locks a task and its prerequisites until the end of the
+// transaction and returns the prerequisites that are not completed yet.
+// Dependencies added to the task and prerequisites reopened concurrently wait
+// for the transaction, so the result still holds when the task is moved to
+// in_progress in the same transaction. It returns sql.ErrNoRows if the task
+// does not exist.
+func LockTaskForStart(q Querier, taskID uuid.UUID) ([]models.TaskRef, error) {
+	// FOR UPDATE also blocks new task_dependencies rows referencing the task
+	var id uuid.UUID
+	if err := q.QueryRow(`SELECT id FROM tasks WHERE id = $1 FOR UPDATE`, taskID).Scan(&id); err != nil {
+		return nil, err
+	}
+	if _, err := q.Exec(`
+		SELECT 1 FROM task_dependencies d
+		INNER JOIN tasks t ON t.id = d.depends_on_id
+		WHERE d.task_id = $1
+		FOR SHARE OF t
+	`, taskID); err != nil {
+		return nil, fmt.Errorf("failed to lock prerequisites: %w", err)
+	}
+	return UnmetTaskDependencies(q, taskID)
+}
+
+// DependencyCreatesCycle reports whether adding the edge taskID -> dependsOnID
+// would close a cycle, i.e. dependsOnID already (transitively) depends on taskID
+func DependencyCreatesCycle(q Querier, taskID, dependsOnID uuid.UUID) (bool, error) {
+	if taskID == dependsOnID {
+		return true, nil
+	}
+
+	var cycle bool
+	err := q.QueryRow(`
+		WITH RECURSIVE chain(id) AS (
+			SELECT depends_on_id FROM task_dependencies WHERE task_id = $1
+			UNION
+			SELECT d.depends_on_id
+			FROM task_dependencies d
+			INNER JOIN chain c ON d.task_id = c.id
+		)
+		SELECT EXISTS(SELECT 1 FROM chain WHERE id = $2)
+	`, dependsOnID, taskID).Scan(&cycle)
+	if err != nil {
+		return false, fmt.Errorf("failed to walk dependency graph: %w", err)
+	}
+	return cycle, nil
+}
+
+// UnmetDependenciesFilter is a SQL predicate matching tasks (aliased as t)
+// that still wait on at least one unfinished prerequisite
+const UnmetDependenciesFilter = `EXISTS (
+	SELECT 1 FROM task_dependencies dep
+	INNER JOIN tasks pre ON pre.id = dep.depends_on_id
+	WHERE dep.task_id = t.id AND pre.status NOT IN ` + completedStatuses + `
+)`
+
+// UnmetDependencyIDsColumn is a SQL expression selecting the IDs (as text) of
+// unfinished prerequisites of a task aliased as t
+const UnmetDependencyIDsColumn = `ARRAY(
+	SELECT pre.id::text FROM task_dependencies dep
+	INNER JOIN tasks pre ON pre.id = dep.depends_on_id
+	WHERE dep.task_id = t.id AND pre.status NOT IN ` + completedStatuses + `
+)`
+
+func queryTaskRefs(q Querier, query string, args ...interface{}) ([]models.TaskRef, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+	defer rows.Close()
+
+	refs := []models.TaskRef{}
+	for rows.Next() {
+		var ref models.TaskRef
+		if err := rows.Scan(&ref.ID, &ref.Title, &ref.Status); err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
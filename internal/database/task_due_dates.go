@@ -0,0 +1,53 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// terminalStatuses lists the task statuses that can no longer become overdue
+const terminalStatuses = `('completed', 'failed', 'cancelled')`
+
+// OverdueFilter matches open tasks whose due date has passed
+const OverdueFilter = `(due_at < NOW() AND status NOT IN ` + terminalStatuses + `)`
+
+// taskSortOrders maps the sort values accepted by task listings to ORDER BY clauses
+var taskSortOrders = map[string]string{
+	"":            "created_at DESC",
+	"created_at":  "created_at DESC",
+	"-created_at": "created_at ASC",
+	"due_at":      "due_at ASC NULLS LAST, created_at DESC",
+	"-due_at":     "due_at DESC NULLS LAST, created_at DESC",
+}
+
+// TaskOrderBy returns the ORDER BY clause for a task listing sort value.
+// It returns false for unknown sort values.
+func TaskOrderBy(sort string) (string, bool) {
+	order, ok := taskSortOrders[sort]
+	return order, ok
+}
+
+// FlagOverdueTasks marks every open task that passed its due date and has not
+// been flagged yet, and returns those tasks so callers can announce them
+func FlagOverdueTasks(q Querier) ([]models.Task, error) {
+	rows, err := q.Query(`
+		UPDATE tasks
+		SET overdue_notified_at = NOW()
+		WHERE ` + OverdueFilter + ` AND overdue_notified_at IS NULL
+		RETURNING ` + TaskColumns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to flag overdue tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var overdue []models.Task
+	for rows.Next() {
+		task, err := ScanTask(rows)
+		if err != nil {
+			return overdue, fmt.Errorf("failed to scan overdue task: %w", err)
+		}
+		overdue = append(overdue, task)
+	}
+	return overdue, rows.Err()
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// RecordTaskEvents appends events to the task audit trail. An actor that is
+// not a known agent, such as an unchecked X-Agent-ID header, is recorded as
+// no actor.
+func RecordTaskEvents(q Querier, events ...models.TaskEvent) error {
+	for _, e := range events {
+		_, err := q.Exec(`
+			INSERT INTO task_events (id, task_id, project_id, actor_id, event_type, old_value, new_value, created_at)
+			VALUES ($1, $2, $3, (SELECT id FROM agents WHERE id = $4), $5, $6, $7, $8)
+		`, e.ID, e.TaskID, e.ProjectID, e.ActorID, e.EventType, e.OldValue, e.NewValue, e.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record task event: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListTaskEvents returns the audit trail of a task, oldest first
+func ListTaskEvents(q Querier, taskID uuid.UUID) ([]models.TaskEvent, error) {
+	rows, err := q.Query(`
+		SELECT id, task_id, project_id, actor_id, event_type, old_value, new_value, created_at
+		FROM task_events
+		WHERE task_id = $1
+		ORDER BY seq
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query task events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.TaskEvent{}
+	for rows.Next() {
+		var e models.TaskEvent
+		var actorID uuid.NullUUID
+		if err := rows.Scan(&e.ID, &e.TaskID, &e.ProjectID, &actorID, &e.EventType, &e.OldValue, &e.NewValue, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan task event: %w", err)
+		}
+		if actorID.Valid {
+			e.ActorID = &actorID.UUID
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// previousTaskColumns selects the audited fields of a task before an UPDATE.
+// The updated table must be self-joined as "old" on the same row.
+const previousTaskColumns = `old.status, old.assigned_to, old.output`
+
+// appendScanner scans extra trailing columns after the ones requested by the caller
+type appendScanner struct {
+	row   RowScanner
+	extra []interface{}
+}
+
+func (s appendScanner) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+// scanTaskChange scans a row selected with TaskColumns followed by
+// previousTaskColumns into snapshots of the task before and after the update
+func scanTaskChange(row RowScanner) (before, after models.Task, err error) {
+	var previousStatus models.TaskStatus
+	var previousAssignee uuid.NullUUID
+	var previousOutput sql.NullString
+
+	after, err = ScanTask(appendScanner{row: row, extra: []interface{}{&previousStatus, &previousAssignee, &previousOutput}})
+	if err != nil {
+		return before, after, err
+	}
+
+	before = after
+	before.Status = previousStatus
+	before.AssignedTo = nil
+	if previousAssignee.Valid {
+		before.AssignedTo = &previousAssignee.UUID
+	}
+	before.Output = previousOutput.String
+	return before, after, nil
+}
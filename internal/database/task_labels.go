@@ -0,0 +1,44 @@
+package database
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// SetTaskLabels replaces the labels of a task and returns them as they were
+// before the update along with the updated task. It returns sql.ErrNoRows if
+// the task does not exist.
+func SetTaskLabels(q Querier, id uuid.UUID, labels []string) ([]string, models.Task, error) {
+	var previous pq.StringArray
+	task, err := ScanTask(appendScanner{row: q.QueryRow(`
+		UPDATE tasks t
+		SET labels = $1, updated_at = NOW()
+		FROM tasks old
+		WHERE old.id = t.id AND t.id = $2
+		RETURNING `+qualifiedTaskColumns("t")+`, old.labels`,
+		pq.StringArray(labels), id), extra: []interface{}{&previous}})
+	return previous, task, err
+}
+
+// SetTaskCustomFields replaces the custom field values of a task with the
+// given JSON object and returns the values they had before the update along
+// with the updated task. It returns sql.ErrNoRows if the task does not exist.
+func SetTaskCustomFields(q Querier, id uuid.UUID, customFieldsJSON []byte) (models.CustomFields, models.Task, error) {
+	var previousJSON []byte
+	task, err := ScanTask(appendScanner{row: q.QueryRow(`
+		UPDATE tasks t
+		SET custom_fields = $1, updated_at = NOW()
+		FROM tasks old
+		WHERE old.id = t.id AND t.id = $2
+		RETURNING `+qualifiedTaskColumns("t")+`, old.custom_fields`,
+		customFieldsJSON, id), extra: []interface{}{&previousJSON}})
+
+	previous := models.CustomFields{}
+	if len(previousJSON) > 0 {
+		_ = json.Unmarshal(previousJSON, &previous)
+	}
+	return previous, task, err
+}
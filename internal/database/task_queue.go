@@ -0,0 +1,45 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// PriorityRank is a SQL expression ordering tasks (aliased as t) from high to low priority
+const PriorityRank = `CASE t.priority WHEN 'high' THEN 0 WHEN 'medium' THEN 1 ELSE 2 END`
+
+// ClaimNextTask atomically picks the highest-priority pending task that is
+// unassigned, has no unfinished prerequisites, has no subtasks, matches the
+// agent's role and team and, if it requires skills, needs one the agent has,
+// then claims it for the agent. Rows locked by a
+// concurrent caller are skipped, so two agents never receive the same task.
+// It must run inside a transaction and returns sql.ErrNoRows if no task is available.
+func ClaimNextTask(q Querier, agent models.Agent) (models.Task, error) {
+	var task models.Task
+	err := q.QueryRow(`
+		SELECT t.id
+		FROM tasks t
+		WHERE t.project_id = $1
+		  AND t.status = $2
+		  AND t.assigned_to IS NULL
+		  AND COALESCE(t.role, '') IN ('', $3)
+		  AND COALESCE(t.team, '') IN ('', $4)
+		  AND (t.required_skills = '{}' OR t.required_skills && $5)
+		  AND NOT `+UnmetDependenciesFilter+`
+		  AND NOT EXISTS (SELECT 1 FROM tasks c WHERE c.parent_id = t.id)
+		ORDER BY `+PriorityRank+`, t.created_at
+		LIMIT 1
+		FOR UPDATE OF t SKIP LOCKED
+	`, agent.ProjectID, models.StatusPending, string(agent.Role), agent.Team, pq.Array(agent.Skills)).Scan(&task.ID)
+	if err != nil {
+		return task, err
+	}
+
+	task, err = ClaimTask(q, task.ID, agent.ID)
+	if err != nil {
+		return task, fmt.Errorf("failed to claim task: %w", err)
+	}
+	return task, nil
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// TaskColumns is the column list read by ScanTask
+const TaskColumns = `id, project_id, parent_id, title, description, status, priority, role, team, created_by, assigned_to, output, claim_expires_at, due_at, labels, custom_fields, milestone_id, required_skills, version, created_at, updated_at`
+
+// qualifiedTaskColumns returns TaskColumns prefixed with a table alias, for
+// queries that join tasks with other tables
+func qualifiedTaskColumns(alias string) string {
+	cols := strings.Split(TaskColumns, ", ")
+	for i, col := range cols {
+		cols[i] = alias + "." + col
+	}
+	return strings.Join(cols, ", ")
+}
+
+// RowScanner is implemented by *sql.Row and *sql.Rows
+type RowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// ScanTask scans a row selected with TaskColumns into a Task
+func ScanTask(row RowScanner) (models.Task, error) {
+	var task models.Task
+	var parentID, assignedTo, milestoneID uuid.NullUUID
+	var description, output, role, team sql.NullString
+	var claimExpiresAt, dueAt sql.NullTime
+	var customFieldsJSON []byte
+
+	err := row.Scan(&task.ID, &task.ProjectID, &parentID, &task.Title, &description, &task.Status, &task.Priority,
+		&role, &team, &task.CreatedBy, &assignedTo, &output, &claimExpiresAt, &dueAt, &task.Labels, &customFieldsJSON, &milestoneID, &task.RequiredSkills, &task.Version, &task.CreatedAt, &task.UpdatedAt)
+	if err != nil {
+		return task, err
+	}
+
+	// Handle nullable fields
+	if parentID.Valid {
+		task.ParentID = &parentID.UUID
+	}
+	if assignedTo.Valid {
+		task.AssignedTo = &assignedTo.UUID
+	}
+	if milestoneID.Valid {
+		task.MilestoneID = &milestoneID.UUID
+	}
+	if claimExpiresAt.Valid {
+		task.ClaimExpiresAt = &claimExpiresAt.Time
+	}
+	if dueAt.Valid {
+		task.DueAt = &dueAt.Time
+	}
+	task.Description = description.String
+	task.Output = output.String
+	task.Role = models.AgentRole(role.String)
+	task.Team = team.String
+	task.CustomFields = models.CustomFields{}
+	if len(customFieldsJSON) > 0 {
+		_ = json.Unmarshal(customFieldsJSON, &task.CustomFields)
+	}
+
+	return task, nil
+}
+
+// GetTask loads a single task by ID. It returns sql.ErrNoRows if the task does not exist.
+func GetTask(q Querier, id uuid.UUID) (models.Task, error) {
+	return ScanTask(q.QueryRow(`SELECT `+TaskColumns+` FROM tasks WHERE id = $1`, id))
+}
+
+// ListTaskChildren returns the direct subtasks of a task
+func ListTaskChildren(q Querier, parentID uuid.UUID) ([]models.Task, error) {
+	rows, err := q.Query(`SELECT `+TaskColumns+` FROM tasks WHERE parent_id = $1 ORDER BY created_at`, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subtasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		task, err := ScanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subtask: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// GetTaskProgress rolls up the completion of a task's direct subtasks
+func GetTaskProgress(q Querier, taskID uuid.UUID) (models.TaskProgress, error) {
+	var subtasks, completed, cancelled int
+	err := q.QueryRow(`
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE status IN `+completedStatuses+`), COUNT(*) FILTER (WHERE status = $2)
+		FROM tasks
+		WHERE parent_id = $1
+	`, taskID, models.StatusCancelled).Scan(&subtasks, &completed, &cancelled)
+	if err != nil {
+		return models.TaskProgress{TaskID: taskID}, fmt.Errorf("failed to compute task progress: %w", err)
+	}
+	return models.NewTaskProgress(taskID, subtasks, completed, cancelled), nil
+}
+
+// closedStatuses lists the subtask statuses that no longer hold up their parent
+const closedStatuses = `('completed', 'cancelled')`
+
+// CompleteFinishedAncestors walks up from taskID and marks every open ancestor
+// whose subtasks are all completed or cancelled as completed, recording the
+// change in the task history. It returns the tasks that were changed so
+// callers can broadcast them.
+func CompleteFinishedAncestors(q Querier, taskID uuid.UUID) ([]models.Task, error) {
+	var completed []models.Task
+
+	current := taskID
+	for {
+		var parentID uuid.NullUUID
+		err := q.QueryRow(`SELECT parent_id FROM tasks WHERE id = $1`, current).Scan(&parentID)
+		if err == sql.ErrNoRows || (err == nil && !parentID.Valid) {
+			return completed, nil
+		} else if err != nil {
+			return completed, fmt.Errorf("failed to load parent task: %w", err)
+		}
+
+		// Only complete the parent if none of its subtasks are still open
+		before, parent, err := scanTaskChange(q.QueryRow(`
+			UPDATE tasks p
+			SET status = $1, claim_expires_at = NULL, updated_at = NOW()
+			FROM tasks old
+			WHERE old.id = p.id AND p.id = $2
+			  AND p.status IN ($3, $4)
+			  AND NOT EXISTS (
+				SELECT 1 FROM tasks c
+				WHERE c.parent_id = p.id AND c.status NOT IN `+closedStatuses+`
+			  )
+			RETURNING `+qualifiedTaskColumns("p")+`, `+previousTaskColumns,
+			models.StatusCompleted, parentID.UUID, models.StatusPending, models.StatusInProgress))
+		if err == sql.ErrNoRows {
+			return completed, nil
+		} else if err != nil {
+			return completed, fmt.Errorf("failed to complete parent task: %w", err)
+		}
+		if err := RecordTaskEvents(q, models.TaskChangeEvents(before, parent, nil)...); err != nil {
+			return completed, err
+		}
+
+		completed = append(completed, parent)
+		current = parent.ID
+	}
+}
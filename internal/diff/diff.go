@@ -0,0 +1,207 @@
+// Package diff computes line-based differences between two texts and renders
+// them in unified diff format.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is the type of a single edit
+type Kind int
+
+const (
+	Equal Kind = iota
+	Insert
+	Delete
+)
+
+// Edit is one line of an edit script turning a into b
+type Edit struct {
+	Kind Kind
+	Line string
+}
+
+// DefaultContext is the number of unchanged lines shown around each change
+const DefaultContext = 3
+
+// MaxEdits bounds the work of Lines. Texts that differ in more lines are
+// diffed as a whole replacement.
+const MaxEdits = 2000
+
+// Lines returns the shortest edit script turning a into b, using Myers'
+// O((N+M)D) algorithm. The trace it keeps takes O(D²) memory, so when the
+// script would be longer than MaxEdits it returns a script deleting all of a
+// and inserting all of b instead.
+func Lines(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	max := n + m
+	if max > MaxEdits {
+		max = MaxEdits
+	}
+	offset := max + 1
+	v := make([]int, 2*max+3)
+
+	// trace[d] holds the furthest reaching x per diagonal before step d, for
+	// the diagonals -d to d that step d reads
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	return replace(a, b)
+}
+
+// replace returns the edit script deleting all of a and inserting all of b
+func replace(a, b []string) []Edit {
+	edits := make([]Edit, 0, len(a)+len(b))
+	for _, line := range a {
+		edits = append(edits, Edit{Kind: Delete, Line: line})
+	}
+	for _, line := range b {
+		edits = append(edits, Edit{Kind: Insert, Line: line})
+	}
+	return edits
+}
+
+// backtrack walks the trace from the end of both texts back to the start
+func backtrack(trace [][]int, a, b []string) []Edit {
+	x, y := len(a), len(b)
+	var edits []Edit
+
+	for d := len(trace) - 1; d > 0; d-- {
+		v, offset := trace[d], d
+		k := x - y
+
+		prevK := k - 1
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, Edit{Kind: Equal, Line: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			edits = append(edits, Edit{Kind: Insert, Line: b[y-1]})
+		} else {
+			edits = append(edits, Edit{Kind: Delete, Line: a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, Edit{Kind: Equal, Line: a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// SplitLines splits text into lines, ignoring a single trailing newline
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Unified renders the difference between two texts as a unified diff with
+// the given number of context lines. It returns an empty string if the texts
+// are equal.
+func Unified(fromName, toName, a, b string, context int) string {
+	edits := Lines(SplitLines(a), SplitLines(b))
+
+	var changes []int
+	for i, e := range edits {
+		if e.Kind != Equal {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	// aLine and bLine count the lines of a and b consumed before edits[i]
+	aLine, bLine := make([]int, len(edits)+1), make([]int, len(edits)+1)
+	for i, e := range edits {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if e.Kind != Insert {
+			aLine[i+1]++
+		}
+		if e.Kind != Delete {
+			bLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(changes); {
+		// Merge changes whose context would overlap into a single hunk
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*context {
+			j++
+		}
+		start := changes[i] - context
+		if start < 0 {
+			start = 0
+		}
+		end := changes[j] + context + 1
+		if end > len(edits) {
+			end = len(edits)
+		}
+
+		aCount, bCount := aLine[end]-aLine[start], bLine[end]-bLine[start]
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aLine[start], aCount), hunkRange(bLine[start], bCount))
+		for _, e := range edits[start:end] {
+			switch e.Kind {
+			case Equal:
+				out.WriteString(" ")
+			case Insert:
+				out.WriteString("+")
+			case Delete:
+				out.WriteString("-")
+			}
+			out.WriteString(e.Line)
+			out.WriteString("\n")
+		}
+		i = j + 1
+	}
+	return out.String()
+}
+
+// hunkRange formats the start,count part of a hunk header. An empty range
+// refers to the line before it, as in GNU diff.
+func hunkRange(before, count int) string {
+	start := before + 1
+	if count == 0 {
+		start = before
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
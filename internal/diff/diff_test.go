@@ -0,0 +1,101 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedEqualTexts(t *testing.T) {
+	if got := Unified("a", "b", "one\ntwo\n", "one\ntwo\n", DefaultContext); got != "" {
+		t.Errorf("Expected empty diff for equal texts, got %q", got)
+	}
+}
+
+func TestUnifiedChangedLine(t *testing.T) {
+	a := "# API\n\nGET /tasks\nPOST /tasks\nDELETE /tasks\n"
+	b := "# API\n\nGET /tasks\nPUT /tasks\nDELETE /tasks\n"
+
+	want := strings.Join([]string{
+		"--- rev 1",
+		"+++ rev 2",
+		"@@ -1,5 +1,5 @@",
+		" # API",
+		" ",
+		" GET /tasks",
+		"-POST /tasks",
+		"+PUT /tasks",
+		" DELETE /tasks",
+		"",
+	}, "\n")
+
+	if got := Unified("rev 1", "rev 2", a, b, DefaultContext); got != want {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedFromEmpty(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+first\n+second\n"
+	if got := Unified("a", "b", "", "first\nsecond", DefaultContext); got != want {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedSeparateHunks(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 20; i++ {
+		line := string(rune('a' + i))
+		a = append(a, line)
+		b = append(b, line)
+	}
+	b[1] = "changed"
+	b[18] = "changed"
+
+	got := Unified("a", "b", strings.Join(a, "\n"), strings.Join(b, "\n"), 1)
+	if strings.Count(got, "@@ -") != 2 {
+		t.Errorf("Expected two hunks, got:\n%s", got)
+	}
+	if !strings.Contains(got, "@@ -1,3 +1,3 @@\n a\n-b\n+changed\n c\n") {
+		t.Errorf("Unexpected first hunk:\n%s", got)
+	}
+}
+
+func TestLinesRoundTrip(t *testing.T) {
+	a := []string{"a", "b", "c", "a", "b", "b", "a"}
+	b := []string{"c", "b", "a", "b", "a", "c"}
+
+	var gotA, gotB []string
+	for _, e := range Lines(a, b) {
+		if e.Kind != Insert {
+			gotA = append(gotA, e.Line)
+		}
+		if e.Kind != Delete {
+			gotB = append(gotB, e.Line)
+		}
+	}
+
+	if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+		t.Errorf("Edit script does not reproduce inputs: %v / %v", gotA, gotB)
+	}
+}
+
+func TestLinesMaxEdits(t *testing.T) {
+	var a, b []string
+	for i := 0; i < MaxEdits; i++ {
+		a = append(a, fmt.Sprintf("a%d", i))
+		b = append(b, fmt.Sprintf("b%d", i))
+	}
+
+	// Long texts with a single change still get the shortest script
+	changed := append([]string(nil), a...)
+	changed[MaxEdits/2] = "changed"
+	if edits := Lines(a, changed); len(edits) != MaxEdits+1 {
+		t.Errorf("Expected %d edits for a single changed line, got %d", MaxEdits+1, len(edits))
+	}
+
+	// Texts differing in more than MaxEdits lines are replaced as a whole
+	edits := Lines(a, b)
+	if len(edits) != 2*MaxEdits || edits[0].Kind != Delete || edits[MaxEdits].Kind != Insert {
+		t.Errorf("Expected a whole replacement of %d edits, got %d", 2*MaxEdits, len(edits))
+	}
+}
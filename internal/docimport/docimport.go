@@ -0,0 +1,345 @@
+// Package docimport reads a directory of markdown files, packed as a tar,
+// tar.gz or zip archive, so it can be imported as contexts keyed by path.
+package docimport
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/techbuzzz/agent-shaker/internal/markdown"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// Limits on what an archive may hold. MaxTotalSize caps the decompressed
+// bytes read from an archive, so a small archive cannot expand without bound.
+const (
+	MaxFileSize  = 1 << 20 // Largest markdown file that is imported
+	MaxFiles     = 5000    // Most entries an archive may have
+	MaxTotalSize = 64 << 20
+)
+
+// Document is a markdown file read from an archive
+type Document struct {
+	Path    string   // Slash-separated path inside the archive, the import key
+	Title   string   // Front matter title, first heading or file name
+	Content string   // File content including front matter
+	Tags    []string // Directories the file is in
+}
+
+// Skipped is a file of the archive that was not imported
+type Skipped struct {
+	Path   string
+	Reason string
+}
+
+var (
+	// ErrUnsupportedArchive is returned for data that is not a tar, tar.gz or zip archive
+	ErrUnsupportedArchive = errors.New("unsupported archive, expected tar, tar.gz or zip")
+	// ErrTooManyFiles is returned for archives with more than MaxFiles entries
+	ErrTooManyFiles = fmt.Errorf("archive has more than %d files", MaxFiles)
+	// ErrArchiveTooLarge is returned for archives that decompress to more than MaxTotalSize bytes
+	ErrArchiveTooLarge = fmt.Errorf("archive decompresses to more than %d MB", MaxTotalSize>>20)
+)
+
+// IsMarkdown reports whether a file name has a markdown extension
+func IsMarkdown(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// ReadArchive reads the markdown files of a tar, tar.gz or zip archive,
+// detected from its content. Other files, hidden files and files that are
+// empty, too large or not UTF-8 are reported as skipped. Archives with too
+// many entries or too much content fail with ErrTooManyFiles or
+// ErrArchiveTooLarge.
+func ReadArchive(data []byte) ([]Document, []Skipped, error) {
+	r := &reader{remaining: MaxTotalSize}
+
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")) || bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		if err := r.readZip(data); err != nil {
+			return nil, nil, err
+		}
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid gzip archive: %w", err)
+		}
+		defer gz.Close()
+		if err := r.readTar(gz); err != nil {
+			return nil, nil, err
+		}
+	case len(data) > 262 && string(data[257:262]) == "ustar":
+		if err := r.readTar(bytes.NewReader(data)); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, ErrUnsupportedArchive
+	}
+
+	return r.docs, r.skipped, nil
+}
+
+type reader struct {
+	docs      []Document
+	skipped   []Skipped
+	files     int
+	remaining int64 // Decompressed bytes left to read
+}
+
+// budget counts the bytes read from src against the archive's total size
+type budget struct {
+	src io.Reader
+	r   *reader
+}
+
+func (b budget) Read(p []byte) (int, error) {
+	n, err := b.src.Read(p)
+	b.r.remaining -= int64(n)
+	if b.r.remaining < 0 {
+		return n, ErrArchiveTooLarge
+	}
+	return n, err
+}
+
+// count records another entry and fails once there are too many
+func (r *reader) count() error {
+	r.files++
+	if r.files > MaxFiles {
+		return ErrTooManyFiles
+	}
+	return nil
+}
+
+func (r *reader) readTar(src io.Reader) error {
+	// The whole stream counts, including entries that are skipped
+	tr := tar.NewReader(budget{src: src, r: r})
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if errors.Is(err, ErrArchiveTooLarge) {
+			return ErrArchiveTooLarge
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := r.count(); err != nil {
+			return err
+		}
+		if err := r.add(hdr.Name, hdr.Size, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *reader) readZip(data []byte) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("invalid zip archive: %w", err)
+	}
+	if len(zr.File) > MaxFiles {
+		return ErrTooManyFiles
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := r.count(); err != nil {
+			return err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			r.skipped = append(r.skipped, Skipped{Path: f.Name, Reason: err.Error()})
+			continue
+		}
+		err = r.add(f.Name, int64(f.UncompressedSize64), budget{src: rc, r: r})
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add reads one archive entry and records it as a document or as skipped. It
+// only fails when the archive exceeds its total size.
+func (r *reader) add(name string, size int64, src io.Reader) error {
+	p := CleanPath(name)
+	switch {
+	case p == "":
+		r.skipped = append(r.skipped, Skipped{Path: name, Reason: "invalid path"})
+		return nil
+	case isHidden(p):
+		r.skipped = append(r.skipped, Skipped{Path: p, Reason: "hidden file"})
+		return nil
+	case !IsMarkdown(p):
+		r.skipped = append(r.skipped, Skipped{Path: p, Reason: "not a markdown file"})
+		return nil
+	case size > MaxFileSize:
+		r.skipped = append(r.skipped, Skipped{Path: p, Reason: "file too large"})
+		return nil
+	}
+
+	content, err := io.ReadAll(io.LimitReader(src, MaxFileSize+1))
+	switch {
+	case errors.Is(err, ErrArchiveTooLarge):
+		return err
+	case err != nil:
+		r.skipped = append(r.skipped, Skipped{Path: p, Reason: err.Error()})
+	case len(content) > MaxFileSize:
+		r.skipped = append(r.skipped, Skipped{Path: p, Reason: "file too large"})
+	case len(bytes.TrimSpace(content)) == 0:
+		r.skipped = append(r.skipped, Skipped{Path: p, Reason: "empty file"})
+	case !utf8.Valid(content):
+		r.skipped = append(r.skipped, Skipped{Path: p, Reason: "not valid UTF-8"})
+	default:
+		r.docs = append(r.docs, NewDocument(p, string(content)))
+	}
+	return nil
+}
+
+// NewDocument builds the document for a markdown file at a cleaned path
+func NewDocument(p, content string) Document {
+	return Document{
+		Path:    p,
+		Title:   Title(p, content),
+		Content: content,
+		Tags:    Tags(p),
+	}
+}
+
+// CleanPath normalizes an archive entry name to a slash-separated path
+// relative to the archive root, so "./docs//a.md" and "docs/a.md" are the same
+// key. It returns "" for the root itself.
+func CleanPath(name string) string {
+	p := path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	return strings.TrimPrefix(p, "/")
+}
+
+// isHidden reports whether any element of a path starts with a dot or is a
+// macOS resource fork directory
+func isHidden(p string) bool {
+	for _, part := range strings.Split(p, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// Title picks the context title of a file: the front matter "title", else the
+// first level-one heading, else the file name without extension
+func Title(p, content string) string {
+	fm, body, err := markdown.SplitFrontMatter(content)
+	if err == nil {
+		if title, ok := fm["title"].(string); ok && strings.TrimSpace(title) != "" {
+			return truncate(strings.TrimSpace(title))
+		}
+	} else {
+		body = content
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		if heading := strings.TrimPrefix(strings.TrimSpace(line), "# "); heading != strings.TrimSpace(line) {
+			if heading = strings.TrimSpace(heading); heading != "" {
+				return truncate(heading)
+			}
+		}
+	}
+
+	base := path.Base(p)
+	return truncate(strings.TrimSuffix(base, path.Ext(base)))
+}
+
+// truncate keeps titles within the 255 characters of contexts.title
+func truncate(title string) string {
+	if utf8.RuneCountInString(title) <= 255 {
+		return title
+	}
+	return string([]rune(title)[:255])
+}
+
+// Tags derives tags from the directories a file is in, so docs/api/auth.md
+// is tagged "docs" and "api"
+func Tags(p string) []string {
+	dir := path.Dir(p)
+	if dir == "." {
+		return []string{}
+	}
+	return models.NormalizeLabels(strings.Split(dir, "/"))
+}
+
+// WriteTarGz packs the markdown files below dir into a gzipped tar archive
+// with paths relative to dir. Hidden files and directories are left out. It
+// returns the number of files written.
+func WriteTarGz(w io.Writer, dir string) (int, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	count := 0
+
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if file != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !IsMarkdown(d.Name()) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		hdr := &tar.Header{
+			Name:     filepath.ToSlash(rel),
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("failed to pack %s: %w", dir, err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return count, err
+	}
+	return count, gz.Close()
+}
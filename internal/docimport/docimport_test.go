@@ -0,0 +1,133 @@
+package docimport
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var archiveFiles = []struct{ name, content string }{
+	{"README.md", "# Overview\n\nHello"},
+	{"docs/api/auth.md", "---\ntitle: Authentication\n---\n# Auth\n"},
+	{"./docs/Guides/setup.markdown", "No heading here"},
+	{"docs/logo.png", "\x89PNG"},
+	{".github/notes.md", "# Hidden"},
+	{"docs/empty.md", "  \n"},
+}
+
+func wantDocuments() []Document {
+	return []Document{
+		{Path: "README.md", Title: "Overview", Content: "# Overview\n\nHello", Tags: []string{}},
+		{Path: "docs/api/auth.md", Title: "Authentication", Content: "---\ntitle: Authentication\n---\n# Auth\n", Tags: []string{"docs", "api"}},
+		{Path: "docs/Guides/setup.markdown", Title: "setup", Content: "No heading here", Tags: []string{"docs", "guides"}},
+	}
+}
+
+func checkArchive(t *testing.T, data []byte) {
+	t.Helper()
+
+	docs, skipped, err := ReadArchive(data)
+	if err != nil {
+		t.Fatalf("ReadArchive failed: %v", err)
+	}
+	if !reflect.DeepEqual(docs, wantDocuments()) {
+		t.Errorf("Expected documents %+v, got %+v", wantDocuments(), docs)
+	}
+
+	wantSkipped := []Skipped{
+		{Path: "docs/logo.png", Reason: "not a markdown file"},
+		{Path: ".github/notes.md", Reason: "hidden file"},
+		{Path: "docs/empty.md", Reason: "empty file"},
+	}
+	if !reflect.DeepEqual(skipped, wantSkipped) {
+		t.Errorf("Expected skipped %+v, got %+v", wantSkipped, skipped)
+	}
+}
+
+func TestReadTarArchive(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range archiveFiles {
+		tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(f.content))
+	}
+	tw.Close()
+
+	checkArchive(t, buf.Bytes())
+}
+
+func TestReadZipArchive(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range archiveFiles {
+		w, _ := zw.Create(f.name)
+		w.Write([]byte(f.content))
+	}
+	zw.Close()
+
+	checkArchive(t, buf.Bytes())
+}
+
+func TestReadArchiveUnsupported(t *testing.T) {
+	if _, _, err := ReadArchive([]byte("# just markdown")); err != ErrUnsupportedArchive {
+		t.Errorf("Expected ErrUnsupportedArchive, got %v", err)
+	}
+}
+
+func TestWriteTarGz(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range archiveFiles {
+		file := filepath.Join(dir, filepath.FromSlash(f.name))
+		os.MkdirAll(filepath.Dir(file), 0755)
+		os.WriteFile(file, []byte(f.content), 0644)
+	}
+
+	var buf bytes.Buffer
+	count, err := WriteTarGz(&buf, dir)
+	if err != nil {
+		t.Fatalf("WriteTarGz failed: %v", err)
+	}
+	// Hidden directories and non-markdown files are not packed
+	if count != 4 {
+		t.Errorf("Expected 4 files, got %d", count)
+	}
+
+	docs, _, err := ReadArchive(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ReadArchive failed: %v", err)
+	}
+	if len(docs) != 3 {
+		t.Errorf("Expected 3 documents, got %+v", docs)
+	}
+}
+
+func TestReadArchiveLimits(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i := 0; i <= MaxFiles; i++ {
+		tw.WriteHeader(&tar.Header{Name: fmt.Sprintf("doc%d.md", i), Mode: 0644, Size: 1, Typeflag: tar.TypeReg})
+		tw.Write([]byte("x"))
+	}
+	tw.Close()
+	if _, _, err := ReadArchive(buf.Bytes()); err != ErrTooManyFiles {
+		t.Errorf("Expected ErrTooManyFiles, got %v", err)
+	}
+
+	// Files of zeros compress well but must not expand past MaxTotalSize
+	buf.Reset()
+	zw := zip.NewWriter(&buf)
+	zeros := make([]byte, MaxFileSize)
+	for i := 0; i <= MaxTotalSize/MaxFileSize; i++ {
+		w, _ := zw.Create(fmt.Sprintf("zeros%d.md", i))
+		w.Write(zeros)
+	}
+	zw.Close()
+	if _, _, err := ReadArchive(buf.Bytes()); err != ErrArchiveTooLarge {
+		t.Errorf("Expected ErrArchiveTooLarge, got %v", err)
+	}
+}
@@ -3,11 +3,14 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
 	"github.com/techbuzzz/agent-shaker/internal/database"
 	"github.com/techbuzzz/agent-shaker/internal/models"
 	"github.com/techbuzzz/agent-shaker/internal/validator"
@@ -35,22 +38,50 @@ func (h *AgentHandler) CreateAgent(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	req.Skills = models.NormalizeSkills(req.Skills)
+	if err := validator.ValidateSkills(req.Skills); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	project, err := database.GetProject(h.db, req.ProjectID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Project not found", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve project", http.StatusInternalServerError)
+		return
+	}
+	if !project.Settings.AllowsRole(req.Role) {
+		http.Error(w, fmt.Sprintf("Role %q is not allowed in this project", req.Role), http.StatusBadRequest)
+		return
+	}
+
+	// Only coordinators may grant more or less than the default access
+	if req.AccessRole == "" {
+		req.AccessRole = models.AccessWorker
+	} else if req.AccessRole != models.AccessWorker && !authorize(w, r, req.ProjectID, auth.PermManageProject) {
+		return
+	}
 
 	agent := models.Agent{
-		ID:        uuid.New(),
-		ProjectID: req.ProjectID,
-		Name:      req.Name,
-		Role:      req.Role,
-		Team:      req.Team,
-		Status:    "active",
-		LastSeen:  time.Now(),
-		CreatedAt: time.Now(),
-	}
-
-	_, err := h.db.Exec(`
-		INSERT INTO agents (id, project_id, name, role, team, status, last_seen, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-	`, agent.ID, agent.ProjectID, agent.Name, agent.Role, agent.Team, agent.Status, agent.LastSeen, agent.CreatedAt)
+		ID:         uuid.New(),
+		ProjectID:  req.ProjectID,
+		Name:       req.Name,
+		Role:       req.Role,
+		AccessRole: req.AccessRole,
+		Team:       req.Team,
+		Skills:     pq.StringArray(req.Skills),
+		Capacity:   req.Capacity,
+		Status:     "active",
+		LastSeen:   time.Now(),
+		CreatedAt:  time.Now(),
+	}
+
+	_, err = h.db.Exec(`
+		INSERT INTO agents (id, project_id, name, role, access_role, team, skills, capacity, status, last_seen, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, agent.ID, agent.ProjectID, agent.Name, agent.Role, agent.AccessRole, agent.Team, agent.Skills, agent.Capacity, agent.Status, agent.LastSeen, agent.CreatedAt)
 	if err != nil {
 		http.Error(w, "Failed to create agent", http.StatusInternalServerError)
 		return
@@ -73,7 +104,7 @@ func (h *AgentHandler) ListAgents(w http.ResponseWriter, r *http.Request) {
 	if projectIDStr == "" {
 		// If no project_id, return all agents
 		rows, err = h.db.Query(`
-			SELECT id, project_id, name, role, team, status, last_seen, created_at
+			SELECT ` + database.AgentColumns + `
 			FROM agents
 			ORDER BY created_at DESC
 		`)
@@ -86,7 +117,7 @@ func (h *AgentHandler) ListAgents(w http.ResponseWriter, r *http.Request) {
 		}
 
 		rows, err = h.db.Query(`
-			SELECT id, project_id, name, role, team, status, last_seen, created_at
+			SELECT `+database.AgentColumns+`
 			FROM agents
 			WHERE project_id = $1
 			ORDER BY created_at DESC
@@ -101,8 +132,8 @@ func (h *AgentHandler) ListAgents(w http.ResponseWriter, r *http.Request) {
 
 	var agents []models.Agent
 	for rows.Next() {
-		var a models.Agent
-		if err := rows.Scan(&a.ID, &a.ProjectID, &a.Name, &a.Role, &a.Team, &a.Status, &a.LastSeen, &a.CreatedAt); err != nil {
+		a, err := database.ScanAgent(rows)
+		if err != nil {
 			http.Error(w, "Failed to scan agent", http.StatusInternalServerError)
 			return
 		}
@@ -126,12 +157,7 @@ func (h *AgentHandler) GetAgent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var agent models.Agent
-	err = h.db.QueryRow(`
-		SELECT id, project_id, name, role, team, status, last_seen, created_at
-		FROM agents
-		WHERE id = $1
-	`, id).Scan(&agent.ID, &agent.ProjectID, &agent.Name, &agent.Role, &agent.Team, &agent.Status, &agent.LastSeen, &agent.CreatedAt)
+	agent, err := database.GetAgent(h.db, id)
 	if err == sql.ErrNoRows {
 		http.Error(w, "Agent not found", http.StatusNotFound)
 		return
@@ -164,23 +190,63 @@ func (h *AgentHandler) UpdateAgentStatus(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	_, err = h.db.Exec(`
+	agent, err := database.GetAgent(h.db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Agent not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve agent", http.StatusInternalServerError)
+		return
+	}
+
+	// Agents set their own status; coordinators may set any agent's in their project
+	if !canManageAgent(r, id) && !authorize(w, r, agent.ProjectID, auth.PermManageProject) {
+		return
+	}
+
+	agent, err = database.ScanAgent(h.db.QueryRow(`
 		UPDATE agents
 		SET status = $1, last_seen = $2
 		WHERE id = $3
-	`, req.Status, time.Now(), id)
-	if err != nil {
+		RETURNING `+database.AgentColumns,
+		req.Status, time.Now(), id))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Agent not found", http.StatusNotFound)
+		return
+	} else if err != nil {
 		http.Error(w, "Failed to update agent status", http.StatusInternalServerError)
 		return
 	}
 
-	// Get updated agent
-	var agent models.Agent
-	err = h.db.QueryRow(`
-		SELECT id, project_id, name, role, team, status, last_seen, created_at
-		FROM agents
-		WHERE id = $1
-	`, id).Scan(&agent.ID, &agent.ProjectID, &agent.Name, &agent.Role, &agent.Team, &agent.Status, &agent.LastSeen, &agent.CreatedAt)
+	// Broadcast agent update
+	h.hub.BroadcastToProject(agent.ProjectID, "agent_update", agent)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(agent)
+}
+
+// UpdateAgentAccess changes the access role of an agent. Only the operator
+// and coordinators of the agent's project may do so.
+func (h *AgentHandler) UpdateAgentAccess(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid agent ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateAgentAccessRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.ValidateUpdateAgentAccessRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, err := database.GetAgent(h.db, id)
 	if err == sql.ErrNoRows {
 		http.Error(w, "Agent not found", http.StatusNotFound)
 		return
@@ -189,6 +255,89 @@ func (h *AgentHandler) UpdateAgentStatus(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if !authorize(w, r, current.ProjectID, auth.PermManageProject) {
+		return
+	}
+
+	agent, err := database.ScanAgent(h.db.QueryRow(`
+		UPDATE agents SET access_role = $1
+		WHERE id = $2
+		RETURNING `+database.AgentColumns,
+		req.AccessRole, id))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Agent not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update agent access", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast agent update
+	h.hub.BroadcastToProject(agent.ProjectID, "agent_update", agent)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(agent)
+}
+
+// UpdateAgentProfile changes the skills or capacity an agent declares. An
+// agent may update its own profile; coordinators may update any profile of
+// their project.
+func (h *AgentHandler) UpdateAgentProfile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid agent ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateAgentProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.ValidateUpdateAgentProfileRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	agent, err := database.GetAgent(h.db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Agent not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve agent", http.StatusInternalServerError)
+		return
+	}
+
+	if !canManageAgent(r, id) && !authorize(w, r, agent.ProjectID, auth.PermManageProject) {
+		return
+	}
+
+	if req.Skills != nil {
+		agent.Skills = models.NormalizeSkills(*req.Skills)
+		if err := validator.ValidateSkills(agent.Skills); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Capacity != nil {
+		agent.Capacity = *req.Capacity
+	}
+
+	agent, err = database.ScanAgent(h.db.QueryRow(`
+		UPDATE agents SET skills = $1, capacity = $2
+		WHERE id = $3
+		RETURNING `+database.AgentColumns,
+		agent.Skills, agent.Capacity, id))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Agent not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update agent profile", http.StatusInternalServerError)
+		return
+	}
+
 	// Broadcast agent update
 	h.hub.BroadcastToProject(agent.ProjectID, "agent_update", agent)
 
@@ -225,6 +374,11 @@ func (h *AgentHandler) DeleteAgent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Deleting an agent also deletes its tasks and contexts
+	if !authorize(w, r, agent.ProjectID, auth.PermManageProject) {
+		return
+	}
+
 	// Delete related contexts (they reference tasks which reference agents)
 	_, err = tx.Exec("DELETE FROM contexts WHERE task_id IN (SELECT id FROM tasks WHERE agent_id = $1)", id)
 	if err != nil {
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+	"github.com/techbuzzz/agent-shaker/internal/validator"
+)
+
+// ListAPIKeys returns the API keys of an agent without their secrets
+func (h *AgentHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := h.keyOwner(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := database.ListAPIKeys(h.db, agentID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve API keys", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// CreateAPIKey issues a new API key for an agent. The key is only returned
+// in this response.
+func (h *AgentHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := h.keyOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.ValidateCreateAPIKeyRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	issued, err := database.CreateAPIKey(h.db, agentID, req.Name)
+	if err != nil {
+		http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(issued)
+}
+
+// RevokeAPIKey revokes an API key of an agent. Requests using it are
+// rejected from then on.
+func (h *AgentHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := h.keyOwner(w, r)
+	if !ok {
+		return
+	}
+	keyID, err := uuid.Parse(mux.Vars(r)["keyId"])
+	if err != nil {
+		http.Error(w, "Invalid API key ID format", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := database.RevokeAPIKey(h.db, agentID, keyID); err == sql.ErrNoRows {
+		http.Error(w, "API key not found or already revoked", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RotateAPIKey revokes an API key of an agent and issues a replacement with
+// the same name in one transaction
+func (h *AgentHandler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := h.keyOwner(w, r)
+	if !ok {
+		return
+	}
+	keyID, err := uuid.Parse(mux.Vars(r)["keyId"])
+	if err != nil {
+		http.Error(w, "Invalid API key ID format", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	issued, err := database.RotateAPIKey(tx, agentID, keyID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "API key not found or already revoked", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to rotate API key", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(issued)
+}
+
+// keyOwner parses the agent of a key management request and checks that the
+// agent exists and that the caller may manage its keys. It writes the error
+// response and returns false otherwise.
+func (h *AgentHandler) keyOwner(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid agent ID format", http.StatusBadRequest)
+		return id, false
+	}
+
+	if !canManageAgent(r, id) {
+		http.Error(w, "Forbidden: agents can only manage their own API keys", http.StatusForbidden)
+		return id, false
+	}
+
+	if _, err := database.GetAgent(h.db, id); err == sql.ErrNoRows {
+		http.Error(w, "Agent not found", http.StatusNotFound)
+		return id, false
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve agent", http.StatusInternalServerError)
+		return id, false
+	}
+	return id, true
+}
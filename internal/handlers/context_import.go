@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/docimport"
+	"github.com/techbuzzz/agent-shaker/internal/markdown"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// MaxImportSize is the largest archive accepted by ImportContexts
+const MaxImportSize = 32 << 20
+
+// ImportContexts creates or updates the contexts of a project from a tar,
+// tar.gz or zip archive of markdown files sent as the request body. Contexts
+// are keyed by file path, so importing the same archive again only updates
+// files that changed. Directories become tags.
+func (h *ContextHandler) ImportContexts(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !authorize(w, r, projectID, auth.PermWriteContexts) {
+		return
+	}
+
+	// Imported contexts are authored by ?agent_id=, falling back to the acting agent
+	var author uuid.UUID
+	if agentParam := r.URL.Query().Get("agent_id"); agentParam != "" {
+		author, err = uuid.Parse(agentParam)
+		if err != nil {
+			http.Error(w, "Invalid agent_id format", http.StatusBadRequest)
+			return
+		}
+	}
+	if !actingAgent(w, r, &author) {
+		return
+	}
+	if author == uuid.Nil {
+		http.Error(w, "agent_id query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := database.GetProject(h.db, projectID); err == sql.ErrNoRows {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve project", http.StatusInternalServerError)
+		return
+	}
+
+	agent, err := database.GetAgent(h.db, author)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Agent not found", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to verify agent", http.StatusInternalServerError)
+		return
+	}
+	if agent.ProjectID != projectID {
+		http.Error(w, "Agent belongs to a different project", http.StatusBadRequest)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxImportSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, "Archive too large", http.StatusRequestEntityTooLarge)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	docs, skipped, err := docimport.ReadArchive(data)
+	if errors.Is(err, docimport.ErrArchiveTooLarge) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	result := models.ContextImportResult{ProjectID: projectID, Files: []models.ContextImportFile{}}
+	for _, s := range skipped {
+		result.Add(models.ContextImportFile{Path: s.Path, Action: models.ContextImportSkipped, Reason: s.Reason})
+	}
+
+	for _, doc := range docs {
+		file, err := importDocument(tx, projectID, author, doc)
+		if err != nil {
+			http.Error(w, "Failed to import "+doc.Path, http.StatusInternalServerError)
+			return
+		}
+		result.Add(file)
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	h.hub.BroadcastToProject(projectID, "contexts_imported", map[string]interface{}{
+		"created":    result.Created,
+		"updated":    result.Updated,
+		"skipped":    result.Skipped,
+		"project_id": projectID.String(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// importDocument creates the context for a document, or updates the context
+// previously imported from the same path if its title, content or tags changed
+func importDocument(q database.Querier, projectID, author uuid.UUID, doc docimport.Document) (models.ContextImportFile, error) {
+	file := models.ContextImportFile{Path: doc.Path}
+
+	if _, _, err := markdown.SplitFrontMatter(doc.Content); err != nil {
+		file.Action = models.ContextImportSkipped
+		file.Reason = err.Error()
+		return file, nil
+	}
+
+	existing, err := database.LockContextBySourcePath(q, projectID, doc.Path)
+	if err == sql.ErrNoRows {
+		created, err := database.InsertContext(q, models.Context{
+			ProjectID:  projectID,
+			AgentID:    author,
+			Title:      doc.Title,
+			Content:    doc.Content,
+			Tags:       doc.Tags,
+			SourcePath: &doc.Path,
+		})
+		if err != nil {
+			return file, err
+		}
+		file.Action = models.ContextImportCreated
+		file.ContextID = &created.ID
+		return file, nil
+	} else if err != nil {
+		return file, err
+	}
+
+	file.ContextID = &existing.ID
+	if existing.Title == doc.Title && existing.Content == doc.Content && slices.Equal([]string(existing.Tags), doc.Tags) {
+		file.Action = models.ContextImportSkipped
+		file.Reason = "unchanged"
+		return file, nil
+	}
+
+	if _, err := database.UpdateContextContent(q, existing.ID, existing.TaskID, doc.Title, doc.Content, doc.Tags, &author); err != nil {
+		return file, err
+	}
+	file.Action = models.ContextImportUpdated
+	return file, nil
+}
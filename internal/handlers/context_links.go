@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// GetContextLinks returns the links of a context to other contexts, the
+// contexts linking back to it and which of its links are broken
+func (h *ContextHandler) GetContextLinks(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid context ID format", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := database.GetContext(h.db, id); err == sql.ErrNoRows {
+		http.Error(w, "Context not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve context", http.StatusInternalServerError)
+		return
+	}
+
+	outgoing, err := database.ListContextLinks(h.db, id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve context links", http.StatusInternalServerError)
+		return
+	}
+
+	backlinks, err := database.ListContextBacklinks(h.db, id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve context backlinks", http.StatusInternalServerError)
+		return
+	}
+
+	links := models.NewContextLinks(id, outgoing, backlinks)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/diff"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// ListContextRevisions returns the history of a context, newest revision first
+func (h *ContextHandler) ListContextRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid context ID format", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := database.GetContext(h.db, id); err == sql.ErrNoRows {
+		http.Error(w, "Context not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve context", http.StatusInternalServerError)
+		return
+	}
+
+	revisions, err := database.ListContextRevisions(h.db, id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve context revisions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// GetContextRevision returns a single revision of a context
+func (h *ContextHandler) GetContextRevision(w http.ResponseWriter, r *http.Request) {
+	id, revision, ok := parseContextRevision(w, r)
+	if !ok {
+		return
+	}
+
+	rev, err := database.GetContextRevision(h.db, id, revision)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Revision not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve revision", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rev)
+}
+
+// DiffContextRevisions returns a unified diff of the content between two
+// revisions. "to" defaults to the latest revision and "from" to the one before it.
+func (h *ContextHandler) DiffContextRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid context ID format", http.StatusBadRequest)
+		return
+	}
+
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if r.URL.Query().Get("to") == "" {
+		to, err = database.LatestContextRevision(h.db, id)
+		if err != nil {
+			http.Error(w, "Failed to retrieve context revisions", http.StatusInternalServerError)
+			return
+		}
+	} else if err != nil {
+		http.Error(w, "Invalid to revision", http.StatusBadRequest)
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if r.URL.Query().Get("from") == "" {
+		from = to - 1
+	} else if err != nil {
+		http.Error(w, "Invalid from revision", http.StatusBadRequest)
+		return
+	}
+
+	// Revision 0 is the empty document before the context was created
+	var fromRev, toRev models.ContextRevision
+	for _, side := range []struct {
+		revision int
+		target   *models.ContextRevision
+	}{{from, &fromRev}, {to, &toRev}} {
+		if side.revision == 0 {
+			continue
+		}
+		rev, err := database.GetContextRevision(h.db, id, side.revision)
+		if err == sql.ErrNoRows {
+			http.Error(w, fmt.Sprintf("Revision %d not found", side.revision), http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "Failed to retrieve revision", http.StatusInternalServerError)
+			return
+		}
+		*side.target = rev
+	}
+
+	result := models.ContextDiff{
+		ContextID:    id,
+		FromRevision: from,
+		ToRevision:   to,
+		Diff: diff.Unified(fmt.Sprintf("revision %d", from), fmt.Sprintf("revision %d", to),
+			fromRev.Content, toRev.Content, diff.DefaultContext),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// RestoreContextRevision makes an old revision the current version of a
+// context. The restore is recorded as a new revision so nothing is lost.
+func (h *ContextHandler) RestoreContextRevision(w http.ResponseWriter, r *http.Request) {
+	id, revision, ok := parseContextRevision(w, r)
+	if !ok {
+		return
+	}
+
+	expected, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	current, err := database.LockContext(tx, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Context not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve context", http.StatusInternalServerError)
+		return
+	}
+	if !authorize(w, r, current.ProjectID, auth.PermWriteContexts) {
+		return
+	}
+	if !checkVersion(w, expected, current.Version) {
+		return
+	}
+
+	rev, err := database.GetContextRevision(tx, id, revision)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Revision not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve revision", http.StatusInternalServerError)
+		return
+	}
+
+	// Revisions saved before front matter was parsed may not have valid YAML
+	frontMatter, err := database.FrontMatterJSON(rev.Content)
+	if err != nil {
+		frontMatter = []byte("{}")
+	}
+
+	restored, err := database.ScanContext(tx.QueryRow(`
+		UPDATE contexts
+		SET task_id = $1, title = $2, content = $3, tags = $4, front_matter = $5, updated_at = $6
+		WHERE id = $7
+		RETURNING `+database.ContextColumns,
+		rev.TaskID, rev.Title, rev.Content, rev.Tags, frontMatter, time.Now(), id))
+	if err != nil {
+		http.Error(w, "Failed to restore context", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := database.RecordContextRevision(tx, restored, requestActor(r)); err != nil {
+		http.Error(w, "Failed to record context revision", http.StatusInternalServerError)
+		return
+	}
+
+	if err := database.ReplaceContextLinks(tx, restored); err != nil {
+		http.Error(w, "Failed to index context links", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast context update
+	h.hub.BroadcastToProject(restored.ProjectID, "context_updated", restored)
+
+	setETag(w, restored.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(restored)
+}
+
+// parseContextRevision reads the context ID and revision number from the route
+func parseContextRevision(w http.ResponseWriter, r *http.Request) (uuid.UUID, int, bool) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid context ID format", http.StatusBadRequest)
+		return uuid.Nil, 0, false
+	}
+
+	revision, err := strconv.Atoi(vars["revision"])
+	if err != nil || revision < 1 {
+		http.Error(w, "Invalid revision number", http.StatusBadRequest)
+		return uuid.Nil, 0, false
+	}
+	return id, revision, true
+}
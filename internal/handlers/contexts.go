@@ -3,14 +3,16 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/lib/pq"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
 	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/markdown"
 	"github.com/techbuzzz/agent-shaker/internal/models"
 	"github.com/techbuzzz/agent-shaker/internal/validator"
 	"github.com/techbuzzz/agent-shaker/internal/websocket"
@@ -32,30 +34,47 @@ func (h *ContextHandler) CreateContext(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Requests authenticated as an agent act as that agent
+	if !actingAgent(w, r, &req.AgentID) {
+		return
+	}
+
 	// Validate request
 	if err := validator.ValidateCreateContextRequest(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	ctx := models.Context{
-		ID:        uuid.New(),
+	if !authorize(w, r, req.ProjectID, auth.PermWriteContexts) {
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Insert the context, starting its history with the first version
+	ctx, err := database.InsertContext(tx, models.Context{
 		ProjectID: req.ProjectID,
 		AgentID:   req.AgentID,
 		TaskID:    req.TaskID,
 		Title:     req.Title,
 		Content:   req.Content,
-		Tags:      pq.StringArray(req.Tags),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Tags:      req.Tags,
+	})
+	if errors.Is(err, markdown.ErrInvalidFrontMatter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to create context", http.StatusInternalServerError)
+		return
 	}
 
-	_, err := h.db.Exec(`
-		INSERT INTO contexts (id, project_id, agent_id, task_id, title, content, tags, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-	`, ctx.ID, ctx.ProjectID, ctx.AgentID, ctx.TaskID, ctx.Title, ctx.Content, ctx.Tags, ctx.CreatedAt, ctx.UpdatedAt)
-	if err != nil {
-		http.Error(w, "Failed to create context", http.StatusInternalServerError)
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
 		return
 	}
 
@@ -81,7 +100,7 @@ func (h *ContextHandler) ListContexts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	query := `
-		SELECT id, project_id, agent_id, task_id, title, content, tags, created_at, updated_at
+		SELECT ` + database.ContextColumns + `
 		FROM contexts
 		WHERE project_id = $1
 	`
@@ -95,6 +114,22 @@ func (h *ContextHandler) ListContexts(w http.ResponseWriter, r *http.Request) {
 		args = append(args, pq.Array(tags))
 	}
 
+	// Front matter keys match on their text value or list membership
+	// (meta.<key>=value); an empty value only requires the key to be present
+	for param, values := range r.URL.Query() {
+		key := strings.TrimPrefix(param, "meta.")
+		if key == param || key == "" {
+			continue
+		}
+		if len(values) == 0 || values[0] == "" {
+			query += " AND " + database.FrontMatterKeyFilter(len(args)+1)
+			args = append(args, key)
+			continue
+		}
+		query += " AND " + database.FrontMatterFilter(len(args)+1, len(args)+2)
+		args = append(args, key, values[0])
+	}
+
 	query += " ORDER BY created_at DESC"
 
 	rows, err := h.db.Query(query, args...)
@@ -106,8 +141,8 @@ func (h *ContextHandler) ListContexts(w http.ResponseWriter, r *http.Request) {
 
 	var contexts []models.Context
 	for rows.Next() {
-		var c models.Context
-		if err := rows.Scan(&c.ID, &c.ProjectID, &c.AgentID, &c.TaskID, &c.Title, &c.Content, &c.Tags, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		c, err := database.ScanContext(rows)
+		if err != nil {
 			http.Error(w, "Failed to scan context", http.StatusInternalServerError)
 			return
 		}
@@ -131,12 +166,13 @@ func (h *ContextHandler) GetContext(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var ctx models.Context
-	err = h.db.QueryRow(`
-		SELECT id, project_id, agent_id, task_id, title, content, tags, created_at, updated_at
-		FROM contexts
-		WHERE id = $1
-	`, id).Scan(&ctx.ID, &ctx.ProjectID, &ctx.AgentID, &ctx.TaskID, &ctx.Title, &ctx.Content, &ctx.Tags, &ctx.CreatedAt, &ctx.UpdatedAt)
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "markdown" && format != "html" {
+		http.Error(w, "Invalid format, must be one of: markdown, html", http.StatusBadRequest)
+		return
+	}
+
+	ctx, err := database.GetContext(h.db, id)
 	if err == sql.ErrNoRows {
 		http.Error(w, "Context not found", http.StatusNotFound)
 		return
@@ -145,7 +181,19 @@ func (h *ContextHandler) GetContext(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, ctx.Version)
 	w.Header().Set("Content-Type", "application/json")
+
+	if format == "html" {
+		doc, err := markdown.Render(ctx.Content)
+		if err != nil {
+			http.Error(w, "Failed to render context", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(models.RenderedContext{Context: ctx, Document: doc})
+		return
+	}
+
 	json.NewEncoder(w).Encode(ctx)
 }
 
@@ -169,13 +217,42 @@ func (h *ContextHandler) UpdateContext(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if context exists and get current data
-	var currentCtx models.Context
-	err = h.db.QueryRow(`
-		SELECT id, project_id, agent_id, task_id, title, content, tags, created_at, updated_at
-		FROM contexts
-		WHERE id = $1
-	`, id).Scan(&currentCtx.ID, &currentCtx.ProjectID, &currentCtx.AgentID, &currentCtx.TaskID, &currentCtx.Title, &currentCtx.Content, &currentCtx.Tags, &currentCtx.CreatedAt, &currentCtx.UpdatedAt)
+	expected, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	// The new revision is authored by agent_id, falling back to the acting agent
+	var authorID uuid.UUID
+	if req.AgentID != nil {
+		authorID = *req.AgentID
+	}
+	if !actingAgent(w, r, &authorID) {
+		return
+	}
+	var author *uuid.UUID
+	if authorID != uuid.Nil {
+		author = &authorID
+	}
+	if req.AgentID != nil && author != nil {
+		if _, err := database.GetAgent(h.db, *author); err == sql.ErrNoRows {
+			http.Error(w, "Agent not found", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, "Failed to verify agent", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Lock the context so concurrent updates are numbered in order
+	current, err := database.LockContext(tx, id)
 	if err == sql.ErrNoRows {
 		http.Error(w, "Context not found", http.StatusNotFound)
 		return
@@ -183,33 +260,34 @@ func (h *ContextHandler) UpdateContext(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to retrieve context", http.StatusInternalServerError)
 		return
 	}
+	if !authorize(w, r, current.ProjectID, auth.PermWriteContexts) {
+		return
+	}
 
-	// Update the context
-	_, err = h.db.Exec(`
-		UPDATE contexts
-		SET task_id = $1, title = $2, content = $3, tags = $4, updated_at = $5
-		WHERE id = $6
-	`, req.TaskID, req.Title, req.Content, pq.Array(req.Tags), time.Now(), id)
-	if err != nil {
+	// Refuse to overwrite changes the client has not seen
+	if !checkVersion(w, expected, current.Version) {
+		return
+	}
+
+	// Update the context, keeping the new version in its history
+	updatedCtx, err := database.UpdateContextContent(tx, id, req.TaskID, req.Title, req.Content, req.Tags, author)
+	if errors.Is(err, markdown.ErrInvalidFrontMatter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
 		http.Error(w, "Failed to update context", http.StatusInternalServerError)
 		return
 	}
 
-	// Get updated context
-	var updatedCtx models.Context
-	err = h.db.QueryRow(`
-		SELECT id, project_id, agent_id, task_id, title, content, tags, created_at, updated_at
-		FROM contexts
-		WHERE id = $1
-	`, id).Scan(&updatedCtx.ID, &updatedCtx.ProjectID, &updatedCtx.AgentID, &updatedCtx.TaskID, &updatedCtx.Title, &updatedCtx.Content, &updatedCtx.Tags, &updatedCtx.CreatedAt, &updatedCtx.UpdatedAt)
-	if err != nil {
-		http.Error(w, "Failed to retrieve updated context", http.StatusInternalServerError)
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
 		return
 	}
 
 	// Broadcast context update
 	h.hub.BroadcastToProject(updatedCtx.ProjectID, "context_updated", updatedCtx)
 
+	setETag(w, updatedCtx.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(updatedCtx)
 }
@@ -235,6 +313,10 @@ func (h *ContextHandler) DeleteContext(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !authorize(w, r, projectID, auth.PermWriteContexts) {
+		return
+	}
+
 	// Delete the context
 	result, err := h.db.Exec(`DELETE FROM contexts WHERE id = $1`, id)
 	if err != nil {
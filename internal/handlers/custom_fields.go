@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+	"github.com/techbuzzz/agent-shaker/internal/validator"
+)
+
+// ListCustomFields returns the custom field schema of a project
+func (h *ProjectHandler) ListCustomFields(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID format", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := database.GetProject(h.db, id); err == sql.ErrNoRows {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve project", http.StatusInternalServerError)
+		return
+	}
+
+	defs, err := database.ListCustomFieldDefinitions(h.db, id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve custom fields", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(defs)
+}
+
+// CreateCustomField adds a typed custom field to the task schema of a project
+func (h *ProjectHandler) CreateCustomField(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !authorize(w, r, id, auth.PermManageProject) {
+		return
+	}
+
+	var req models.CreateCustomFieldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.ValidateCreateCustomFieldRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	def := models.CustomFieldDefinition{
+		ProjectID: id,
+		Name:      req.Name,
+		Type:      req.Type,
+		Options:   pq.StringArray(req.Options),
+		Required:  req.Required,
+		CreatedAt: time.Now(),
+	}
+	if def.Options == nil {
+		def.Options = pq.StringArray{}
+	}
+
+	// A project's field names are unique; an existing name is reported as a conflict
+	result, err := h.db.Exec(`
+		INSERT INTO custom_field_definitions (project_id, name, field_type, options, required, created_at)
+		SELECT $1, $2, $3, $4, $5, $6
+		WHERE EXISTS (SELECT 1 FROM projects WHERE id = $1)
+		ON CONFLICT (project_id, name) DO NOTHING
+	`, def.ProjectID, def.Name, def.Type, def.Options, def.Required, def.CreatedAt)
+	if err != nil {
+		http.Error(w, "Failed to create custom field", http.StatusInternalServerError)
+		return
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		if _, err := database.GetProject(h.db, id); err == sql.ErrNoRows {
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Custom field already exists", http.StatusConflict)
+		return
+	}
+
+	h.broadcastCustomFields(id)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(def)
+}
+
+// DeleteCustomField removes a custom field from a project's schema and clears
+// its value on every task of the project
+func (h *ProjectHandler) DeleteCustomField(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !authorize(w, r, id, auth.PermManageProject) {
+		return
+	}
+	name := vars["name"]
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM custom_field_definitions WHERE project_id = $1 AND name = $2`, id, name)
+	if err != nil {
+		http.Error(w, "Failed to delete custom field", http.StatusInternalServerError)
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		http.Error(w, "Custom field not found", http.StatusNotFound)
+		return
+	}
+
+	_, err = tx.Exec(`
+		UPDATE tasks SET custom_fields = custom_fields - $1
+		WHERE project_id = $2 AND custom_fields ? $1
+	`, name, id)
+	if err != nil {
+		http.Error(w, "Failed to clear custom field values", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcastCustomFields(id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// broadcastCustomFields sends the current custom field schema of a project
+func (h *ProjectHandler) broadcastCustomFields(projectID uuid.UUID) {
+	defs, err := database.ListCustomFieldDefinitions(h.db, projectID)
+	if err != nil {
+		return
+	}
+	h.hub.BroadcastToProject(projectID, "custom_fields_update", map[string]interface{}{
+		"project_id": projectID.String(),
+		"fields":     defs,
+	})
+}
@@ -5,7 +5,9 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/google/uuid"
 	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
 )
 
 // DashboardHandler handles dashboard statistics requests
@@ -24,6 +26,8 @@ type DashboardStats struct {
 	Agents   AgentStats   `json:"agents"`
 	Tasks    TaskStats    `json:"tasks"`
 	Contexts ContextStats `json:"contexts"`
+	// Milestones lists the milestones that are not completed yet, soonest first
+	Milestones []models.MilestoneProgress `json:"milestones"`
 }
 
 // ProjectStats represents project statistics
@@ -33,7 +37,8 @@ type ProjectStats struct {
 	Archived int `json:"archived"`
 }
 
-// AgentStats represents agent statistics
+// AgentStats represents agent statistics. Active counts every agent that is
+// neither idle nor offline, including working and blocked ones.
 type AgentStats struct {
 	Total   int `json:"total"`
 	Active  int `json:"active"`
@@ -46,8 +51,16 @@ type TaskStats struct {
 	Total      int `json:"total"`
 	Pending    int `json:"pending"`
 	InProgress int `json:"in_progress"`
-	Done       int `json:"done"`
 	Blocked    int `json:"blocked"`
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+	Cancelled  int `json:"cancelled"`
+	// Ready counts pending tasks whose prerequisites are all completed
+	Ready int `json:"ready"`
+	// WaitingOnDependencies counts pending tasks with unfinished prerequisites
+	WaitingOnDependencies int `json:"waiting_on_dependencies"`
+	// Overdue counts open tasks whose due date has passed
+	Overdue int `json:"overdue"`
 }
 
 // ContextStats represents context statistics
@@ -55,13 +68,24 @@ type ContextStats struct {
 	Total int `json:"total"`
 }
 
-// GetDashboardStats returns comprehensive dashboard statistics
+// GetDashboardStats returns comprehensive dashboard statistics. The open
+// milestones can be limited to one project with project_id.
 func (h *DashboardHandler) GetDashboardStats(w http.ResponseWriter, r *http.Request) {
 	if h.db == nil {
 		http.Error(w, "Database connection not available", http.StatusServiceUnavailable)
 		return
 	}
 
+	var projectID *uuid.UUID
+	if projectIDStr := r.URL.Query().Get("project_id"); projectIDStr != "" {
+		id, err := uuid.Parse(projectIDStr)
+		if err != nil {
+			http.Error(w, "Invalid project_id format", http.StatusBadRequest)
+			return
+		}
+		projectID = &id
+	}
+
 	stats := DashboardStats{}
 
 	// Get project statistics
@@ -85,7 +109,7 @@ func (h *DashboardHandler) GetDashboardStats(w http.ResponseWriter, r *http.Requ
 	err = h.db.QueryRow(`
 		SELECT 
 			COUNT(*) as total,
-			COUNT(*) FILTER (WHERE status = 'active') as active,
+			COUNT(*) FILTER (WHERE status NOT IN ('idle', 'offline')) as active,
 			COUNT(*) FILTER (WHERE status = 'idle') as idle,
 			COUNT(*) FILTER (WHERE status = 'offline') as offline
 		FROM agents
@@ -104,14 +128,21 @@ func (h *DashboardHandler) GetDashboardStats(w http.ResponseWriter, r *http.Requ
 			COUNT(*) as total,
 			COUNT(*) FILTER (WHERE status = 'pending') as pending,
 			COUNT(*) FILTER (WHERE status = 'in_progress') as in_progress,
-			COUNT(*) FILTER (WHERE status = 'done') as done,
-			COUNT(*) FILTER (WHERE status = 'blocked') as blocked
-		FROM tasks
-	`).Scan(&taskStats.Total, &taskStats.Pending, &taskStats.InProgress, &taskStats.Done, &taskStats.Blocked)
+			COUNT(*) FILTER (WHERE status = 'blocked') as blocked,
+			COUNT(*) FILTER (WHERE status = 'completed') as completed,
+			COUNT(*) FILTER (WHERE status = 'failed') as failed,
+			COUNT(*) FILTER (WHERE status = 'cancelled') as cancelled,
+			COUNT(*) FILTER (WHERE status = 'pending' AND NOT `+database.UnmetDependenciesFilter+`) as ready,
+			COUNT(*) FILTER (WHERE status = 'pending' AND `+database.UnmetDependenciesFilter+`) as waiting_on_dependencies,
+			COUNT(*) FILTER (WHERE `+database.OverdueFilter+`) as overdue
+		FROM tasks t
+	`).Scan(&taskStats.Total, &taskStats.Pending, &taskStats.InProgress, &taskStats.Blocked,
+		&taskStats.Completed, &taskStats.Failed, &taskStats.Cancelled, &taskStats.Ready, &taskStats.WaitingOnDependencies,
+		&taskStats.Overdue)
 
 	if err != nil {
 		log.Printf("Error fetching task stats: %v", err)
-		taskStats = TaskStats{Total: 0, Pending: 0, InProgress: 0, Done: 0, Blocked: 0}
+		taskStats = TaskStats{}
 	}
 	stats.Tasks = taskStats
 
@@ -128,6 +159,13 @@ func (h *DashboardHandler) GetDashboardStats(w http.ResponseWriter, r *http.Requ
 	}
 	stats.Contexts = contextStats
 
+	// Get open milestone progress
+	stats.Milestones, err = database.ListMilestoneProgress(h.db, projectID, true)
+	if err != nil {
+		log.Printf("Error fetching milestones: %v", err)
+		stats.Milestones = []models.MilestoneProgress{}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
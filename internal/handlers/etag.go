@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// setETag sends the version of the returned entity as a strong ETag
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// ifMatchVersion reads the version a client expects from the If-Match header.
+// It returns nil when the header is absent or "*", in which case any version
+// may be overwritten. A malformed header writes a 400 response and returns false.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (*int, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	version, err := strconv.Atoi(tag)
+	if err != nil {
+		http.Error(w, "Invalid If-Match header, expected an ETag returned by the server", http.StatusBadRequest)
+		return nil, false
+	}
+	return &version, true
+}
+
+// checkVersion writes a 412 response and returns false if the entity was
+// changed since the client read the expected version
+func checkVersion(w http.ResponseWriter, expected *int, current int) bool {
+	if expected != nil && *expected != current {
+		setETag(w, current)
+		http.Error(w, fmt.Sprintf("Version mismatch: expected %d, current is %d. Reload and retry", *expected, current), http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+// checkVersionUnchanged writes a 412 response and returns false if an update
+// guarded by If-Match matched no row because the entity changed in the meantime
+func checkVersionUnchanged(w http.ResponseWriter, result sql.Result) bool {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		http.Error(w, "Failed to update", http.StatusInternalServerError)
+		return false
+	}
+	if rowsAffected == 0 {
+		http.Error(w, "Version mismatch: the entity was changed concurrently. Reload and retry", http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+	"github.com/techbuzzz/agent-shaker/internal/validator"
+)
+
+// ListMilestones returns the milestones of a project with their progress.
+// open=true leaves out completed milestones.
+func (h *ProjectHandler) ListMilestones(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID format", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := database.GetProject(h.db, id); err == sql.ErrNoRows {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve project", http.StatusInternalServerError)
+		return
+	}
+
+	milestones, err := database.ListMilestoneProgress(h.db, &id, r.URL.Query().Get("open") == "true")
+	if err != nil {
+		http.Error(w, "Failed to retrieve milestones", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(milestones)
+}
+
+// CreateMilestone adds a milestone to a project
+func (h *ProjectHandler) CreateMilestone(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !authorize(w, r, id, auth.PermManageProject) {
+		return
+	}
+
+	var req models.CreateMilestoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.ValidateCreateMilestoneRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	targetDate, _ := models.ParseMilestoneDate(req.TargetDate)
+
+	milestone := models.Milestone{
+		ID:          uuid.New(),
+		ProjectID:   id,
+		Name:        req.Name,
+		Description: req.Description,
+		TargetDate:  targetDate,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	result, err := h.db.Exec(`
+		INSERT INTO milestones (id, project_id, name, description, target_date, created_at, updated_at)
+		SELECT $1, $2, $3, $4, $5, $6, $7
+		WHERE EXISTS (SELECT 1 FROM projects WHERE id = $2)
+	`, milestone.ID, milestone.ProjectID, milestone.Name, milestone.Description, milestone.TargetDate, milestone.CreatedAt, milestone.UpdatedAt)
+	if err != nil {
+		http.Error(w, "Failed to create milestone", http.StatusInternalServerError)
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	progress, ok := h.broadcastMilestone(w, milestone.ID)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(progress)
+}
+
+// GetMilestone returns a milestone with its progress
+func (h *ProjectHandler) GetMilestone(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid milestone ID format", http.StatusBadRequest)
+		return
+	}
+
+	progress, err := database.GetMilestoneProgress(h.db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Milestone not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve milestone", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(progress)
+}
+
+// UpdateMilestone changes a milestone's name, description or target date.
+// Fields left out of the request keep their value.
+func (h *ProjectHandler) UpdateMilestone(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid milestone ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateMilestoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.ValidateUpdateMilestoneRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	milestone, err := database.GetMilestone(h.db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Milestone not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve milestone", http.StatusInternalServerError)
+		return
+	}
+	if !authorize(w, r, milestone.ProjectID, auth.PermManageProject) {
+		return
+	}
+
+	if req.Name != nil {
+		milestone.Name = *req.Name
+	}
+	if req.Description != nil {
+		milestone.Description = *req.Description
+	}
+	if req.TargetDate != nil {
+		milestone.TargetDate, _ = models.ParseMilestoneDate(*req.TargetDate)
+	}
+
+	result, err := h.db.Exec(`
+		UPDATE milestones SET name = $1, description = $2, target_date = $3, updated_at = $4
+		WHERE id = $5
+	`, milestone.Name, milestone.Description, milestone.TargetDate, time.Now(), id)
+	if err != nil {
+		http.Error(w, "Failed to update milestone", http.StatusInternalServerError)
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		http.Error(w, "Milestone not found", http.StatusNotFound)
+		return
+	}
+
+	progress, ok := h.broadcastMilestone(w, id)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(progress)
+}
+
+// DeleteMilestone removes a milestone. Its tasks are kept and detached from it.
+func (h *ProjectHandler) DeleteMilestone(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid milestone ID format", http.StatusBadRequest)
+		return
+	}
+
+	milestone, err := database.GetMilestone(h.db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Milestone not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve milestone", http.StatusInternalServerError)
+		return
+	}
+	if !authorize(w, r, milestone.ProjectID, auth.PermManageProject) {
+		return
+	}
+
+	var projectID uuid.UUID
+	err = h.db.QueryRow(`DELETE FROM milestones WHERE id = $1 RETURNING project_id`, id).Scan(&projectID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Milestone not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to delete milestone", http.StatusInternalServerError)
+		return
+	}
+
+	h.hub.BroadcastToProject(projectID, "milestone_deleted", map[string]interface{}{
+		"project_id":   projectID.String(),
+		"milestone_id": id.String(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// broadcastMilestone loads a milestone's progress and sends it to the
+// milestone's project. It writes the error response and returns false if the
+// milestone cannot be loaded.
+func (h *ProjectHandler) broadcastMilestone(w http.ResponseWriter, id uuid.UUID) (models.MilestoneProgress, bool) {
+	progress, err := database.GetMilestoneProgress(h.db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Milestone not found", http.StatusNotFound)
+		return progress, false
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve milestone", http.StatusInternalServerError)
+		return progress, false
+	}
+	h.hub.BroadcastToProject(progress.ProjectID, "milestone_update", progress)
+	return progress, true
+}
+
+// UpdateTaskMilestone attaches a task to a milestone of its project, or
+// detaches it when milestone_id is null
+func (h *TaskHandler) UpdateTaskMilestone(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid task ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorizeTask(w, r, id, auth.PermManageTasks) {
+		return
+	}
+
+	var req models.UpdateTaskMilestoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	current, err := database.GetTask(h.db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve task", http.StatusInternalServerError)
+		return
+	}
+
+	if req.MilestoneID != nil && !h.checkMilestone(w, current.ProjectID, *req.MilestoneID) {
+		return
+	}
+
+	task, err := database.ScanTask(h.db.QueryRow(`
+		UPDATE tasks SET milestone_id = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING `+database.TaskColumns,
+		req.MilestoneID, time.Now(), id))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update milestone", http.StatusInternalServerError)
+		return
+	}
+
+	h.recordTaskEvents(models.NewTaskEvent(task, requestActor(r), models.TaskEventMilestoneChanged,
+		formatMilestoneID(current.MilestoneID), formatMilestoneID(task.MilestoneID)))
+
+	// Broadcast task update
+	h.hub.BroadcastToProject(task.ProjectID, "task_update", task)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// checkMilestone verifies that a milestone exists and belongs to the
+// project. It writes the error response and returns false otherwise.
+func (h *TaskHandler) checkMilestone(w http.ResponseWriter, projectID, milestoneID uuid.UUID) bool {
+	milestone, err := database.GetMilestone(h.db, milestoneID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Milestone not found", http.StatusBadRequest)
+		return false
+	} else if err != nil {
+		http.Error(w, "Failed to verify milestone", http.StatusInternalServerError)
+		return false
+	}
+	if milestone.ProjectID != projectID {
+		http.Error(w, "Milestone belongs to a different project", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// formatMilestoneID renders a milestone reference for the task history, empty if unset
+func formatMilestoneID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
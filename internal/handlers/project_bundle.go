@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
+	"github.com/techbuzzz/agent-shaker/internal/bundle"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+	"github.com/techbuzzz/agent-shaker/internal/validator"
+)
+
+// MaxBundleSize is the largest bundle accepted by ImportProject
+const MaxBundleSize = 64 << 20
+
+// ExportProject returns a portable bundle of a project with its agents,
+// tasks, comments, contexts, standups and a summary of agent heartbeats.
+// ?format=zip returns the bundle compressed in a zip archive.
+func (h *ProjectHandler) ExportProject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID format", http.StatusBadRequest)
+		return
+	}
+	if !authorize(w, r, id, auth.PermManageProject) {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = bundle.FormatJSON
+	}
+	if format != bundle.FormatJSON && format != bundle.FormatZip {
+		http.Error(w, "Invalid format, must be one of: json, zip", http.StatusBadRequest)
+		return
+	}
+
+	// Read the whole project from one snapshot
+	tx, err := h.db.BeginTx(r.Context(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	b, err := database.ExportProject(tx, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to export project", http.StatusInternalServerError)
+		return
+	}
+	b.FormatVersion = bundle.FormatVersion
+
+	// Encode before writing headers so a failure can still return an error
+	var buf bytes.Buffer
+	if err := bundle.Encode(&buf, b, format); err != nil {
+		log.Printf("Failed to encode bundle of project %s: %v", id, err)
+		http.Error(w, "Failed to encode project bundle", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", bundleFileName(b.Project.Name), b.ExportedAt.Format("20060102-150405"), format)
+	if format == bundle.FormatZip {
+		w.Header().Set("Content-Type", "application/zip")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Write(buf.Bytes())
+}
+
+// ImportProject creates a new project from a bundle made by ExportProject,
+// sent as JSON or zip. Every record gets a new ID, so a bundle can be imported
+// into the server it was exported from. ?name= renames the imported project.
+func (h *ProjectHandler) ImportProject(w http.ResponseWriter, r *http.Request) {
+	if !authorizeNewProject(w, r, auth.PermManageProject) {
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBundleSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, "Bundle too large", http.StatusRequestEntityTooLarge)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	b, err := bundle.Decode(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if name := strings.TrimSpace(r.URL.Query().Get("name")); name != "" {
+		b.Project.Name = name
+	}
+	if err := validator.ValidateCreateProjectRequest(&models.CreateProjectRequest{
+		Name:              b.Project.Name,
+		Description:       b.Project.Description,
+		ClaimLeaseSeconds: b.Project.ClaimLeaseSeconds,
+		SLAHours:          b.Project.SLAHours,
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validator.ValidateProjectSettings(b.Project.Settings); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ids, err := bundle.Remap(&b)
+	if err != nil {
+		http.Error(w, "Invalid bundle: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := database.ImportProject(tx, b); err != nil {
+		http.Error(w, "Failed to import project", http.StatusInternalServerError)
+		return
+	}
+
+	project, err := database.GetProject(tx, b.Project.ID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve imported project", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	result := models.ProjectImportResult{
+		Project:      project,
+		Agents:       len(b.Agents),
+		Milestones:   len(b.Milestones),
+		Tasks:        len(b.Tasks),
+		TaskComments: len(b.TaskComments),
+		Contexts:     len(b.Contexts),
+		Standups:     len(b.Standups),
+		IDMap:        ids,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// CloneProject creates a new project from an existing one, typically a
+// template: its agents, custom fields, selected contexts and a task skeleton
+// with statuses and assignments reset
+func (h *ProjectHandler) CloneProject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !authorize(w, r, id, auth.PermManageProject) {
+		return
+	}
+
+	var req models.CloneProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.ValidateCloneProjectRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := database.CloneProject(tx, id, req)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to clone project", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	// Let the source project know it was cloned
+	h.hub.BroadcastToProject(id, "project_cloned", map[string]interface{}{
+		"project_id": id,
+		"clone_id":   result.Project.ID,
+		"name":       result.Project.Name,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// bundleFileName turns a project name into a file name for its export
+func bundleFileName(name string) string {
+	slug := strings.Trim(unsafeFileNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if slug == "" {
+		slug = "project"
+	}
+	return slug
+}
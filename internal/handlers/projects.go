@@ -4,10 +4,13 @@ import (
 	"database/sql"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
 	"github.com/techbuzzz/agent-shaker/internal/database"
 	"github.com/techbuzzz/agent-shaker/internal/models"
 	"github.com/techbuzzz/agent-shaker/internal/validator"
@@ -36,19 +39,35 @@ func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.ClaimLeaseSeconds == 0 {
+		req.ClaimLeaseSeconds = models.DefaultClaimLeaseSeconds
+	}
+	if req.SLAHours == nil {
+		req.SLAHours = models.SLAHours{}
+	}
+
 	project := models.Project{
-		ID:          uuid.New(),
-		Name:        req.Name,
-		Description: req.Description,
-		Status:      "active",
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
-
-	_, err := h.db.Exec(`
-		INSERT INTO projects (id, name, description, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, project.ID, project.Name, project.Description, project.Status, project.CreatedAt, project.UpdatedAt)
+		ID:                uuid.New(),
+		Name:              req.Name,
+		Description:       req.Description,
+		Status:            "active",
+		ClaimLeaseSeconds: req.ClaimLeaseSeconds,
+		SLAHours:          req.SLAHours,
+		IsTemplate:        req.IsTemplate,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	slaJSON, err := json.Marshal(project.SLAHours)
+	if err != nil {
+		http.Error(w, "Failed to serialize sla_hours", http.StatusBadRequest)
+		return
+	}
+
+	_, err = h.db.Exec(`
+		INSERT INTO projects (id, name, description, status, claim_lease_seconds, priority_sla_hours, is_template, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, project.ID, project.Name, project.Description, project.Status, project.ClaimLeaseSeconds, slaJSON, project.IsTemplate, project.CreatedAt, project.UpdatedAt)
 	if err != nil {
 		http.Error(w, "Failed to create project", http.StatusInternalServerError)
 		return
@@ -60,11 +79,22 @@ func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ProjectHandler) ListProjects(w http.ResponseWriter, r *http.Request) {
-	rows, err := h.db.Query(`
-		SELECT id, name, description, status, created_at, updated_at
-		FROM projects
-		ORDER BY created_at DESC
-	`)
+	query := `SELECT ` + database.ProjectColumns + ` FROM projects`
+	var args []interface{}
+
+	// ?template=true lists only templates, ?template=false only regular projects
+	if templateParam := r.URL.Query().Get("template"); templateParam != "" {
+		isTemplate, err := strconv.ParseBool(templateParam)
+		if err != nil {
+			http.Error(w, "Invalid template filter, must be true or false", http.StatusBadRequest)
+			return
+		}
+		query += ` WHERE is_template = $1`
+		args = append(args, isTemplate)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := h.db.Query(query, args...)
 	if err != nil {
 		http.Error(w, "Failed to retrieve projects", http.StatusInternalServerError)
 		return
@@ -73,8 +103,8 @@ func (h *ProjectHandler) ListProjects(w http.ResponseWriter, r *http.Request) {
 
 	var projects []models.Project
 	for rows.Next() {
-		var p models.Project
-		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Status, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		p, err := database.ScanProject(rows)
+		if err != nil {
 			http.Error(w, "Failed to scan project", http.StatusInternalServerError)
 			return
 		}
@@ -98,12 +128,59 @@ func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var project models.Project
-	err = h.db.QueryRow(`
-		SELECT id, name, description, status, created_at, updated_at
-		FROM projects
-		WHERE id = $1
-	`, id).Scan(&project.ID, &project.Name, &project.Description, &project.Status, &project.CreatedAt, &project.UpdatedAt)
+	project, err := database.GetProject(h.db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve project", http.StatusInternalServerError)
+		return
+	}
+
+	project.Milestones, err = database.ListMilestoneProgress(h.db, &id, false)
+	if err != nil {
+		http.Error(w, "Failed to retrieve milestones", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+// UpdateProject changes a project's name, description and settings. Fields
+// left out of the request, including fields of settings, keep their value.
+func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !authorize(w, r, id, auth.PermManageProject) {
+		return
+	}
+
+	var req models.UpdateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.ValidateUpdateProjectRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Lock the project so concurrent patches of different settings both apply
+	project, err := database.ScanProject(tx.QueryRow(`SELECT `+database.ProjectColumns+` FROM projects WHERE id = $1 FOR UPDATE`, id))
 	if err == sql.ErrNoRows {
 		http.Error(w, "Project not found", http.StatusNotFound)
 		return
@@ -112,6 +189,49 @@ func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Name != nil {
+		project.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		project.Description = *req.Description
+	}
+	// Decoding onto the current settings keeps the fields the request leaves out
+	if len(req.Settings) > 0 && string(req.Settings) != "null" {
+		if err := json.Unmarshal(req.Settings, &project.Settings); err != nil {
+			http.Error(w, "Invalid settings", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := validator.ValidateProjectSettings(project.Settings); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	settingsJSON, err := database.ProjectSettingsJSON(project.Settings)
+	if err != nil {
+		http.Error(w, "Failed to serialize settings", http.StatusBadRequest)
+		return
+	}
+
+	project, err = database.ScanProject(tx.QueryRow(`
+		UPDATE projects
+		SET name = $1, description = $2, settings = $3, claim_lease_seconds = $4, updated_at = $5
+		WHERE id = $6
+		RETURNING `+database.ProjectColumns,
+		project.Name, project.Description, settingsJSON, project.Settings.ClaimLeaseSeconds, time.Now(), id))
+	if err != nil {
+		http.Error(w, "Failed to update project", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast project update via WebSocket
+	h.hub.BroadcastToProject(id, "project_updated", project)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(project)
 }
@@ -124,6 +244,10 @@ func (h *ProjectHandler) UpdateProjectStatus(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if !authorize(w, r, id, auth.PermManageProject) {
+		return
+	}
+
 	var req struct {
 		Status string `json:"status"`
 	}
@@ -161,12 +285,7 @@ func (h *ProjectHandler) UpdateProjectStatus(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Fetch updated project
-	var project models.Project
-	err = h.db.QueryRow(`
-		SELECT id, name, description, status, created_at, updated_at
-		FROM projects
-		WHERE id = $1
-	`, id).Scan(&project.ID, &project.Name, &project.Description, &project.Status, &project.CreatedAt, &project.UpdatedAt)
+	project, err := database.GetProject(h.db, id)
 	if err != nil {
 		http.Error(w, "Failed to retrieve updated project", http.StatusInternalServerError)
 		return
@@ -179,6 +298,149 @@ func (h *ProjectHandler) UpdateProjectStatus(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(project)
 }
 
+// UpdateProjectLease changes how long task claims in a project last before
+// they must be renewed
+func (h *ProjectHandler) UpdateProjectLease(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !authorize(w, r, id, auth.PermManageProject) {
+		return
+	}
+
+	var req models.UpdateProjectLeaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.ValidateClaimLeaseSeconds(req.ClaimLeaseSeconds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Existing claims keep their expiry; the new duration applies from the next claim or renewal
+	project, err := database.ScanProject(h.db.QueryRow(`
+		UPDATE projects
+		SET claim_lease_seconds = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING `+database.ProjectColumns,
+		req.ClaimLeaseSeconds, time.Now(), id))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update project lease", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast project update via WebSocket
+	h.hub.BroadcastToProject(id, "project_status_update", project)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+// UpdateProjectSLA replaces the project's SLA per task priority. The SLA sets
+// the due date of tasks created without one; existing due dates are kept.
+func (h *ProjectHandler) UpdateProjectSLA(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !authorize(w, r, id, auth.PermManageProject) {
+		return
+	}
+
+	var req models.UpdateProjectSLARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.ValidateSLAHours(req.SLAHours); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.SLAHours == nil {
+		req.SLAHours = models.SLAHours{}
+	}
+
+	slaJSON, err := json.Marshal(req.SLAHours)
+	if err != nil {
+		http.Error(w, "Failed to serialize sla_hours", http.StatusBadRequest)
+		return
+	}
+
+	project, err := database.ScanProject(h.db.QueryRow(`
+		UPDATE projects
+		SET priority_sla_hours = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING `+database.ProjectColumns,
+		slaJSON, time.Now(), id))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update project SLA", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast project update via WebSocket
+	h.hub.BroadcastToProject(id, "project_status_update", project)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+// UpdateProjectTemplate marks a project as a template, or unmarks it.
+// Templates are listed with ?template=true and meant to be cloned.
+func (h *ProjectHandler) UpdateProjectTemplate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid project ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !authorize(w, r, id, auth.PermManageProject) {
+		return
+	}
+
+	var req models.UpdateProjectTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	project, err := database.ScanProject(h.db.QueryRow(`
+		UPDATE projects
+		SET is_template = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING `+database.ProjectColumns,
+		req.IsTemplate, time.Now(), id))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update project template flag", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast project update via WebSocket
+	h.hub.BroadcastToProject(id, "project_status_update", project)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
 func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
@@ -189,6 +451,10 @@ func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !authorize(w, r, id, auth.PermManageProject) {
+		return
+	}
+
 	// Begin transaction to delete project and related data
 	tx, err := h.db.Begin()
 	if err != nil {
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+	"github.com/techbuzzz/agent-shaker/internal/validator"
+)
+
+// SearchHandler handles full-text search requests
+type SearchHandler struct {
+	db *database.DB
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(db *database.DB) *SearchHandler {
+	return &SearchHandler{db: db}
+}
+
+// Search runs a ranked full-text search across tasks, contexts and standups.
+// Results can be narrowed with project_id and a comma-separated type list.
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := models.SearchQuery{Text: r.URL.Query().Get("q")}
+
+	if projectIDStr := r.URL.Query().Get("project_id"); projectIDStr != "" {
+		projectID, err := uuid.Parse(projectIDStr)
+		if err != nil {
+			http.Error(w, "Invalid project_id format", http.StatusBadRequest)
+			return
+		}
+		query.ProjectID = &projectID
+	}
+
+	if types := r.URL.Query().Get("type"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			query.Types = append(query.Types, models.SearchResultType(strings.TrimSpace(t)))
+		}
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			http.Error(w, "Invalid limit format", http.StatusBadRequest)
+			return
+		}
+		query.Limit = limit
+	}
+
+	if err := validator.ValidateSearchQuery(&query); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := database.Search(h.db, query)
+	if err != nil {
+		http.Error(w, "Failed to search", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
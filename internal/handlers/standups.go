@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/techbuzzz/agent-shaker/internal/database"
 	"github.com/techbuzzz/agent-shaker/internal/models"
+	"github.com/techbuzzz/agent-shaker/internal/validator"
 	"github.com/techbuzzz/agent-shaker/internal/websocket"
 )
 
@@ -32,6 +34,11 @@ func (h *StandupHandler) CreateStandup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Requests authenticated as an agent act as that agent
+	if !actingAgent(w, r, &req.AgentID) {
+		return
+	}
+
 	// Validate required fields
 	if req.AgentID == uuid.Nil {
 		http.Error(w, "agent_id is required", http.StatusBadRequest)
@@ -235,6 +242,10 @@ func (h *StandupHandler) UpdateStandup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.authorizeStandup(w, r, id) {
+		return
+	}
+
 	// Use RETURNING to get the updated standup in a single query
 	var standup models.DailyStandup
 	err = h.db.QueryRow(`
@@ -271,6 +282,10 @@ func (h *StandupHandler) DeleteStandup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.authorizeStandup(w, r, id) {
+		return
+	}
+
 	res, err := h.db.Exec("DELETE FROM daily_standups WHERE id = $1", id)
 	if err != nil {
 		http.Error(w, "Failed to delete standup", http.StatusInternalServerError)
@@ -292,6 +307,27 @@ func (h *StandupHandler) DeleteStandup(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "Standup deleted successfully"})
 }
 
+// authorizeStandup checks that a request may change a standup, which only its
+// author and the operator may. It writes the error response and returns
+// false otherwise.
+func (h *StandupHandler) authorizeStandup(w http.ResponseWriter, r *http.Request, id uuid.UUID) bool {
+	var authorID uuid.UUID
+	err := h.db.QueryRow(`SELECT agent_id FROM daily_standups WHERE id = $1`, id).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Standup not found", http.StatusNotFound)
+		return false
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve standup", http.StatusInternalServerError)
+		return false
+	}
+
+	if !canManageAgent(r, authorID) {
+		http.Error(w, "Forbidden: only the author can change a standup", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
 // RecordHeartbeat records an agent heartbeat
 func (h *StandupHandler) RecordHeartbeat(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateHeartbeatRequest
@@ -300,11 +336,17 @@ func (h *StandupHandler) RecordHeartbeat(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Requests authenticated as an agent act as that agent
+	if !actingAgent(w, r, &req.AgentID) {
+		return
+	}
+
 	if req.AgentID == uuid.Nil {
 		http.Error(w, "agent_id is required", http.StatusBadRequest)
 		return
 	}
 
+	reported := req.Status
 	if req.Status == "" {
 		req.Status = "active"
 	}
@@ -333,8 +375,18 @@ func (h *StandupHandler) RecordHeartbeat(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Update agent's last_seen timestamp
-	_, _ = h.db.Exec("UPDATE agents SET last_seen = $1 WHERE id = $2", heartbeat.HeartbeatTime, heartbeat.AgentID)
+	// Update the agent's last_seen and bring it back online if it was marked idle or offline
+	presence, err := database.RecordAgentSeen(h.db, heartbeat.AgentID, heartbeat.HeartbeatTime, reported)
+	if err != nil {
+		log.Printf("Failed to record presence of agent %s: %v", heartbeat.AgentID, err)
+	} else if presence.Status != presence.PreviousStatus {
+		h.hub.BroadcastToProject(presence.ProjectID, "agent_presence", presence)
+	}
+
+	// A live agent keeps its task claims
+	if _, err := database.RenewAgentClaims(h.db, heartbeat.AgentID); err != nil {
+		log.Printf("Failed to renew claims for agent %s: %v", heartbeat.AgentID, err)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -398,3 +450,68 @@ func (h *StandupHandler) GetAgentHeartbeats(w http.ResponseWriter, r *http.Reque
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(heartbeats)
 }
+
+// GetAgentActivity returns an agent's activity timeline between from and to
+// (RFC 3339 timestamps or YYYY-MM-DD dates, the last 24 hours by default), in
+// hourly buckets for ranges up to a week and daily buckets otherwise unless
+// granularity is given
+func (h *StandupHandler) GetAgentActivity(w http.ResponseWriter, r *http.Request) {
+	agentID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid agent ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	to := time.Now().UTC()
+	if v := query.Get("to"); v != "" {
+		if to, err = parseActivityTime(v); err != nil {
+			http.Error(w, "to must be an RFC 3339 timestamp or a YYYY-MM-DD date", http.StatusBadRequest)
+			return
+		}
+	}
+	from := to.Add(-24 * time.Hour)
+	if v := query.Get("from"); v != "" {
+		if from, err = parseActivityTime(v); err != nil {
+			http.Error(w, "from must be an RFC 3339 timestamp or a YYYY-MM-DD date", http.StatusBadRequest)
+			return
+		}
+	}
+
+	granularity := models.ActivityGranularity(query.Get("granularity"))
+	if granularity == "" {
+		granularity = models.ActivityHour
+		if to.Sub(from) > 7*24*time.Hour {
+			granularity = models.ActivityDay
+		}
+	}
+	if err := validator.ValidateActivityRange(from, to, granularity); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := database.GetAgent(h.db, agentID); err == sql.ErrNoRows {
+		http.Error(w, "Agent not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve agent", http.StatusInternalServerError)
+		return
+	}
+
+	buckets, err := database.ListAgentActivity(h.db, agentID, granularity, from, to)
+	if err != nil {
+		http.Error(w, "Failed to retrieve activity", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.BuildActivityTimeline(agentID, granularity, from, to, buckets))
+}
+
+// parseActivityTime parses an RFC 3339 timestamp or a YYYY-MM-DD date as UTC
+func parseActivityTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// ListTaskChildren returns the direct subtasks of a task
+func (h *TaskHandler) ListTaskChildren(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid task ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !h.taskExists(w, id) {
+		return
+	}
+
+	children, err := database.ListTaskChildren(h.db, id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve subtasks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(children)
+}
+
+// GetTaskProgress returns the percentage of a task's subtasks that are completed
+func (h *TaskHandler) GetTaskProgress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid task ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !h.taskExists(w, id) {
+		return
+	}
+
+	progress, err := database.GetTaskProgress(h.db, id)
+	if err != nil {
+		http.Error(w, "Failed to compute task progress", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(progress)
+}
+
+// taskExists writes a 404 response and returns false if the task does not exist
+func (h *TaskHandler) taskExists(w http.ResponseWriter, id uuid.UUID) bool {
+	var exists bool
+	err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		http.Error(w, "Failed to retrieve task", http.StatusInternalServerError)
+		return false
+	}
+	if !exists {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// completeFinishedAncestors completes and broadcasts every ancestor of task
+// whose subtasks are now all completed or cancelled
+func (h *TaskHandler) completeFinishedAncestors(task models.Task) {
+	if task.ParentID == nil || !(task.Status.IsCompleted() || task.Status == models.StatusCancelled) {
+		return
+	}
+
+	parents, err := database.CompleteFinishedAncestors(h.db, task.ID)
+	if err != nil {
+		log.Printf("Failed to complete parent of task %s: %v", task.ID, err)
+	}
+	for _, parent := range parents {
+		h.hub.BroadcastToProject(parent.ProjectID, "task_update", parent)
+	}
+}
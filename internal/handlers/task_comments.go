@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+	"github.com/techbuzzz/agent-shaker/internal/validator"
+)
+
+// ListTaskComments returns the comments on a task. Replies are nested under
+// the comment they answer unless ?flat=true is given.
+func (h *TaskHandler) ListTaskComments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid task ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !h.taskExists(w, id) {
+		return
+	}
+
+	comments, err := database.ListTaskComments(h.db, id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve comments", http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("flat") != "true" {
+		comments = models.ThreadTaskComments(comments)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comments)
+}
+
+// CreateTaskComment adds a comment to a task, optionally as a reply to an
+// existing comment on the same task
+func (h *TaskHandler) CreateTaskComment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid task ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorizeTask(w, r, id, auth.PermWorkOnTasks) {
+		return
+	}
+
+	var req models.CreateTaskCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Fall back to the acting agent when no author is given
+	if !actingAgent(w, r, &req.AuthorID) {
+		return
+	}
+
+	if err := validator.ValidateCreateTaskCommentRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	task, err := database.GetTask(h.db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve task", http.StatusInternalServerError)
+		return
+	}
+
+	// The author must be an agent of the task's project
+	author, err := database.GetAgent(h.db, req.AuthorID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Author agent not found", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to verify author", http.StatusInternalServerError)
+		return
+	}
+	if author.ProjectID != task.ProjectID {
+		http.Error(w, "Author belongs to a different project", http.StatusBadRequest)
+		return
+	}
+
+	// Replies must answer a comment on the same task
+	if req.ReplyToID != nil {
+		_, err := database.GetTaskComment(h.db, id, *req.ReplyToID)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Reply-to comment not found on this task", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, "Failed to verify reply-to comment", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	comment := models.TaskComment{
+		ID:        uuid.New(),
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		AuthorID:  req.AuthorID,
+		ReplyToID: req.ReplyToID,
+		Body:      req.Body,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := database.InsertTaskComment(h.db, comment); err != nil {
+		http.Error(w, "Failed to create comment", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcastTaskComment("created", comment)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+// UpdateTaskComment edits the body of a comment
+func (h *TaskHandler) UpdateTaskComment(w http.ResponseWriter, r *http.Request) {
+	id, commentID, ok := parseTaskCommentIDs(w, r)
+	if !ok || !h.authorizeTask(w, r, id, auth.PermWorkOnTasks) {
+		return
+	}
+
+	var req models.UpdateTaskCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validator.ValidateCommentBody(req.Body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	comment, err := database.ScanTaskComment(h.db.QueryRow(`
+		UPDATE task_comments
+		SET body = $1, updated_at = $2
+		WHERE id = $3 AND task_id = $4
+		RETURNING `+database.TaskCommentColumns,
+		req.Body, time.Now(), commentID, id))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update comment", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcastTaskComment("updated", comment)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// DeleteTaskComment removes a comment. Its replies are kept as top-level comments.
+func (h *TaskHandler) DeleteTaskComment(w http.ResponseWriter, r *http.Request) {
+	id, commentID, ok := parseTaskCommentIDs(w, r)
+	if !ok || !h.authorizeTask(w, r, id, auth.PermWorkOnTasks) {
+		return
+	}
+
+	comment, err := database.ScanTaskComment(h.db.QueryRow(`
+		DELETE FROM task_comments
+		WHERE id = $1 AND task_id = $2
+		RETURNING `+database.TaskCommentColumns,
+		commentID, id))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to delete comment", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcastTaskComment("deleted", comment)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseTaskCommentIDs reads the task and comment IDs from the route
+func parseTaskCommentIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid task ID format", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	commentID, err := uuid.Parse(vars["commentId"])
+	if err != nil {
+		http.Error(w, "Invalid comment ID format", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, commentID, true
+}
+
+// broadcastTaskComment notifies the project that a comment was created, updated or deleted
+func (h *TaskHandler) broadcastTaskComment(action string, comment models.TaskComment) {
+	h.hub.BroadcastToProject(comment.ProjectID, "task_comment", map[string]interface{}{
+		"action":     action,
+		"comment":    comment,
+		"task_id":    comment.TaskID,
+		"project_id": comment.ProjectID.String(),
+	})
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// ListTaskDependencies returns the prerequisites, blockers and dependents of a task
+func (h *TaskHandler) ListTaskDependencies(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid task ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !h.taskExists(w, id) {
+		return
+	}
+
+	dependsOn, err := database.ListTaskPrerequisites(h.db, id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve dependencies", http.StatusInternalServerError)
+		return
+	}
+
+	blockedBy, err := database.UnmetTaskDependencies(h.db, id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve dependencies", http.StatusInternalServerError)
+		return
+	}
+
+	dependents, err := database.ListTaskDependents(h.db, id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve dependents", http.StatusInternalServerError)
+		return
+	}
+
+	deps := models.TaskDependencies{
+		TaskID:     id,
+		Ready:      len(blockedBy) == 0,
+		DependsOn:  dependsOn,
+		BlockedBy:  blockedBy,
+		Dependents: dependents,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deps)
+}
+
+// AddTaskDependency declares that a task cannot start until another task is completed
+func (h *TaskHandler) AddTaskDependency(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid task ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorizeTask(w, r, id, auth.PermManageTasks) {
+		return
+	}
+
+	var req models.AddTaskDependencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.DependsOnID == uuid.Nil {
+		http.Error(w, "depends_on_id is required", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Both tasks must exist and belong to the same project
+	var projectID, dependsOnProjectID uuid.UUID
+	err = tx.QueryRow("SELECT project_id FROM tasks WHERE id = $1", id).Scan(&projectID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve task", http.StatusInternalServerError)
+		return
+	}
+
+	err = tx.QueryRow("SELECT project_id FROM tasks WHERE id = $1", req.DependsOnID).Scan(&dependsOnProjectID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Prerequisite task not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve prerequisite task", http.StatusInternalServerError)
+		return
+	}
+
+	if projectID != dependsOnProjectID {
+		http.Error(w, "Tasks must belong to the same project", http.StatusBadRequest)
+		return
+	}
+
+	// Serialize graph changes per project so concurrent inserts cannot race past the cycle check
+	if _, err := tx.Exec("SELECT id FROM projects WHERE id = $1 FOR UPDATE", projectID); err != nil {
+		http.Error(w, "Failed to lock project", http.StatusInternalServerError)
+		return
+	}
+
+	cycle, err := database.DependencyCreatesCycle(tx, id, req.DependsOnID)
+	if err != nil {
+		http.Error(w, "Failed to check dependency graph", http.StatusInternalServerError)
+		return
+	}
+	if cycle {
+		http.Error(w, "Dependency would create a cycle", http.StatusConflict)
+		return
+	}
+
+	dep := models.TaskDependency{
+		TaskID:      id,
+		DependsOnID: req.DependsOnID,
+		CreatedAt:   time.Now(),
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO task_dependencies (task_id, depends_on_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (task_id, depends_on_id) DO NOTHING
+	`, dep.TaskID, dep.DependsOnID, dep.CreatedAt)
+	if err != nil {
+		http.Error(w, "Failed to add dependency", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast dependency change
+	h.hub.BroadcastToProject(projectID, "task_dependency_added", map[string]interface{}{
+		"task_id":       dep.TaskID,
+		"depends_on_id": dep.DependsOnID,
+		"project_id":    projectID.String(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dep)
+}
+
+// RemoveTaskDependency deletes a "depends on" edge between two tasks
+func (h *TaskHandler) RemoveTaskDependency(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid task ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorizeTask(w, r, id, auth.PermManageTasks) {
+		return
+	}
+
+	dependsOnID, err := uuid.Parse(vars["dependsOnId"])
+	if err != nil {
+		http.Error(w, "Invalid prerequisite task ID format", http.StatusBadRequest)
+		return
+	}
+
+	var projectID uuid.UUID
+	err = h.db.QueryRow(`
+		DELETE FROM task_dependencies d
+		USING tasks t
+		WHERE d.task_id = $1 AND d.depends_on_id = $2 AND t.id = d.task_id
+		RETURNING t.project_id
+	`, id, dependsOnID).Scan(&projectID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Dependency not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to remove dependency", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast dependency change
+	h.hub.BroadcastToProject(projectID, "task_dependency_removed", map[string]interface{}{
+		"task_id":       id,
+		"depends_on_id": dependsOnID,
+		"project_id":    projectID.String(),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkTaskReady writes a 409 response and returns false if the task still
+// waits on unfinished prerequisites. Call it in the transaction that moves
+// the task to in_progress, which then holds the locks on the task and its
+// prerequisites.
+func checkTaskReady(w http.ResponseWriter, tx database.Querier, id uuid.UUID) bool {
+	blockedBy, err := database.LockTaskForStart(tx, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return false
+	} else if err != nil {
+		http.Error(w, "Failed to check task dependencies", http.StatusInternalServerError)
+		return false
+	}
+	if len(blockedBy) == 0 {
+		return true
+	}
+
+	titles := make([]string, len(blockedBy))
+	for i, t := range blockedBy {
+		titles[i] = t.Title
+	}
+	http.Error(w, "Task is blocked by unfinished prerequisites: "+strings.Join(titles, ", "), http.StatusConflict)
+	return false
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// UpdateTaskDueDate sets or clears the due date of a task. A task whose due
+// date moves is announced again by the overdue detector once it passes.
+func (h *TaskHandler) UpdateTaskDueDate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid task ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorizeTask(w, r, id, auth.PermManageTasks) {
+		return
+	}
+
+	var req models.UpdateTaskDueDateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	current, err := database.GetTask(h.db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve task", http.StatusInternalServerError)
+		return
+	}
+
+	task, err := database.ScanTask(h.db.QueryRow(`
+		UPDATE tasks
+		SET due_at = $1, overdue_notified_at = NULL, updated_at = $2
+		WHERE id = $3
+		RETURNING `+database.TaskColumns,
+		req.DueAt, time.Now(), id))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update due date", http.StatusInternalServerError)
+		return
+	}
+
+	h.recordTaskEvents(models.NewTaskEvent(task, requestActor(r), models.TaskEventDueChanged,
+		formatDueAt(current.DueAt), formatDueAt(task.DueAt)))
+
+	// Broadcast task update
+	h.hub.BroadcastToProject(task.ProjectID, "task_update", task)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// formatDueAt renders a due date for the task history, empty if unset
+func formatDueAt(dueAt *time.Time) string {
+	if dueAt == nil {
+		return ""
+	}
+	return dueAt.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// GetTaskHistory returns the audit trail of a task, oldest first. The history
+// of a deleted task remains available.
+func (h *TaskHandler) GetTaskHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid task ID format", http.StatusBadRequest)
+		return
+	}
+
+	events, err := database.ListTaskEvents(h.db, id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve task history", http.StatusInternalServerError)
+		return
+	}
+
+	if len(events) == 0 && !h.taskExists(w, id) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+// recordTaskEvents appends events to the task history, logging failures
+func (h *TaskHandler) recordTaskEvents(events ...models.TaskEvent) {
+	if err := database.RecordTaskEvents(h.db, events...); err != nil {
+		log.Printf("Failed to record task history: %v", err)
+	}
+}
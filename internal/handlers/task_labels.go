@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+	"github.com/techbuzzz/agent-shaker/internal/validator"
+)
+
+// UpdateTaskLabels replaces the labels of a task
+func (h *TaskHandler) UpdateTaskLabels(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid task ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorizeTask(w, r, id, auth.PermManageTasks) {
+		return
+	}
+
+	var req models.UpdateTaskLabelsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	labels := models.NormalizeLabels(req.Labels)
+	if err := validator.ValidateLabels(labels); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The previous labels come from the updated row so a concurrent update
+	// is recorded correctly in the history
+	previous, task, err := database.SetTaskLabels(h.db, id, labels)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update labels", http.StatusInternalServerError)
+		return
+	}
+
+	h.recordTaskEvents(models.NewTaskEvent(task, requestActor(r), models.TaskEventLabelsChanged,
+		strings.Join(previous, ","), strings.Join(task.Labels, ",")))
+
+	// Broadcast task update
+	h.hub.BroadcastToProject(task.ProjectID, "task_update", task)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// UpdateTaskCustomFields replaces the custom field values of a task after
+// validating them against the project's schema
+func (h *TaskHandler) UpdateTaskCustomFields(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid task ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorizeTask(w, r, id, auth.PermManageTasks) {
+		return
+	}
+
+	var req models.UpdateTaskCustomFieldsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.CustomFields == nil {
+		req.CustomFields = models.CustomFields{}
+	}
+
+	current, err := database.GetTask(h.db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve task", http.StatusInternalServerError)
+		return
+	}
+
+	if !h.checkCustomFields(w, current.ProjectID, req.CustomFields) {
+		return
+	}
+
+	customFieldsJSON, err := json.Marshal(req.CustomFields)
+	if err != nil {
+		http.Error(w, "Failed to serialize custom_fields", http.StatusBadRequest)
+		return
+	}
+
+	// The previous values come from the updated row so a concurrent update
+	// is recorded correctly in the history
+	previous, task, err := database.SetTaskCustomFields(h.db, id, customFieldsJSON)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to update custom fields", http.StatusInternalServerError)
+		return
+	}
+
+	previousJSON, _ := json.Marshal(previous)
+	h.recordTaskEvents(models.NewTaskEvent(task, requestActor(r), models.TaskEventFieldsChanged,
+		string(previousJSON), string(customFieldsJSON)))
+
+	// Broadcast task update
+	h.hub.BroadcastToProject(task.ProjectID, "task_update", task)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// checkCustomFields writes a 400 response and returns false if the values do
+// not match the custom field schema of the project
+func (h *TaskHandler) checkCustomFields(w http.ResponseWriter, projectID uuid.UUID, values models.CustomFields) bool {
+	defs, err := database.ListCustomFieldDefinitions(h.db, projectID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve custom fields", http.StatusInternalServerError)
+		return false
+	}
+	if err := validator.ValidateCustomFields(defs, values); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+)
+
+// NextTask assigns the highest-priority ready task matching the agent's role
+// and team to the agent in a single transaction. It responds with 204 No
+// Content when the queue is empty.
+func (h *TaskHandler) NextTask(w http.ResponseWriter, r *http.Request) {
+	var req models.NextTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Requests authenticated as an agent act as that agent
+	if !actingAgent(w, r, &req.AgentID) {
+		return
+	}
+
+	if req.AgentID == uuid.Nil {
+		http.Error(w, "agent_id is required", http.StatusBadRequest)
+		return
+	}
+
+	agent, err := database.GetAgent(h.db, req.AgentID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Agent not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve agent", http.StatusInternalServerError)
+		return
+	}
+
+	if !authorize(w, r, agent.ProjectID, auth.PermWorkOnTasks) {
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	task, err := database.ClaimNextTask(tx, agent)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	} else if errors.Is(err, database.ErrWIPLimitReached) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to pick next task", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast task assignment
+	h.hub.BroadcastToProject(task.ProjectID, "task_update", task)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
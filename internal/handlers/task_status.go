@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/models"
+	"github.com/techbuzzz/agent-shaker/internal/validator"
+)
+
+// ReopenTask moves a completed, failed or cancelled task back to pending
+func (h *TaskHandler) ReopenTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid task ID format", http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorizeTask(w, r, id, auth.PermManageTasks) {
+		return
+	}
+
+	current, err := database.GetTask(h.db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve task", http.StatusInternalServerError)
+		return
+	}
+
+	if err := validator.ValidateTaskReopen(current.Status); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	task, err := database.ScanTask(h.db.QueryRow(`
+		UPDATE tasks
+		SET status = $1, claim_expires_at = NULL, overdue_notified_at = NULL, updated_at = $2
+		WHERE id = $3 AND status = $4
+		RETURNING `+database.TaskColumns,
+		models.StatusPending, time.Now(), id, current.Status))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task status was changed concurrently, retry", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to reopen task", http.StatusInternalServerError)
+		return
+	}
+
+	h.recordTaskEvents(models.TaskChangeEvents(current, task, requestActor(r))...)
+
+	// Broadcast task update
+	h.hub.BroadcastToProject(task.ProjectID, "task_update", task)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// checkTaskTransition loads the task and writes a 404 or 409 response and
+// returns false if it may not move to the given status
+func (h *TaskHandler) checkTaskTransition(w http.ResponseWriter, id uuid.UUID, to models.TaskStatus) (models.Task, bool) {
+	task, err := database.GetTask(h.db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return task, false
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve task", http.StatusInternalServerError)
+		return task, false
+	}
+
+	if err := validator.ValidateTaskTransition(task.Status, to); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return task, false
+	}
+	return task, true
+}
+
+// checkStatusUnchanged writes a 409 response and returns false if a guarded
+// status update matched no row because the status changed in the meantime
+func checkStatusUnchanged(w http.ResponseWriter, result sql.Result) bool {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		http.Error(w, "Failed to update task status", http.StatusInternalServerError)
+		return false
+	}
+	if rowsAffected == 0 {
+		http.Error(w, "Task status was changed concurrently, retry", http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// checkWIPLimits writes a 409 response and returns false if moving the task
+// to the given status would exceed its project's WIP limits. Call it in the
+// transaction that updates the task, which then holds the project lock.
+func checkWIPLimits(w http.ResponseWriter, tx database.Querier, task models.Task, to models.TaskStatus) bool {
+	if to != models.StatusInProgress || task.Status == models.StatusInProgress {
+		return true
+	}
+
+	assignee := uuid.Nil
+	if task.AssignedTo != nil {
+		assignee = *task.AssignedTo
+	}
+	err := database.CheckWIPLimits(tx, task.ID, assignee)
+	if errors.Is(err, database.ErrWIPLimitReached) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return false
+	} else if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return false
+	} else if err != nil {
+		http.Error(w, "Failed to check WIP limits", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
@@ -3,11 +3,15 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
 	"github.com/techbuzzz/agent-shaker/internal/database"
 	"github.com/techbuzzz/agent-shaker/internal/models"
 	"github.com/techbuzzz/agent-shaker/internal/validator"
@@ -30,39 +34,128 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Requests authenticated as an agent act as that agent
+	if !actingAgent(w, r, &req.CreatedBy) {
+		return
+	}
+
 	// Validate request
 	if err := validator.ValidateCreateTaskRequest(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Set default priority if not provided
-	if req.Priority == "" {
-		req.Priority = "medium"
+	if !authorize(w, r, req.ProjectID, auth.PermManageTasks) {
+		return
+	}
+
+	project, err := database.GetProject(h.db, req.ProjectID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Project not found", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve project", http.StatusInternalServerError)
+		return
+	}
+
+	// Without a priority the project's default priority applies
+	req.Priority = project.Settings.TaskPriority(req.Priority)
+
+	req.Labels = models.NormalizeLabels(req.Labels)
+	if err := validator.ValidateLabels(req.Labels); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.RequiredSkills = models.NormalizeSkills(req.RequiredSkills)
+	if err := validator.ValidateSkills(req.RequiredSkills); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.CustomFields == nil {
+		req.CustomFields = models.CustomFields{}
+	}
+	if !h.checkCustomFields(w, req.ProjectID, req.CustomFields) {
+		return
+	}
+
+	// A subtask must belong to the same project as its parent
+	if req.ParentID != nil {
+		var parentProjectID uuid.UUID
+		err := h.db.QueryRow("SELECT project_id FROM tasks WHERE id = $1", *req.ParentID).Scan(&parentProjectID)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Parent task not found", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, "Failed to verify parent task", http.StatusInternalServerError)
+			return
+		}
+		if parentProjectID != req.ProjectID {
+			http.Error(w, "Parent task belongs to a different project", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.MilestoneID != nil && !h.checkMilestone(w, req.ProjectID, *req.MilestoneID) {
+		return
+	}
+
+	// Without an explicit due date the project's SLA for the priority applies
+	createdAt := time.Now()
+	dueAt := req.DueAt
+	if dueAt == nil {
+		dueAt = project.SLAHours.DueAt(req.Priority, createdAt)
 	}
 
 	task := models.Task{
-		ID:          uuid.New(),
-		ProjectID:   req.ProjectID,
-		Title:       req.Title,
-		Description: req.Description,
-		Status:      "pending",
-		Priority:    req.Priority,
-		CreatedBy:   req.CreatedBy,
-		AssignedTo:  req.AssignedTo,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
-
-	_, err := h.db.Exec(`
-		INSERT INTO tasks (id, project_id, title, description, status, priority, created_by, assigned_to, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-	`, task.ID, task.ProjectID, task.Title, task.Description, task.Status, task.Priority, task.CreatedBy, task.AssignedTo, task.CreatedAt, task.UpdatedAt)
+		ID:             uuid.New(),
+		ProjectID:      req.ProjectID,
+		ParentID:       req.ParentID,
+		Title:          req.Title,
+		Description:    req.Description,
+		Status:         "pending",
+		Priority:       req.Priority,
+		Role:           req.Role,
+		Team:           req.Team,
+		CreatedBy:      req.CreatedBy,
+		AssignedTo:     req.AssignedTo,
+		DueAt:          dueAt,
+		Labels:         pq.StringArray(req.Labels),
+		CustomFields:   req.CustomFields,
+		MilestoneID:    req.MilestoneID,
+		RequiredSkills: pq.StringArray(req.RequiredSkills),
+		CreatedAt:      createdAt,
+		UpdatedAt:      createdAt,
+	}
+
+	// Without a suitable agent an auto-assigned task stays in the queue
+	if req.AutoAssign {
+		candidates, err := database.ListAssignmentCandidates(h.db, task.ProjectID)
+		if err != nil {
+			http.Error(w, "Failed to retrieve agents", http.StatusInternalServerError)
+			return
+		}
+		if agent, ok := models.PickAssignee(task, candidates); ok {
+			task.AssignedTo = &agent.ID
+		}
+	}
+
+	customFieldsJSON, err := json.Marshal(task.CustomFields)
+	if err != nil {
+		http.Error(w, "Failed to serialize custom_fields", http.StatusBadRequest)
+		return
+	}
+
+	_, err = h.db.Exec(`
+		INSERT INTO tasks (id, project_id, parent_id, title, description, status, priority, role, team, created_by, assigned_to, due_at, labels, custom_fields, milestone_id, required_skills, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	`, task.ID, task.ProjectID, task.ParentID, task.Title, task.Description, task.Status, task.Priority, task.Role, task.Team, task.CreatedBy, task.AssignedTo, task.DueAt, task.Labels, customFieldsJSON, task.MilestoneID, task.RequiredSkills, task.CreatedAt, task.UpdatedAt)
 	if err != nil {
 		http.Error(w, "Failed to create task", http.StatusInternalServerError)
 		return
 	}
 
+	h.recordTaskEvents(models.NewTaskEvent(task, requestActor(r), models.TaskEventCreated, "", task.Title))
+
 	// Broadcast task creation
 	h.hub.BroadcastToProject(task.ProjectID, "task_update", task)
 
@@ -72,13 +165,8 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
-	query := `
-		SELECT id, project_id, title, description, status, priority, created_by, assigned_to, output, created_at, updated_at
-		FROM tasks
-		WHERE 1=1
-	`
+	query := `SELECT ` + database.TaskColumns + ` FROM tasks WHERE 1=1`
 	args := []interface{}{}
-	argCount := 1
 
 	// Add optional filters
 	projectIDStr := r.URL.Query().Get("project_id")
@@ -88,9 +176,8 @@ func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Invalid project_id format", http.StatusBadRequest)
 			return
 		}
-		query += " AND project_id = $1"
+		query += fmt.Sprintf(" AND project_id = $%d", len(args)+1)
 		args = append(args, projectID)
-		argCount++
 	}
 
 	agentIDStr := r.URL.Query().Get("agent_id")
@@ -100,30 +187,82 @@ func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Invalid agent_id format", http.StatusBadRequest)
 			return
 		}
-		if argCount == 1 {
-			query += " AND assigned_to = $1"
-		} else {
-			query += " AND assigned_to = $2"
-		}
+		query += fmt.Sprintf(" AND assigned_to = $%d", len(args)+1)
 		args = append(args, agentID)
-		argCount++
 	}
 
 	status := r.URL.Query().Get("status")
 	if status != "" {
-		switch argCount {
-		case 1:
-			query += " AND status = $1"
-		case 2:
-			query += " AND status = $2"
-		default:
-			query += " AND status = $3"
-		}
+		query += fmt.Sprintf(" AND status = $%d", len(args)+1)
 		args = append(args, status)
-		argCount++
 	}
 
-	query += " ORDER BY created_at DESC"
+	// parent_id=root returns only top-level tasks
+	parentIDStr := r.URL.Query().Get("parent_id")
+	if parentIDStr == "root" {
+		query += " AND parent_id IS NULL"
+	} else if parentIDStr != "" {
+		parentID, err := uuid.Parse(parentIDStr)
+		if err != nil {
+			http.Error(w, "Invalid parent_id format", http.StatusBadRequest)
+			return
+		}
+		query += fmt.Sprintf(" AND parent_id = $%d", len(args)+1)
+		args = append(args, parentID)
+	}
+
+	// milestone_id=none returns only tasks outside any milestone
+	milestoneIDStr := r.URL.Query().Get("milestone_id")
+	if milestoneIDStr == "none" {
+		query += " AND milestone_id IS NULL"
+	} else if milestoneIDStr != "" {
+		milestoneID, err := uuid.Parse(milestoneIDStr)
+		if err != nil {
+			http.Error(w, "Invalid milestone_id format", http.StatusBadRequest)
+			return
+		}
+		query += fmt.Sprintf(" AND milestone_id = $%d", len(args)+1)
+		args = append(args, milestoneID)
+	}
+
+	// Due date filters take RFC 3339 timestamps
+	for _, filter := range []struct{ param, op string }{{"due_before", "<"}, {"due_after", ">"}} {
+		param, op := filter.param, filter.op
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			continue
+		}
+		due, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, "Invalid "+param+" format, expected RFC 3339", http.StatusBadRequest)
+			return
+		}
+		query += fmt.Sprintf(" AND due_at %s $%d", op, len(args)+1)
+		args = append(args, due)
+	}
+
+	// Every label must be present; custom fields match on their text value (field.<name>=value)
+	if labels := models.NormalizeLabels(r.URL.Query()["label"]); len(labels) > 0 {
+		query += fmt.Sprintf(" AND labels @> $%d", len(args)+1)
+		args = append(args, pq.StringArray(labels))
+	}
+	for param, values := range r.URL.Query() {
+		if name := strings.TrimPrefix(param, "field."); name != param && len(values) > 0 {
+			query += " AND " + database.CustomFieldFilter(len(args)+1, len(args)+2)
+			args = append(args, name, values[0])
+		}
+	}
+
+	if r.URL.Query().Get("overdue") == "true" {
+		query += " AND " + database.OverdueFilter
+	}
+
+	orderBy, ok := database.TaskOrderBy(r.URL.Query().Get("sort"))
+	if !ok {
+		http.Error(w, "Invalid sort, must be one of: created_at, -created_at, due_at, -due_at", http.StatusBadRequest)
+		return
+	}
+	query += " ORDER BY " + orderBy
 
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
@@ -134,31 +273,11 @@ func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
 
 	var tasks []models.Task
 	for rows.Next() {
-		var t models.Task
-		var assignedToStr sql.NullString
-		var outputStr sql.NullString
-
-		if err := rows.Scan(&t.ID, &t.ProjectID, &t.Title, &t.Description, &t.Status, &t.Priority, &t.CreatedBy, &assignedToStr, &outputStr, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		t, err := database.ScanTask(rows)
+		if err != nil {
 			http.Error(w, "Failed to scan task", http.StatusInternalServerError)
 			return
 		}
-
-		// Handle nullable assigned_to field
-		if assignedToStr.Valid {
-			if assignedToUUID, err := uuid.Parse(assignedToStr.String); err == nil {
-				t.AssignedTo = &assignedToUUID
-			}
-		} else {
-			t.AssignedTo = nil
-		}
-
-		// Handle nullable output field
-		if outputStr.Valid {
-			t.Output = outputStr.String
-		} else {
-			t.Output = ""
-		}
-
 		tasks = append(tasks, t)
 	}
 
@@ -179,15 +298,7 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var task models.Task
-	var assignedToStr sql.NullString
-	var outputStr sql.NullString
-
-	err = h.db.QueryRow(`
-		SELECT id, project_id, title, description, status, priority, created_by, assigned_to, output, created_at, updated_at
-		FROM tasks
-		WHERE id = $1
-	`, id).Scan(&task.ID, &task.ProjectID, &task.Title, &task.Description, &task.Status, &task.Priority, &task.CreatedBy, &assignedToStr, &outputStr, &task.CreatedAt, &task.UpdatedAt)
+	task, err := database.GetTask(h.db, id)
 	if err == sql.ErrNoRows {
 		http.Error(w, "Task not found", http.StatusNotFound)
 		return
@@ -196,22 +307,7 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Handle nullable assigned_to field
-	if assignedToStr.Valid {
-		if assignedToUUID, err := uuid.Parse(assignedToStr.String); err == nil {
-			task.AssignedTo = &assignedToUUID
-		}
-	} else {
-		task.AssignedTo = nil
-	}
-
-	// Handle nullable output field
-	if outputStr.Valid {
-		task.Output = outputStr.String
-	} else {
-		task.Output = ""
-	}
-
+	setETag(w, task.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(task)
 }
@@ -224,6 +320,10 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.authorizeOwnTask(w, r, id) {
+		return
+	}
+
 	var req models.UpdateTaskRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -236,26 +336,64 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.db.Exec(`
+	expected, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	current, ok := h.checkTaskTransition(w, id, req.Status)
+	if !ok {
+		return
+	}
+
+	// Refuse to overwrite changes the client has not seen
+	if !checkVersion(w, expected, current.Version) {
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Refuse to start a task whose prerequisites are not completed
+	if req.Status == models.StatusInProgress && !checkTaskReady(w, tx, id) {
+		return
+	}
+
+	if !checkWIPLimits(w, tx, current, req.Status) {
+		return
+	}
+
+	// Leaving in_progress ends the claim lease. With If-Match the update is
+	// also guarded by the version so a write racing ours is not overwritten.
+	result, err := tx.Exec(`
 		UPDATE tasks
-		SET status = $1, output = $2, updated_at = $3
-		WHERE id = $4
-	`, req.Status, req.Output, time.Now(), id)
+		SET status = $1, output = $2, updated_at = $3,
+		    claim_expires_at = CASE WHEN $1 = 'in_progress' THEN claim_expires_at ELSE NULL END
+		WHERE id = $4 AND status = $5 AND ($6::int IS NULL OR version = $6)
+	`, req.Status, req.Output, time.Now(), id, current.Status, expected)
 	if err != nil {
 		http.Error(w, "Failed to update task", http.StatusInternalServerError)
 		return
 	}
+	if expected != nil {
+		if !checkVersionUnchanged(w, result) {
+			return
+		}
+	} else if !checkStatusUnchanged(w, result) {
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
 
 	// Get updated task
-	var task models.Task
-	var assignedToStr sql.NullString
-	var outputStr sql.NullString
-
-	err = h.db.QueryRow(`
-		SELECT id, project_id, title, description, status, priority, created_by, assigned_to, output, created_at, updated_at
-		FROM tasks
-		WHERE id = $1
-	`, id).Scan(&task.ID, &task.ProjectID, &task.Title, &task.Description, &task.Status, &task.Priority, &task.CreatedBy, &assignedToStr, &outputStr, &task.CreatedAt, &task.UpdatedAt)
+	task, err := database.GetTask(h.db, id)
 	if err == sql.ErrNoRows {
 		http.Error(w, "Task not found", http.StatusNotFound)
 		return
@@ -264,25 +402,15 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Handle nullable assigned_to field
-	if assignedToStr.Valid {
-		if assignedToUUID, err := uuid.Parse(assignedToStr.String); err == nil {
-			task.AssignedTo = &assignedToUUID
-		}
-	} else {
-		task.AssignedTo = nil
-	}
-
-	// Handle nullable output field
-	if outputStr.Valid {
-		task.Output = outputStr.String
-	} else {
-		task.Output = ""
-	}
+	h.recordTaskEvents(models.TaskChangeEvents(current, task, requestActor(r))...)
 
 	// Broadcast task update
 	h.hub.BroadcastToProject(task.ProjectID, "task_update", task)
 
+	// Finishing the last open subtask completes the parent
+	h.completeFinishedAncestors(task)
+
+	setETag(w, task.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(task)
 }
@@ -295,8 +423,12 @@ func (h *TaskHandler) UpdateTaskStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.authorizeOwnTask(w, r, id) {
+		return
+	}
+
 	var req struct {
-		Status string `json:"status"`
+		Status models.TaskStatus `json:"status"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -309,39 +441,54 @@ func (h *TaskHandler) UpdateTaskStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate status
-	validStatuses := []string{"pending", "in_progress", "completed", "failed", "blocked"}
-	valid := false
-	for _, s := range validStatuses {
-		if req.Status == s {
-			valid = true
-			break
-		}
+	if err := validator.ValidateTaskStatus(req.Status); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	if !valid {
-		http.Error(w, "invalid status value", http.StatusBadRequest)
+
+	current, ok := h.checkTaskTransition(w, id, req.Status)
+	if !ok {
 		return
 	}
 
-	_, err = h.db.Exec(`
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Refuse to start a task whose prerequisites are not completed
+	if req.Status == models.StatusInProgress && !checkTaskReady(w, tx, id) {
+		return
+	}
+
+	if !checkWIPLimits(w, tx, current, req.Status) {
+		return
+	}
+
+	// Leaving in_progress ends the claim lease
+	result, err := tx.Exec(`
 		UPDATE tasks
-		SET status = $1, updated_at = $2
-		WHERE id = $3
-	`, req.Status, time.Now(), id)
+		SET status = $1, updated_at = $2,
+		    claim_expires_at = CASE WHEN $1 = 'in_progress' THEN claim_expires_at ELSE NULL END
+		WHERE id = $3 AND status = $4
+	`, req.Status, time.Now(), id, current.Status)
 	if err != nil {
 		http.Error(w, "Failed to update task status", http.StatusInternalServerError)
 		return
 	}
+	if !checkStatusUnchanged(w, result) {
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
 
 	// Get updated task
-	var task models.Task
-	var assignedToStr sql.NullString
-	var outputStr sql.NullString
-
-	err = h.db.QueryRow(`
-		SELECT id, project_id, title, description, status, priority, created_by, assigned_to, output, created_at, updated_at
-		FROM tasks
-		WHERE id = $1
-	`, id).Scan(&task.ID, &task.ProjectID, &task.Title, &task.Description, &task.Status, &task.Priority, &task.CreatedBy, &assignedToStr, &outputStr, &task.CreatedAt, &task.UpdatedAt)
+	task, err := database.GetTask(h.db, id)
 	if err == sql.ErrNoRows {
 		http.Error(w, "Task not found", http.StatusNotFound)
 		return
@@ -350,25 +497,14 @@ func (h *TaskHandler) UpdateTaskStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Handle nullable assigned_to field
-	if assignedToStr.Valid {
-		if assignedToUUID, err := uuid.Parse(assignedToStr.String); err == nil {
-			task.AssignedTo = &assignedToUUID
-		}
-	} else {
-		task.AssignedTo = nil
-	}
-
-	// Handle nullable output field
-	if outputStr.Valid {
-		task.Output = outputStr.String
-	} else {
-		task.Output = ""
-	}
+	h.recordTaskEvents(models.TaskChangeEvents(current, task, requestActor(r))...)
 
 	// Broadcast task update
 	h.hub.BroadcastToProject(task.ProjectID, "task_update", task)
 
+	// Finishing the last open subtask completes the parent
+	h.completeFinishedAncestors(task)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(task)
 }
@@ -383,6 +519,10 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.authorizeTask(w, r, id, auth.PermManageTasks) {
+		return
+	}
+
 	// Begin transaction
 	tx, err := h.db.Begin()
 	if err != nil {
@@ -392,8 +532,7 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	defer tx.Rollback()
 
 	// Get task to retrieve project_id for WebSocket broadcast
-	var task models.Task
-	err = tx.QueryRow("SELECT id, project_id FROM tasks WHERE id = $1", id).Scan(&task.ID, &task.ProjectID)
+	task, err := database.GetTask(tx, id)
 	if err == sql.ErrNoRows {
 		http.Error(w, "Task not found", http.StatusNotFound)
 		return
@@ -402,8 +541,15 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Delete related contexts first
-	_, err = tx.Exec("DELETE FROM contexts WHERE task_id = $1", id)
+	// Delete related contexts first, including those of subtasks removed by the cascade
+	_, err = tx.Exec(`
+		WITH RECURSIVE subtree(id) AS (
+			SELECT id FROM tasks WHERE id = $1
+			UNION
+			SELECT t.id FROM tasks t INNER JOIN subtree s ON t.parent_id = s.id
+		)
+		DELETE FROM contexts WHERE task_id IN (SELECT id FROM subtree)
+	`, id)
 	if err != nil {
 		http.Error(w, "Failed to delete related contexts", http.StatusInternalServerError)
 		return
@@ -422,6 +568,13 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Keep a record of the deletion; subtasks removed by the cascade are covered by it
+	err = database.RecordTaskEvents(tx, models.NewTaskEvent(task, requestActor(r), models.TaskEventDeleted, task.Title, ""))
+	if err != nil {
+		http.Error(w, "Failed to record task history", http.StatusInternalServerError)
+		return
+	}
+
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
@@ -446,6 +599,10 @@ func (h *TaskHandler) ReassignTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.authorizeTask(w, r, id, auth.PermManageTasks) {
+		return
+	}
+
 	var req models.ReassignTaskRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -464,6 +621,15 @@ func (h *TaskHandler) ReassignTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	previous, err := database.GetTask(h.db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to retrieve task", http.StatusInternalServerError)
+		return
+	}
+
 	_, err = h.db.Exec(`
 		UPDATE tasks
 		SET assigned_to = $1, updated_at = $2
@@ -475,15 +641,7 @@ func (h *TaskHandler) ReassignTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get updated task
-	var task models.Task
-	var assignedToStr sql.NullString
-	var outputStr sql.NullString
-
-	err = h.db.QueryRow(`
-		SELECT id, project_id, title, description, status, priority, created_by, assigned_to, output, created_at, updated_at
-		FROM tasks
-		WHERE id = $1
-	`, id).Scan(&task.ID, &task.ProjectID, &task.Title, &task.Description, &task.Status, &task.Priority, &task.CreatedBy, &assignedToStr, &outputStr, &task.CreatedAt, &task.UpdatedAt)
+	task, err := database.GetTask(h.db, id)
 	if err == sql.ErrNoRows {
 		http.Error(w, "Task not found", http.StatusNotFound)
 		return
@@ -492,21 +650,7 @@ func (h *TaskHandler) ReassignTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Handle nullable assigned_to field
-	if assignedToStr.Valid {
-		if assignedToUUID, err := uuid.Parse(assignedToStr.String); err == nil {
-			task.AssignedTo = &assignedToUUID
-		}
-	} else {
-		task.AssignedTo = nil
-	}
-
-	// Handle nullable output field
-	if outputStr.Valid {
-		task.Output = outputStr.String
-	} else {
-		task.Output = ""
-	}
+	h.recordTaskEvents(models.TaskChangeEvents(previous, task, requestActor(r))...)
 
 	// Broadcast task reassignment
 	h.hub.BroadcastToProject(task.ProjectID, "task_reassigned", task)
@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
 	ws "github.com/techbuzzz/agent-shaker/internal/websocket"
 )
 
@@ -39,6 +40,13 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Agents may only follow their own project
+	if identity, ok := auth.FromContext(r.Context()); ok && !identity.IsAdmin() && identity.ProjectID != projectID {
+		log.Printf("WebSocket connection failed: agent %s is not part of project %s", identity.AgentID, projectID)
+		http.Error(w, "Forbidden: agents can only subscribe to their own project", http.StatusForbidden)
+		return
+	}
+
 	log.Printf("WebSocket upgrading connection for project %s", projectID)
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -0,0 +1,81 @@
+package markdown
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter is the YAML metadata block at the top of a document
+type FrontMatter map[string]interface{}
+
+// ErrInvalidFrontMatter is returned for a front matter block that is not valid YAML
+var ErrInvalidFrontMatter = errors.New("invalid front matter")
+
+// SplitFrontMatter separates a leading YAML front matter block, delimited by
+// "---" lines (the closing one may also be "..."), from the markdown body.
+// Content without front matter returns an empty FrontMatter and the content
+// unchanged. A block that is valid YAML but not a mapping, such as text
+// between two thematic breaks, is body text rather than front matter.
+func SplitFrontMatter(content string) (FrontMatter, string, error) {
+	fm := FrontMatter{}
+
+	normalized := strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, "---\n") {
+		return fm, content, nil
+	}
+
+	lines := strings.SplitAfter(normalized[len("---\n"):], "\n")
+	offset := len("---\n")
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, " \t\n")
+		if trimmed != "---" && trimmed != "..." {
+			offset += len(line)
+			continue
+		}
+
+		var parsed interface{}
+		block := strings.Join(lines[:i], "")
+		if err := yaml.Unmarshal([]byte(block), &parsed); err != nil {
+			return FrontMatter{}, content, fmt.Errorf("%w: %v", ErrInvalidFrontMatter, err)
+		}
+		if parsed != nil {
+			mapping, ok := jsonValue(parsed).(map[string]interface{})
+			if !ok {
+				return fm, content, nil
+			}
+			fm = mapping
+		}
+		return fm, normalized[offset+len(line):], nil
+	}
+
+	// No closing delimiter: a leading thematic break, not front matter
+	return fm, content, nil
+}
+
+// jsonValue converts mappings with non-string keys, which YAML allows but
+// JSON does not, into string-keyed maps so the front matter can be stored as JSONB
+func jsonValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = jsonValue(item)
+		}
+		return value
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			converted[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range value {
+			value[i] = jsonValue(item)
+		}
+		return value
+	default:
+		return v
+	}
+}
@@ -0,0 +1,120 @@
+// Package markdown renders context content to HTML on the server so every
+// consumer (web UI, A2A clients, MCP clients) sees the same safe output.
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// TOCEntry is a heading of a rendered document
+type TOCEntry struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"` // Anchor of the heading in the rendered HTML
+}
+
+// CodeBlock is a fenced or indented code block of a document
+type CodeBlock struct {
+	Language string `json:"language"` // Info string of a fenced block, empty if none
+	Code     string `json:"code"`
+}
+
+// Document is markdown rendered to sanitized HTML
+type Document struct {
+	HTML       string      `json:"html"`
+	TOC        []TOCEntry  `json:"toc"`
+	CodeBlocks []CodeBlock `json:"code_blocks"`
+}
+
+// renderer never emits raw HTML from the source and drops javascript:,
+// vbscript:, file: and non-image data: URLs, so the output is safe to embed
+var renderer = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+)
+
+// Render converts markdown to sanitized HTML and extracts its table of
+// contents and code blocks. Code blocks are tagged with a language-* class.
+// A front matter block is metadata and is not rendered.
+func Render(source string) (Document, error) {
+	doc := Document{TOC: []TOCEntry{}, CodeBlocks: []CodeBlock{}}
+
+	_, body, err := SplitFrontMatter(source)
+	if err != nil {
+		body = source
+	}
+	src := []byte(body)
+
+	root := renderer.Parser().Parse(text.NewReader(src))
+
+	err = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Heading:
+			id, _ := node.AttributeString("id")
+			idBytes, _ := id.([]byte)
+			doc.TOC = append(doc.TOC, TOCEntry{
+				Level: node.Level,
+				Text:  nodeText(node, src),
+				ID:    string(idBytes),
+			})
+		case *ast.FencedCodeBlock:
+			doc.CodeBlocks = append(doc.CodeBlocks, CodeBlock{
+				Language: string(node.Language(src)),
+				Code:     blockLines(node, src),
+			})
+		case *ast.CodeBlock:
+			doc.CodeBlocks = append(doc.CodeBlocks, CodeBlock{Code: blockLines(node, src)})
+		}
+		return ast.WalkContinue, nil
+	})
+	if err != nil {
+		return doc, fmt.Errorf("failed to walk markdown: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.Renderer().Render(&buf, src, root); err != nil {
+		return doc, fmt.Errorf("failed to render markdown: %w", err)
+	}
+	doc.HTML = buf.String()
+	return doc, nil
+}
+
+// nodeText returns the plain text of an inline node and its children
+func nodeText(n ast.Node, src []byte) string {
+	var buf bytes.Buffer
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch t := c.(type) {
+		case *ast.Text:
+			buf.Write(t.Segment.Value(src))
+			if t.SoftLineBreak() || t.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(t.Value)
+		default:
+			buf.WriteString(nodeText(c, src))
+		}
+	}
+	return buf.String()
+}
+
+// blockLines returns the raw content of a code block
+func blockLines(n ast.Node, src []byte) string {
+	var buf bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		buf.Write(segment.Value(src))
+	}
+	return buf.String()
+}
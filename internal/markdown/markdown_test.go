@@ -0,0 +1,121 @@
+package markdown
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRenderSanitizes(t *testing.T) {
+	doc, err := Render("# Title\n\n<script>alert(1)</script>\n\n[click](javascript:alert(1)) <img src=x onerror=alert(1)>\n")
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	for _, unsafe := range []string{"<script", "javascript:", "onerror"} {
+		if strings.Contains(doc.HTML, unsafe) {
+			t.Errorf("Expected %q to be removed, got %s", unsafe, doc.HTML)
+		}
+	}
+	if !strings.Contains(doc.HTML, `<h1 id="title">Title</h1>`) {
+		t.Errorf("Expected heading with anchor, got %s", doc.HTML)
+	}
+}
+
+func TestRenderTOCAndCodeBlocks(t *testing.T) {
+	source := "# API Design\n\n## Auth `tokens`\n\n```go\nfunc main() {}\n```\n\n### Errors\n\n    indented\n"
+
+	doc, err := Render(source)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	wantTOC := []TOCEntry{
+		{Level: 1, Text: "API Design", ID: "api-design"},
+		{Level: 2, Text: "Auth tokens", ID: "auth-tokens"},
+		{Level: 3, Text: "Errors", ID: "errors"},
+	}
+	if len(doc.TOC) != len(wantTOC) {
+		t.Fatalf("Expected TOC %v, got %v", wantTOC, doc.TOC)
+	}
+	for i := range wantTOC {
+		if doc.TOC[i] != wantTOC[i] {
+			t.Errorf("Expected TOC entry %v, got %v", wantTOC[i], doc.TOC[i])
+		}
+	}
+
+	wantCode := []CodeBlock{
+		{Language: "go", Code: "func main() {}\n"},
+		{Language: "", Code: "indented\n"},
+	}
+	if len(doc.CodeBlocks) != len(wantCode) {
+		t.Fatalf("Expected code blocks %v, got %v", wantCode, doc.CodeBlocks)
+	}
+	for i := range wantCode {
+		if doc.CodeBlocks[i] != wantCode[i] {
+			t.Errorf("Expected code block %v, got %v", wantCode[i], doc.CodeBlocks[i])
+		}
+	}
+
+	if !strings.Contains(doc.HTML, `<code class="language-go">`) {
+		t.Errorf("Expected code block tagged with its language, got %s", doc.HTML)
+	}
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	content := "---\nservice: billing\napi_version: 2\nowners:\n  - alice\n  - bob\n---\n# Billing API\n"
+
+	fm, body, err := SplitFrontMatter(content)
+	if err != nil {
+		t.Fatalf("SplitFrontMatter failed: %v", err)
+	}
+	if fm["service"] != "billing" || fm["api_version"] != 2 {
+		t.Errorf("Unexpected front matter: %v", fm)
+	}
+	if owners, ok := fm["owners"].([]interface{}); !ok || len(owners) != 2 {
+		t.Errorf("Expected two owners, got %v", fm["owners"])
+	}
+	if body != "# Billing API\n" {
+		t.Errorf("Expected body without front matter, got %q", body)
+	}
+}
+
+func TestSplitFrontMatterAbsent(t *testing.T) {
+	for _, content := range []string{"# Title\n", "---\nnot closed\n", "", "---\n\ntext\n\n---\n", "---\n- a list\n---\n"} {
+		fm, body, err := SplitFrontMatter(content)
+		if err != nil {
+			t.Fatalf("SplitFrontMatter(%q) failed: %v", content, err)
+		}
+		if len(fm) != 0 || body != content {
+			t.Errorf("Expected %q unchanged, got %v and %q", content, fm, body)
+		}
+	}
+}
+
+func TestSplitFrontMatterInvalid(t *testing.T) {
+	for _, content := range []string{"---\n: [unclosed\n---\n", "---\nkey: [unclosed\n---\n"} {
+		if _, _, err := SplitFrontMatter(content); err == nil {
+			t.Errorf("Expected error for %q", content)
+		}
+	}
+}
+
+func TestRenderSkipsFrontMatter(t *testing.T) {
+	doc, err := Render("---\nstatus: draft\n---\n# Title\n")
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if strings.Contains(doc.HTML, "status") || strings.Contains(doc.HTML, "<hr") {
+		t.Errorf("Expected front matter to be left out of the HTML, got %s", doc.HTML)
+	}
+}
+
+func TestSplitFrontMatterJSONCompatible(t *testing.T) {
+	fm, _, err := SplitFrontMatter("---\nports:\n  80: http\n  443: https\n---\n")
+	if err != nil {
+		t.Fatalf("SplitFrontMatter failed: %v", err)
+	}
+	if _, err := json.Marshal(fm); err != nil {
+		t.Errorf("Expected front matter to marshal to JSON, got %v", err)
+	}
+}
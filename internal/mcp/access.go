@@ -0,0 +1,108 @@
+package mcp
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
+)
+
+// errCodeForbidden is the JSON-RPC error code for tool calls the caller's
+// access role does not allow, the counterpart of 403 Forbidden on REST
+const errCodeForbidden = -32003
+
+// toolAccess describes what a tool needs from the agent calling it
+type toolAccess struct {
+	perm auth.Permission // Empty for tools that only read
+	// ownTask tools work on the task named by task_id, which agents that
+	// cannot manage tasks must be assigned to. claim also allows unassigned tasks.
+	ownTask, claim bool
+	// connection tools are checked against the agent's own project whatever
+	// project their arguments name
+	connection bool
+}
+
+// toolAccessList lists every tool. Agents are refused tools missing from it.
+var toolAccessList = map[string]toolAccess{
+	"get_my_identity":       {},
+	"get_my_project":        {},
+	"get_my_tasks":          {},
+	"update_my_status":      {perm: auth.PermWorkOnTasks},
+	"claim_task":            {perm: auth.PermWorkOnTasks, ownTask: true, claim: true},
+	"next_task":             {perm: auth.PermWorkOnTasks},
+	"renew_claim":           {perm: auth.PermWorkOnTasks, ownTask: true},
+	"complete_task":         {perm: auth.PermWorkOnTasks, ownTask: true},
+	"reopen_task":           {perm: auth.PermManageTasks},
+	"reassign_task":         {perm: auth.PermManageTasks},
+	"list_projects":         {},
+	"get_project":           {},
+	"clone_project":         {perm: auth.PermManageProject, connection: true},
+	"get_milestones":        {},
+	"list_agents":           {},
+	"get_agent":             {},
+	"list_tasks":            {},
+	"create_task":           {perm: auth.PermManageTasks},
+	"update_task_status":    {perm: auth.PermWorkOnTasks, ownTask: true},
+	"get_task_history":      {},
+	"comment_on_task":       {perm: auth.PermWorkOnTasks},
+	"list_task_comments":    {},
+	"search":                {},
+	"list_contexts":         {},
+	"add_context":           {perm: auth.PermWriteContexts},
+	"get_context_history":   {},
+	"get_context_links":     {},
+	"get_dashboard":         {},
+	"discover_a2a_agent":    {},
+	"delegate_to_a2a_agent": {perm: auth.PermWorkOnTasks},
+	"get_a2a_task_status":   {},
+}
+
+// authorizeTool checks that the caller may use a tool with the given
+// arguments. Calls without an agent identity are always allowed. The project
+// is that of the task or context the arguments name, else their project_id,
+// else the agent's own. Arguments that do not resolve are left for the tool
+// to reject. Refusals are *auth.ForbiddenError; other errors are lookup failures.
+func (h *MCPHandler) authorizeTool(name string, args map[string]interface{}, ctx MCPContext) error {
+	if ctx.Identity == nil {
+		return nil
+	}
+	identity := *ctx.Identity
+
+	access, ok := toolAccessList[name]
+	if !ok {
+		return &auth.ForbiddenError{Reason: fmt.Sprintf("tool %s is not available to agents", name)}
+	}
+	if access.perm == "" {
+		return nil
+	}
+
+	projectID := identity.ProjectID
+	var assignedTo *uuid.UUID
+	if !access.connection && h.db != nil {
+		if id, err := uuid.Parse(stringArg(args["project_id"])); err == nil {
+			projectID = id
+		}
+		if id, err := uuid.Parse(stringArg(args["context_id"])); err == nil {
+			if err := h.db.QueryRow(`SELECT project_id FROM contexts WHERE id = $1`, id).Scan(&projectID); err != nil && err != sql.ErrNoRows {
+				return fmt.Errorf("failed to look up context: %w", err)
+			}
+		}
+		if id, err := uuid.Parse(stringArg(args["task_id"])); err == nil {
+			if err := h.db.QueryRow(`SELECT project_id, assigned_to FROM tasks WHERE id = $1`, id).Scan(&projectID, &assignedTo); err != nil && err != sql.ErrNoRows {
+				return fmt.Errorf("failed to look up task: %w", err)
+			}
+		}
+	}
+
+	if access.ownTask {
+		return identity.AuthorizeTask(projectID, assignedTo, access.claim)
+	}
+	return identity.Authorize(projectID, access.perm)
+}
+
+// stringArg returns a tool argument that should be a string, empty otherwise
+func stringArg(arg interface{}) string {
+	s, _ := arg.(string)
+	return s
+}
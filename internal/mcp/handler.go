@@ -2,10 +2,13 @@ package mcp
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,8 +16,11 @@ import (
 	"github.com/lib/pq"
 	a2aClient "github.com/techbuzzz/agent-shaker/internal/a2a/client"
 	a2aModels "github.com/techbuzzz/agent-shaker/internal/a2a/models"
+	"github.com/techbuzzz/agent-shaker/internal/auth"
 	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/diff"
 	"github.com/techbuzzz/agent-shaker/internal/models"
+	"github.com/techbuzzz/agent-shaker/internal/validator"
 	"github.com/techbuzzz/agent-shaker/internal/websocket"
 )
 
@@ -141,6 +147,9 @@ type Session struct {
 type MCPContext struct {
 	ProjectID string
 	AgentID   string
+	// Identity is set when the caller authenticated with an agent key, which
+	// AgentID and ProjectID then come from
+	Identity *auth.Identity
 }
 
 func NewMCPHandler(db *database.DB, hub *websocket.Hub) *MCPHandler {
@@ -150,8 +159,31 @@ func NewMCPHandler(db *database.DB, hub *websocket.Hub) *MCPHandler {
 	}
 }
 
+// errActAsOtherAgent is returned when a call authenticated as one agent names another
+const errActAsOtherAgent = `{"error": "Forbidden: cannot act as another agent"}`
+
+// actingAgent returns the agent a tool call acts as when its arguments name
+// one, defaulting to the agent of the connection. Calls authenticated with
+// an agent key always act as that agent and may not name another.
+func actingAgent(arg interface{}, ctx MCPContext) (string, bool) {
+	claimed, _ := arg.(string)
+	if claimed == "" {
+		return ctx.AgentID, true
+	}
+	if ctx.Identity != nil && !strings.EqualFold(claimed, ctx.AgentID) {
+		return "", false
+	}
+	return claimed, true
+}
+
 // extractContext extracts project_id and agent_id from URL params or headers
 func (h *MCPHandler) extractContext(r *http.Request) MCPContext {
+	// An agent key decides who the caller is; URL parameters and headers
+	// only apply to the operator and with authentication disabled
+	if identity, ok := auth.FromContext(r.Context()); ok && !identity.IsAdmin() {
+		return MCPContext{ProjectID: identity.ProjectID.String(), AgentID: identity.AgentID.String(), Identity: &identity}
+	}
+
 	ctx := MCPContext{}
 
 	// Try URL query parameters first
@@ -174,7 +206,7 @@ func (h *MCPHandler) HandleMCP(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, X-Project-ID, X-Agent-ID")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization, X-Project-ID, X-Agent-ID")
 
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
@@ -375,13 +407,18 @@ func (h *MCPHandler) handleToolsList(ctx MCPContext) (interface{}, *JSONRPCError
 		},
 		{
 			Name:        "get_my_tasks",
-			Description: "Get tasks assigned to the current agent (requires agent_id in connection URL)",
+			Description: "Get tasks assigned to the current agent (requires agent_id in connection URL). Each task reports whether it is ready or blocked by unfinished prerequisites.",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
 					"status": map[string]interface{}{
 						"type":        "string",
-						"description": "Optional status filter (pending, in_progress, done, blocked)",
+						"description": "Optional status filter (pending, in_progress, blocked, completed, failed, cancelled)",
+					},
+					"readiness": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional filter: 'ready' (all prerequisites completed) or 'blocked' (waiting on prerequisites)",
+						"enum":        []string{"ready", "blocked"},
 					},
 				},
 			},
@@ -403,7 +440,7 @@ func (h *MCPHandler) handleToolsList(ctx MCPContext) (interface{}, *JSONRPCError
 		},
 		{
 			Name:        "claim_task",
-			Description: "Claim (assign to self) a task from the project (requires agent_id in connection URL)",
+			Description: "Claim (assign to self) a task from the project (requires agent_id in connection URL). Fails if the task still waits on unfinished prerequisite tasks. The claim is a lease that expires unless renewed with renew_claim or a heartbeat.",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
@@ -415,9 +452,31 @@ func (h *MCPHandler) handleToolsList(ctx MCPContext) (interface{}, *JSONRPCError
 				Required: []string{"task_id"},
 			},
 		},
+		{
+			Name:        "next_task",
+			Description: "Atomically pick and claim the highest-priority unassigned, unblocked task matching the current agent's role and team (requires agent_id in connection URL). Use this instead of list_tasks + claim_task to avoid two agents grabbing the same task.",
+			InputSchema: InputSchema{
+				Type:       "object",
+				Properties: map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "renew_claim",
+			Description: "Extend the lease on a task claimed by the current agent so it is not released back to pending (requires agent_id in connection URL)",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The claimed task ID",
+					},
+				},
+				Required: []string{"task_id"},
+			},
+		},
 		{
 			Name:        "complete_task",
-			Description: "Mark a task as done (requires agent_id in connection URL)",
+			Description: "Mark a task as completed (requires agent_id in connection URL)",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
@@ -425,6 +484,24 @@ func (h *MCPHandler) handleToolsList(ctx MCPContext) (interface{}, *JSONRPCError
 						"type":        "string",
 						"description": "The task ID to complete",
 					},
+					"expected_version": map[string]interface{}{
+						"type":        "number",
+						"description": "Optional task version you last read. The tool reports a conflict instead of completing if the task changed since.",
+					},
+				},
+				Required: []string{"task_id"},
+			},
+		},
+		{
+			Name:        "reopen_task",
+			Description: "Reopen a completed, failed or cancelled task by moving it back to pending",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The task ID to reopen",
+					},
 				},
 				Required: []string{"task_id"},
 			},
@@ -452,8 +529,13 @@ func (h *MCPHandler) handleToolsList(ctx MCPContext) (interface{}, *JSONRPCError
 			Name:        "list_projects",
 			Description: "List all projects in the system",
 			InputSchema: InputSchema{
-				Type:       "object",
-				Properties: map[string]interface{}{},
+				Type: "object",
+				Properties: map[string]interface{}{
+					"is_template": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only list templates (true) or only regular projects (false)",
+					},
+				},
 			},
 		},
 		{
@@ -470,6 +552,59 @@ func (h *MCPHandler) handleToolsList(ctx MCPContext) (interface{}, *JSONRPCError
 				Required: []string{"project_id"},
 			},
 		},
+		{
+			Name:        "clone_project",
+			Description: "Create a new project from an existing one, typically a template. Copies the agent roster, custom fields, the selected contexts and the task tree with statuses and assignments reset.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"project_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The project to clone (UUID). Defaults to your project.",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the new project",
+					},
+					"description": map[string]interface{}{
+						"type":        "string",
+						"description": "Description of the new project, defaults to the source project's",
+					},
+					"context_ids": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Contexts to copy",
+					},
+					"context_tags": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Copy contexts with any of these tags",
+					},
+					"include_tasks": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Copy the task tree as pending, unassigned tasks (default true)",
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+		{
+			Name:        "get_milestones",
+			Description: "Get the milestones of a project with their target dates, completion, remaining work and slip in days",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"project_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The project ID (UUID). Defaults to your project.",
+					},
+					"open_only": map[string]interface{}{
+						"type":        "boolean",
+						"description": "If true, leave out completed milestones",
+					},
+				},
+			},
+		},
 		{
 			Name:        "list_agents",
 			Description: "List all agents, optionally filtered by project",
@@ -499,7 +634,7 @@ func (h *MCPHandler) handleToolsList(ctx MCPContext) (interface{}, *JSONRPCError
 		},
 		{
 			Name:        "list_tasks",
-			Description: "List tasks, optionally filtered by project or agent",
+			Description: "List tasks, optionally filtered by project, agent, due date, labels or custom fields and sorted by due date",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
@@ -513,7 +648,45 @@ func (h *MCPHandler) handleToolsList(ctx MCPContext) (interface{}, *JSONRPCError
 					},
 					"status": map[string]interface{}{
 						"type":        "string",
-						"description": "Optional status filter (pending, in_progress, done, blocked)",
+						"description": "Optional status filter (pending, in_progress, blocked, completed, failed, cancelled)",
+					},
+					"parent_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional parent task ID to list only its subtasks",
+					},
+					"milestone_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional milestone ID to list only its tasks",
+					},
+					"tree": map[string]interface{}{
+						"type":        "boolean",
+						"description": "If true, return tasks nested under their parent task in a 'subtasks' field",
+					},
+					"due_before": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional RFC 3339 timestamp, only tasks due before it",
+					},
+					"due_after": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional RFC 3339 timestamp, only tasks due after it",
+					},
+					"overdue": map[string]interface{}{
+						"type":        "boolean",
+						"description": "If true, only open tasks whose due date has passed",
+					},
+					"labels": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Optional labels, only tasks carrying all of them",
+					},
+					"custom_fields": map[string]interface{}{
+						"type":        "object",
+						"description": "Optional custom field values to match exactly, e.g. {\"component\": \"auth\"}",
+					},
+					"sort": map[string]interface{}{
+						"type":        "string",
+						"description": "Sort order, newest first by default. due_at lists the soonest due first, tasks without a due date last.",
+						"enum":        []string{"created_at", "-created_at", "due_at", "-due_at"},
 					},
 				},
 			},
@@ -549,6 +722,44 @@ func (h *MCPHandler) handleToolsList(ctx MCPContext) (interface{}, *JSONRPCError
 						"type":        "string",
 						"description": "Agent ID to assign the task to",
 					},
+					"parent_task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional parent task ID to create this task as a subtask. The parent completes automatically once all its subtasks are completed.",
+					},
+					"role": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional agent role that should pick up the task from next_task (e.g. backend, frontend)",
+					},
+					"team": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional team that should pick up the task from next_task",
+					},
+					"due_at": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional RFC 3339 due date. Defaults to the project's SLA for the task priority.",
+					},
+					"labels": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Optional labels such as component names",
+					},
+					"custom_fields": map[string]interface{}{
+						"type":        "object",
+						"description": "Values for the project's custom fields (string, number, enum or YYYY-MM-DD date). Required fields must be set.",
+					},
+					"milestone_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional milestone of the project to attach the task to",
+					},
+					"required_skills": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Optional skills (languages, services, tools) an agent needs for the task",
+					},
+					"auto_assign": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Assign the task to the best available agent by skill match, current load and presence instead of assigned_to. The task stays unassigned if no agent qualifies.",
+					},
 				},
 				Required: []string{"title"},
 			},
@@ -565,13 +776,133 @@ func (h *MCPHandler) handleToolsList(ctx MCPContext) (interface{}, *JSONRPCError
 					},
 					"status": map[string]interface{}{
 						"type":        "string",
-						"description": "New status: pending, in_progress, done, blocked",
-						"enum":        []string{"pending", "in_progress", "done", "blocked"},
+						"description": "New status. Completed, failed and cancelled tasks must be reopened with reopen_task before they can change again.",
+						"enum":        []string{"pending", "in_progress", "blocked", "completed", "failed", "cancelled"},
+					},
+					"expected_version": map[string]interface{}{
+						"type":        "number",
+						"description": "Optional task version you last read (see list_tasks). The tool reports a conflict instead of updating if the task changed since.",
 					},
 				},
 				Required: []string{"task_id", "status"},
 			},
 		},
+		{
+			Name:        "get_task_history",
+			Description: "Get the audit trail of a task: who created it, changed its status, reassigned it, updated its output or deleted it, with old and new values. Check this before picking up work another agent touched.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The task ID",
+					},
+				},
+				Required: []string{"task_id"},
+			},
+		},
+		{
+			Name:        "comment_on_task",
+			Description: "Leave a markdown comment on a task, optionally as a reply to another comment. Use comments to share notes, questions and findings instead of overwriting the task output.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The task ID",
+					},
+					"body": map[string]interface{}{
+						"type":        "string",
+						"description": "Comment text in markdown format",
+					},
+					"reply_to_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional ID of a comment on the same task to reply to",
+					},
+				},
+				Required: []string{"task_id", "body"},
+			},
+		},
+		{
+			Name:        "list_task_comments",
+			Description: "List the comments on a task as threads, oldest first, with replies nested under the comment they answer",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The task ID",
+					},
+				},
+				Required: []string{"task_id"},
+			},
+		},
+		{
+			Name:        "search",
+			Description: "Full-text search across tasks, documentation contexts and standups. Returns ranked matches with highlighted snippets, so you can find prior art without reading every context. Supports quoted phrases, OR and -exclusions.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "Search terms, e.g. \"rate limit\" OR throttling -legacy",
+					},
+					"project_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional project ID to search in (uses connection URL context if not provided)",
+					},
+					"types": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string", "enum": []string{"task", "context", "standup"}},
+						"description": "Optional record types to search, all by default",
+					},
+					"limit": map[string]interface{}{
+						"type":        "number",
+						"description": "Maximum number of results (default 20, max 100)",
+					},
+				},
+				Required: []string{"query"},
+			},
+		},
+		{
+			Name:        "get_context_history",
+			Description: "List the revisions of a context with author and timestamp, and show a unified diff between two revisions. Use it to see who changed shared documentation and what they changed.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"context_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The context ID",
+					},
+					"from_revision": map[string]interface{}{
+						"type":        "number",
+						"description": "Revision to diff from (defaults to the one before to_revision)",
+					},
+					"to_revision": map[string]interface{}{
+						"type":        "number",
+						"description": "Revision to diff to (defaults to the latest)",
+					},
+				},
+				Required: []string{"context_id"},
+			},
+		},
+		{
+			Name:        "get_context_links",
+			Description: "Navigate the knowledge base through [[Context Title]] and agent-shaker://contexts/{id} links. With context_id, returns the contexts it links to, the contexts linking back to it and its broken links. Without it, reports every broken link in the project.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"context_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The context ID (optional, omit for a project-wide broken link report)",
+					},
+					"project_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Project for the broken link report (optional if project_id in MCP connection URL)",
+					},
+				},
+			},
+		},
 		{
 			Name:        "list_contexts",
 			Description: "List all documentation and contexts shared by agents in the project. Content is in markdown format for easy reading.",
@@ -582,12 +913,16 @@ func (h *MCPHandler) handleToolsList(ctx MCPContext) (interface{}, *JSONRPCError
 						"type":        "string",
 						"description": "Optional project ID to filter contexts (uses connection URL context if not provided)",
 					},
+					"front_matter": map[string]interface{}{
+						"type":        "object",
+						"description": "Optional filter on the YAML front matter of contexts, e.g. {\"service\": \"billing\", \"status\": \"stable\"}. A value matches the key's text value or an item of a list; an empty string only requires the key.",
+					},
 				},
 			},
 		},
 		{
 			Name:        "add_context",
-			Description: "Add documentation or context to share with other agents in the project. Supports full markdown formatting for better readability. If connected with project_id and agent_id in URL, those will be used automatically. Other agents can read this context to understand your work.",
+			Description: "Add documentation or context to share with other agents in the project, or update an existing one by passing context_id (previous versions are kept as revisions). Supports full markdown formatting for better readability. If connected with project_id and agent_id in URL, those will be used automatically. Other agents can read this context to understand your work.",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
@@ -612,6 +947,14 @@ func (h *MCPHandler) handleToolsList(ctx MCPContext) (interface{}, *JSONRPCError
 						"description": "Tags for categorization",
 						"items":       map[string]string{"type": "string"},
 					},
+					"context_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Update this existing context instead of creating a new one. The previous version is kept in the revision history.",
+					},
+					"expected_version": map[string]interface{}{
+						"type":        "number",
+						"description": "With context_id: the context version you last read (see list_contexts). The tool reports a conflict instead of overwriting if someone else changed the context since.",
+					},
 				},
 				Required: []string{"title", "content"},
 			},
@@ -700,6 +1043,22 @@ func (h *MCPHandler) handleToolsCall(params json.RawMessage, ctx MCPContext) (in
 
 	log.Printf("MCP Tool Call: %s with args %v (project=%s, agent=%s)", callParams.Name, callParams.Arguments, ctx.ProjectID, ctx.AgentID)
 
+	// Agents may only use the tools their access role allows
+	var forbidden *auth.ForbiddenError
+	if err := h.authorizeTool(callParams.Name, callParams.Arguments, ctx); errors.As(err, &forbidden) {
+		return nil, &JSONRPCError{
+			Code:    errCodeForbidden,
+			Message: "Forbidden",
+			Data:    forbidden.Reason,
+		}
+	} else if err != nil {
+		return nil, &JSONRPCError{
+			Code:    -32603,
+			Message: "Internal error",
+			Data:    err.Error(),
+		}
+	}
+
 	var resultText string
 	var isError bool
 
@@ -715,15 +1074,25 @@ func (h *MCPHandler) handleToolsCall(params json.RawMessage, ctx MCPContext) (in
 		resultText, isError = h.executeUpdateMyStatus(callParams.Arguments, ctx)
 	case "claim_task":
 		resultText, isError = h.executeClaimTask(callParams.Arguments, ctx)
+	case "next_task":
+		resultText, isError = h.executeNextTask(ctx)
+	case "renew_claim":
+		resultText, isError = h.executeRenewClaim(callParams.Arguments, ctx)
 	case "complete_task":
 		resultText, isError = h.executeCompleteTask(callParams.Arguments, ctx)
+	case "reopen_task":
+		resultText, isError = h.executeReopenTask(callParams.Arguments, ctx)
 	case "reassign_task":
-		resultText, isError = h.executeReassignTask(callParams.Arguments)
+		resultText, isError = h.executeReassignTask(callParams.Arguments, ctx)
 	// General tools
 	case "list_projects":
-		resultText, isError = h.executeListProjects()
+		resultText, isError = h.executeListProjects(callParams.Arguments)
 	case "get_project":
 		resultText, isError = h.executeGetProject(callParams.Arguments)
+	case "clone_project":
+		resultText, isError = h.executeCloneProject(callParams.Arguments, ctx)
+	case "get_milestones":
+		resultText, isError = h.executeGetMilestones(callParams.Arguments, ctx)
 	case "list_agents":
 		resultText, isError = h.executeListAgents(callParams.Arguments)
 	case "get_agent":
@@ -733,11 +1102,23 @@ func (h *MCPHandler) handleToolsCall(params json.RawMessage, ctx MCPContext) (in
 	case "create_task":
 		resultText, isError = h.executeCreateTask(callParams.Arguments, ctx)
 	case "update_task_status":
-		resultText, isError = h.executeUpdateTaskStatus(callParams.Arguments)
+		resultText, isError = h.executeUpdateTaskStatus(callParams.Arguments, ctx)
+	case "get_task_history":
+		resultText, isError = h.executeGetTaskHistory(callParams.Arguments)
+	case "comment_on_task":
+		resultText, isError = h.executeCommentOnTask(callParams.Arguments, ctx)
+	case "list_task_comments":
+		resultText, isError = h.executeListTaskComments(callParams.Arguments)
+	case "search":
+		resultText, isError = h.executeSearch(callParams.Arguments, ctx)
 	case "list_contexts":
 		resultText, isError = h.executeListContexts(callParams.Arguments)
 	case "add_context":
 		resultText, isError = h.executeAddContext(callParams.Arguments, ctx)
+	case "get_context_history":
+		resultText, isError = h.executeGetContextHistory(callParams.Arguments)
+	case "get_context_links":
+		resultText, isError = h.executeGetContextLinks(callParams.Arguments, ctx)
 	case "get_dashboard":
 		resultText, isError = h.executeGetDashboard()
 	// A2A Integration tools
@@ -811,7 +1192,7 @@ func (h *MCPHandler) handleResourcesRead(params json.RawMessage) (interface{}, *
 
 	switch readParams.URI {
 	case "agent-shaker://projects":
-		content, isError = h.executeListProjects()
+		content, isError = h.executeListProjects(nil)
 	case "agent-shaker://agents":
 		content, isError = h.executeListAgents(nil)
 	case "agent-shaker://tasks":
@@ -846,15 +1227,20 @@ func (h *MCPHandler) handleResourcesRead(params json.RawMessage) (interface{}, *
 }
 
 // Tool execution methods
-func (h *MCPHandler) executeListProjects() (string, bool) {
+func (h *MCPHandler) executeListProjects(args map[string]interface{}) (string, bool) {
 	if h.db == nil {
 		return `{"error": "Database not connected"}`, true
 	}
 
-	rows, err := h.db.Query(`
-		SELECT id, name, description, status, created_at, updated_at 
-		FROM projects ORDER BY created_at DESC
-	`)
+	query := `SELECT id, name, description, status, is_template, created_at, updated_at FROM projects`
+	var queryArgs []interface{}
+	if isTemplate, ok := args["is_template"].(bool); ok {
+		query += ` WHERE is_template = $1`
+		queryArgs = append(queryArgs, isTemplate)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := h.db.Query(query, queryArgs...)
 	if err != nil {
 		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
 	}
@@ -863,8 +1249,9 @@ func (h *MCPHandler) executeListProjects() (string, bool) {
 	var projects []map[string]interface{}
 	for rows.Next() {
 		var id, name, description, status string
+		var isTemplate bool
 		var createdAt, updatedAt interface{}
-		if err := rows.Scan(&id, &name, &description, &status, &createdAt, &updatedAt); err != nil {
+		if err := rows.Scan(&id, &name, &description, &status, &isTemplate, &createdAt, &updatedAt); err != nil {
 			continue
 		}
 		projects = append(projects, map[string]interface{}{
@@ -872,6 +1259,7 @@ func (h *MCPHandler) executeListProjects() (string, bool) {
 			"name":        name,
 			"description": description,
 			"status":      status,
+			"is_template": isTemplate,
 			"created_at":  createdAt,
 			"updated_at":  updatedAt,
 		})
@@ -892,11 +1280,12 @@ func (h *MCPHandler) executeGetProject(args map[string]interface{}) (string, boo
 	}
 
 	var id, name, description, status string
+	var isTemplate bool
 	var createdAt, updatedAt interface{}
 	err := h.db.QueryRow(`
-		SELECT id, name, description, status, created_at, updated_at 
+		SELECT id, name, description, status, is_template, created_at, updated_at 
 		FROM projects WHERE id = $1
-	`, projectID).Scan(&id, &name, &description, &status, &createdAt, &updatedAt)
+	`, projectID).Scan(&id, &name, &description, &status, &isTemplate, &createdAt, &updatedAt)
 	if err != nil {
 		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
 	}
@@ -906,18 +1295,115 @@ func (h *MCPHandler) executeGetProject(args map[string]interface{}) (string, boo
 		"name":        name,
 		"description": description,
 		"status":      status,
+		"is_template": isTemplate,
 		"created_at":  createdAt,
 		"updated_at":  updatedAt,
 	}, "", "  ")
 	return string(result), false
 }
 
+func (h *MCPHandler) executeGetMilestones(args map[string]interface{}, ctx MCPContext) (string, bool) {
+	if h.db == nil {
+		return `{"error": "Database not connected"}`, true
+	}
+
+	projectID, _ := args["project_id"].(string)
+	if projectID == "" {
+		projectID = ctx.ProjectID
+	}
+	if projectID == "" {
+		return `{"error": "project_id is required (or connect with a project context)"}`, true
+	}
+	pid, err := uuid.Parse(projectID)
+	if err != nil {
+		return `{"error": "Invalid project_id format"}`, true
+	}
+
+	openOnly, _ := args["open_only"].(bool)
+	milestones, err := database.ListMilestoneProgress(h.db, &pid, openOnly)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	result, _ := json.MarshalIndent(map[string]interface{}{
+		"project_id": projectID,
+		"milestones": milestones,
+		"count":      len(milestones),
+	}, "", "  ")
+	return string(result), false
+}
+
+func (h *MCPHandler) executeCloneProject(args map[string]interface{}, ctx MCPContext) (string, bool) {
+	if h.db == nil {
+		return `{"error": "Database not connected"}`, true
+	}
+
+	sourceID, _ := args["project_id"].(string)
+	if sourceID == "" {
+		sourceID = ctx.ProjectID
+	}
+	if sourceID == "" {
+		return `{"error": "project_id is required (or connect with a project context)"}`, true
+	}
+	source, err := uuid.Parse(sourceID)
+	if err != nil {
+		return `{"error": "Invalid project_id format"}`, true
+	}
+
+	req := models.CloneProjectRequest{ContextTags: stringArgs(args["context_tags"])}
+	req.Name, _ = args["name"].(string)
+	if description, ok := args["description"].(string); ok {
+		req.Description = &description
+	}
+	if includeTasks, ok := args["include_tasks"].(bool); ok {
+		req.IncludeTasks = &includeTasks
+	}
+	for _, id := range stringArgs(args["context_ids"]) {
+		contextID, err := uuid.Parse(id)
+		if err != nil {
+			return fmt.Sprintf(`{"error": "Invalid context_id %q"}`, id), true
+		}
+		req.ContextIDs = append(req.ContextIDs, contextID)
+	}
+	if err := validator.ValidateCloneProjectRequest(&req); err != nil {
+		return fmt.Sprintf(`{"error": %q}`, err.Error()), true
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+	defer tx.Rollback()
+
+	result, err := database.CloneProject(tx, source, req)
+	if err == sql.ErrNoRows {
+		return `{"error": "Project not found"}`, true
+	} else if err != nil {
+		return fmt.Sprintf(`{"error": %q}`, err.Error()), true
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	if h.hub != nil {
+		h.hub.BroadcastToProject(source, "project_cloned", map[string]interface{}{
+			"project_id": source,
+			"clone_id":   result.Project.ID,
+			"name":       result.Project.Name,
+		})
+	}
+
+	output, _ := json.MarshalIndent(result, "", "  ")
+	return string(output), false
+}
+
 func (h *MCPHandler) executeListAgents(args map[string]interface{}) (string, bool) {
 	if h.db == nil {
 		return `{"error": "Database not connected"}`, true
 	}
 
-	query := `SELECT id, project_id, name, role, status, team, created_at FROM agents`
+	query := `SELECT id, project_id, name, role, status, team, skills, capacity, created_at FROM agents`
 	var queryArgs []interface{}
 
 	if args != nil {
@@ -938,8 +1424,10 @@ func (h *MCPHandler) executeListAgents(args map[string]interface{}) (string, boo
 	for rows.Next() {
 		var id, projectID, name, role, status string
 		var team *string
+		var skills pq.StringArray
+		var capacity int
 		var createdAt interface{}
-		if err := rows.Scan(&id, &projectID, &name, &role, &status, &team, &createdAt); err != nil {
+		if err := rows.Scan(&id, &projectID, &name, &role, &status, &team, &skills, &capacity, &createdAt); err != nil {
 			continue
 		}
 		agent := map[string]interface{}{
@@ -948,6 +1436,8 @@ func (h *MCPHandler) executeListAgents(args map[string]interface{}) (string, boo
 			"name":       name,
 			"role":       role,
 			"status":     status,
+			"skills":     skills,
+			"capacity":   capacity,
 			"created_at": createdAt,
 		}
 		if team != nil {
@@ -972,11 +1462,13 @@ func (h *MCPHandler) executeGetAgent(args map[string]interface{}) (string, bool)
 
 	var id, projectID, name, role, status string
 	var team *string
+	var skills pq.StringArray
+	var capacity int
 	var createdAt interface{}
 	err := h.db.QueryRow(`
-		SELECT id, project_id, name, role, status, team, created_at 
+		SELECT id, project_id, name, role, status, team, skills, capacity, created_at 
 		FROM agents WHERE id = $1
-	`, agentID).Scan(&id, &projectID, &name, &role, &status, &team, &createdAt)
+	`, agentID).Scan(&id, &projectID, &name, &role, &status, &team, &skills, &capacity, &createdAt)
 	if err != nil {
 		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
 	}
@@ -987,6 +1479,8 @@ func (h *MCPHandler) executeGetAgent(args map[string]interface{}) (string, bool)
 		"name":       name,
 		"role":       role,
 		"status":     status,
+		"skills":     skills,
+		"capacity":   capacity,
 		"created_at": createdAt,
 	}
 	if team != nil {
@@ -1002,9 +1496,10 @@ func (h *MCPHandler) executeListTasks(args map[string]interface{}) (string, bool
 		return `{"error": "Database not connected"}`, true
 	}
 
-	query := `SELECT id, project_id, title, description, status, priority, assigned_to, created_at FROM tasks WHERE 1=1`
+	query := `SELECT id, project_id, parent_id, title, description, status, priority, role, team, assigned_to, due_at, labels, custom_fields, version, created_at FROM tasks WHERE 1=1`
 	var queryArgs []interface{}
 	argNum := 1
+	asTree := false
 
 	if args != nil {
 		if projectID, ok := args["project_id"].(string); ok && projectID != "" {
@@ -1022,8 +1517,53 @@ func (h *MCPHandler) executeListTasks(args map[string]interface{}) (string, bool
 			queryArgs = append(queryArgs, status)
 			argNum++
 		}
-	}
-	query += " ORDER BY created_at DESC"
+		if parentID, ok := args["parent_id"].(string); ok && parentID != "" {
+			query += fmt.Sprintf(" AND parent_id = $%d", argNum)
+			queryArgs = append(queryArgs, parentID)
+			argNum++
+		}
+		if milestoneID, ok := args["milestone_id"].(string); ok && milestoneID != "" {
+			query += fmt.Sprintf(" AND milestone_id = $%d", argNum)
+			queryArgs = append(queryArgs, milestoneID)
+			argNum++
+		}
+		for _, filter := range []struct{ param, op string }{{"due_before", "<"}, {"due_after", ">"}} {
+			value, _ := args[filter.param].(string)
+			if value == "" {
+				continue
+			}
+			due, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return fmt.Sprintf(`{"error": "Invalid %s format, expected RFC 3339"}`, filter.param), true
+			}
+			query += fmt.Sprintf(" AND due_at %s $%d", filter.op, argNum)
+			queryArgs = append(queryArgs, due)
+			argNum++
+		}
+		if overdue, _ := args["overdue"].(bool); overdue {
+			query += " AND " + database.OverdueFilter
+		}
+		if labels := models.NormalizeLabels(stringArgs(args["labels"])); len(labels) > 0 {
+			query += fmt.Sprintf(" AND labels @> $%d", argNum)
+			queryArgs = append(queryArgs, pq.StringArray(labels))
+			argNum++
+		}
+		if fields, ok := args["custom_fields"].(map[string]interface{}); ok {
+			for name, value := range fields {
+				query += " AND " + database.CustomFieldFilter(argNum, argNum+1)
+				queryArgs = append(queryArgs, name, fmt.Sprint(value))
+				argNum += 2
+			}
+		}
+		asTree, _ = args["tree"].(bool)
+	}
+
+	sort, _ := args["sort"].(string)
+	orderBy, ok := database.TaskOrderBy(sort)
+	if !ok {
+		return `{"error": "Invalid sort, must be one of: created_at, -created_at, due_at, -due_at"}`, true
+	}
+	query += " ORDER BY " + orderBy
 
 	rows, err := h.db.Query(query, queryArgs...)
 	if err != nil {
@@ -1034,9 +1574,13 @@ func (h *MCPHandler) executeListTasks(args map[string]interface{}) (string, bool
 	var tasks []map[string]interface{}
 	for rows.Next() {
 		var id, projectID, title, status, priority string
-		var description, assignedTo *string
+		var parentID, description, role, team, assignedTo *string
+		var dueAt *time.Time
+		var labels pq.StringArray
+		var customFieldsJSON []byte
+		var version int
 		var createdAt interface{}
-		if err := rows.Scan(&id, &projectID, &title, &description, &status, &priority, &assignedTo, &createdAt); err != nil {
+		if err := rows.Scan(&id, &projectID, &parentID, &title, &description, &status, &priority, &role, &team, &assignedTo, &dueAt, &labels, &customFieldsJSON, &version, &createdAt); err != nil {
 			continue
 		}
 		task := map[string]interface{}{
@@ -1045,17 +1589,42 @@ func (h *MCPHandler) executeListTasks(args map[string]interface{}) (string, bool
 			"title":      title,
 			"status":     status,
 			"priority":   priority,
+			"version":    version,
 			"created_at": createdAt,
 		}
+		if parentID != nil {
+			task["parent_id"] = *parentID
+		}
 		if description != nil {
 			task["description"] = *description
 		}
+		if role != nil {
+			task["role"] = *role
+		}
+		if team != nil {
+			task["team"] = *team
+		}
 		if assignedTo != nil {
 			task["assigned_to"] = *assignedTo
 		}
+		if dueAt != nil {
+			task["due_at"] = *dueAt
+			task["overdue"] = models.Task{DueAt: dueAt, Status: models.TaskStatus(status)}.IsOverdue(time.Now())
+		}
+		if len(labels) > 0 {
+			task["labels"] = labels
+		}
+		var customFields models.CustomFields
+		if json.Unmarshal(customFieldsJSON, &customFields) == nil && len(customFields) > 0 {
+			task["custom_fields"] = customFields
+		}
 		tasks = append(tasks, task)
 	}
 
+	if asTree {
+		tasks = buildTaskTree(tasks)
+	}
+
 	result, _ := json.MarshalIndent(tasks, "", "  ")
 	return string(result), false
 }
@@ -1082,16 +1651,13 @@ func (h *MCPHandler) executeCreateTask(args map[string]interface{}, ctx MCPConte
 
 	description, _ := args["description"].(string)
 	priority, _ := args["priority"].(string)
-	if priority == "" {
-		priority = "medium"
-	}
 	assignedTo, _ := args["assigned_to"].(string)
-	createdBy, _ := args["created_by"].(string)
-
-	// Use agent_id from context if created_by not provided
-	if createdBy == "" && ctx.AgentID != "" {
-		createdBy = ctx.AgentID
+	createdBy, ok := actingAgent(args["created_by"], ctx)
+	if !ok {
+		return errActAsOtherAgent, true
 	}
+	role, _ := args["role"].(string)
+	team, _ := args["team"].(string)
 
 	// If still no created_by, try to use the first agent from the project
 	if createdBy == "" {
@@ -1101,14 +1667,109 @@ func (h *MCPHandler) executeCreateTask(args map[string]interface{}, ctx MCPConte
 		}
 	}
 
+	autoAssign, _ := args["auto_assign"].(bool)
+	if autoAssign && assignedTo != "" {
+		return fmt.Sprintf(`{"error": "%s"}`, validator.ErrAutoAssignConflict.Error()), true
+	}
+
 	// Use agent_id from context if assigned_to not provided (agent assigns task to themselves)
-	if assignedTo == "" && ctx.AgentID != "" {
+	if assignedTo == "" && ctx.AgentID != "" && !autoAssign {
 		assignedTo = ctx.AgentID
 	}
 
+	// A subtask must belong to the same project as its parent
+	parentTaskID, _ := args["parent_task_id"].(string)
+	var parentTaskIDPtr *string
+	if parentTaskID != "" {
+		var parentProjectID string
+		err := h.db.QueryRow(`SELECT project_id FROM tasks WHERE id = $1`, parentTaskID).Scan(&parentProjectID)
+		if err != nil {
+			return fmt.Sprintf(`{"error": "Parent task not found: %s"}`, err.Error()), true
+		}
+		if parentProjectID != projectID {
+			return `{"error": "Parent task belongs to a different project"}`, true
+		}
+		parentTaskIDPtr = &parentTaskID
+	}
+
+	labels := models.NormalizeLabels(stringArgs(args["labels"]))
+	if err := validator.ValidateLabels(labels); err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+	requiredSkills := models.NormalizeSkills(stringArgs(args["required_skills"]))
+	if err := validator.ValidateSkills(requiredSkills); err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	customFields := models.CustomFields{}
+	if fields, ok := args["custom_fields"].(map[string]interface{}); ok {
+		customFields = fields
+	}
+	pid, err := uuid.Parse(projectID)
+	if err != nil {
+		return `{"error": "Invalid project_id format"}`, true
+	}
+	defs, err := database.ListCustomFieldDefinitions(h.db, pid)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+	if err := validator.ValidateCustomFields(defs, customFields); err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+	customFieldsJSON, _ := json.Marshal(customFields)
+
+	project, err := database.GetProject(h.db, pid)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "Project not found: %s"}`, err.Error()), true
+	}
+
+	// Without a priority the project's default priority applies
+	priority = project.Settings.TaskPriority(priority)
+
+	// A milestone must belong to the same project as the task
+	var milestoneID *uuid.UUID
+	if value, _ := args["milestone_id"].(string); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return `{"error": "Invalid milestone_id format"}`, true
+		}
+		milestone, err := database.GetMilestone(h.db, id)
+		if err != nil {
+			return fmt.Sprintf(`{"error": "Milestone not found: %s"}`, err.Error()), true
+		}
+		if milestone.ProjectID != pid {
+			return `{"error": "Milestone belongs to a different project"}`, true
+		}
+		milestoneID = &id
+	}
+
+	// Without an explicit due date the project's SLA for the priority applies
+	var dueAt *time.Time
+	if due, _ := args["due_at"].(string); due != "" {
+		parsed, err := time.Parse(time.RFC3339, due)
+		if err != nil {
+			return `{"error": "Invalid due_at format, expected RFC 3339"}`, true
+		}
+		dueAt = &parsed
+	} else {
+		dueAt = project.SLAHours.DueAt(priority, time.Now())
+	}
+
+	// Without a suitable agent an auto-assigned task stays in the queue
+	if autoAssign {
+		candidates, err := database.ListAssignmentCandidates(h.db, pid)
+		if err != nil {
+			return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+		}
+		draft := models.Task{ProjectID: pid, Role: models.AgentRole(role), Team: team, RequiredSkills: requiredSkills}
+		if agent, ok := models.PickAssignee(draft, candidates); ok {
+			assignedTo = agent.ID.String()
+		}
+	}
+
 	id := uuid.New().String()
-	query := `INSERT INTO tasks (id, project_id, title, description, status, priority, created_by, assigned_to) 
-	          VALUES ($1, $2, $3, $4, 'pending', $5, $6, $7) RETURNING id, created_at`
+	query := `INSERT INTO tasks (id, project_id, parent_id, title, description, status, priority, role, team, created_by, assigned_to, due_at, labels, custom_fields, milestone_id, required_skills) 
+	          VALUES ($1, $2, $3, $4, $5, 'pending', $6, NULLIF($7, ''), NULLIF($8, ''), $9, $10, $11, $12, $13, $14, $15) RETURNING id, created_at`
 
 	var createdID string
 	var createdAt interface{}
@@ -1117,11 +1778,16 @@ func (h *MCPHandler) executeCreateTask(args map[string]interface{}, ctx MCPConte
 		assignedToPtr = &assignedTo
 	}
 
-	err := h.db.QueryRow(query, id, projectID, title, description, priority, createdBy, assignedToPtr).Scan(&createdID, &createdAt)
+	err = h.db.QueryRow(query, id, projectID, parentTaskIDPtr, title, description, priority, role, team, createdBy, assignedToPtr, dueAt,
+		pq.StringArray(labels), customFieldsJSON, milestoneID, pq.StringArray(requiredSkills)).Scan(&createdID, &createdAt)
 	if err != nil {
 		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
 	}
 
+	if created, err := database.GetTask(h.db, uuid.MustParse(createdID)); err == nil {
+		h.recordTaskEvents(models.NewTaskEvent(created, mcpActor(ctx), models.TaskEventCreated, "", created.Title))
+	}
+
 	responseData := map[string]interface{}{
 		"success":    true,
 		"id":         createdID,
@@ -1136,12 +1802,33 @@ func (h *MCPHandler) executeCreateTask(args map[string]interface{}, ctx MCPConte
 	if assignedTo != "" {
 		responseData["assigned_to"] = assignedTo
 	}
+	if parentTaskID != "" {
+		responseData["parent_id"] = parentTaskID
+	}
+	if dueAt != nil {
+		responseData["due_at"] = dueAt
+	}
+	if len(labels) > 0 {
+		responseData["labels"] = labels
+	}
+	if len(customFields) > 0 {
+		responseData["custom_fields"] = customFields
+	}
+	if milestoneID != nil {
+		responseData["milestone_id"] = milestoneID
+	}
+	if len(requiredSkills) > 0 {
+		responseData["required_skills"] = requiredSkills
+	}
+	if autoAssign {
+		responseData["auto_assigned"] = assignedTo != ""
+	}
 
 	result, _ := json.MarshalIndent(responseData, "", "  ")
 	return string(result), false
 }
 
-func (h *MCPHandler) executeUpdateTaskStatus(args map[string]interface{}) (string, bool) {
+func (h *MCPHandler) executeUpdateTaskStatus(args map[string]interface{}, ctx MCPContext) (string, bool) {
 	if h.db == nil {
 		return `{"error": "Database not connected"}`, true
 	}
@@ -1155,21 +1842,277 @@ func (h *MCPHandler) executeUpdateTaskStatus(args map[string]interface{}) (strin
 		return `{"error": "status is required"}`, true
 	}
 
-	// Validate status
-	validStatuses := map[string]bool{"pending": true, "in_progress": true, "done": true, "blocked": true}
-	if !validStatuses[status] {
-		return `{"error": "invalid status, must be one of: pending, in_progress, done, blocked"}`, true
+	// Validate status and transition
+	current, errText, invalid := h.checkTaskTransition(h.db, taskID, models.TaskStatus(status))
+	if invalid {
+		return errText, true
+	}
+
+	expected := expectedVersion(args)
+	if expected != nil && *expected != current.Version {
+		return versionConflict("task", taskID, *expected, current.Version), true
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+	defer tx.Rollback()
+
+	// Refuse to start a task whose prerequisites are not completed
+	if models.TaskStatus(status) == models.StatusInProgress {
+		if errText, blocked := h.checkTaskReady(tx, taskID); blocked {
+			return errText, true
+		}
+	}
+
+	// Refuse to exceed the project's WIP limits, holding the project lock
+	// until the update commits
+	if models.TaskStatus(status) == models.StatusInProgress && current.Status != models.StatusInProgress {
+		assignee := uuid.Nil
+		if current.AssignedTo != nil {
+			assignee = *current.AssignedTo
+		}
+		if err := database.CheckWIPLimits(tx, current.ID, assignee); err != nil {
+			return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+		}
+	}
+
+	// Leaving in_progress ends the claim lease
+	result, err := tx.Exec(`
+		UPDATE tasks
+		SET status = $1, updated_at = NOW(),
+		    claim_expires_at = CASE WHEN $1 = 'in_progress' THEN claim_expires_at ELSE NULL END
+		WHERE id = $2 AND status = $3 AND ($4::int IS NULL OR version = $4)
+	`, status, taskID, current.Status, expected)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		if expected != nil {
+			return h.taskVersionConflict(current.ID, *expected), true
+		}
+		return `{"error": "Task status was changed concurrently, retry"}`, true
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+	h.recordTaskChanges(current, ctx)
+
+	// Closing the last open subtask completes the parent
+	if models.TaskStatus(status).IsCompleted() || models.TaskStatus(status) == models.StatusCancelled {
+		h.completeFinishedAncestors(taskID)
+	}
+
+	resultJSON, _ := json.MarshalIndent(map[string]interface{}{
+		"success":         true,
+		"task_id":         taskID,
+		"previous_status": current.Status,
+		"status":          status,
+		"version":         h.taskVersion(current.ID),
+	}, "", "  ")
+	return string(resultJSON), false
+}
+
+func (h *MCPHandler) executeGetTaskHistory(args map[string]interface{}) (string, bool) {
+	if h.db == nil {
+		return `{"error": "Database not connected"}`, true
+	}
+
+	taskID, ok := args["task_id"].(string)
+	if !ok || taskID == "" {
+		return `{"error": "task_id is required"}`, true
 	}
 
-	_, err := h.db.Exec(`UPDATE tasks SET status = $1, updated_at = NOW() WHERE id = $2`, status, taskID)
+	id, err := uuid.Parse(taskID)
+	if err != nil {
+		return `{"error": "Invalid task_id format"}`, true
+	}
+
+	events, err := database.ListTaskEvents(h.db, id)
 	if err != nil {
 		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
 	}
 
 	result, _ := json.MarshalIndent(map[string]interface{}{
-		"success": true,
 		"task_id": taskID,
-		"status":  status,
+		"events":  events,
+		"count":   len(events),
+	}, "", "  ")
+	return string(result), false
+}
+
+func (h *MCPHandler) executeCommentOnTask(args map[string]interface{}, ctx MCPContext) (string, bool) {
+	if h.db == nil {
+		return `{"error": "Database not connected"}`, true
+	}
+
+	if ctx.AgentID == "" {
+		return `{"error": "No agent_id configured in MCP connection URL. Add ?agent_id=UUID to the URL."}`, true
+	}
+	authorID, err := uuid.Parse(ctx.AgentID)
+	if err != nil {
+		return `{"error": "Invalid agent_id in connection context"}`, true
+	}
+
+	taskID, ok := args["task_id"].(string)
+	if !ok || taskID == "" {
+		return `{"error": "task_id is required"}`, true
+	}
+	id, err := uuid.Parse(taskID)
+	if err != nil {
+		return `{"error": "Invalid task_id format"}`, true
+	}
+
+	req := models.CreateTaskCommentRequest{AuthorID: authorID}
+	req.Body, _ = args["body"].(string)
+	if replyTo, _ := args["reply_to_id"].(string); replyTo != "" {
+		replyToID, err := uuid.Parse(replyTo)
+		if err != nil {
+			return `{"error": "Invalid reply_to_id format"}`, true
+		}
+		req.ReplyToID = &replyToID
+	}
+
+	if err := validator.ValidateCreateTaskCommentRequest(&req); err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	task, err := database.GetTask(h.db, id)
+	if err == sql.ErrNoRows {
+		return `{"error": "Task not found"}`, true
+	} else if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	// The author must be an agent of the task's project
+	author, err := database.GetAgent(h.db, authorID)
+	if err == sql.ErrNoRows {
+		return `{"error": "Agent not found"}`, true
+	} else if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+	if author.ProjectID != task.ProjectID {
+		return `{"error": "Task belongs to a different project than your agent"}`, true
+	}
+
+	// Replies must answer a comment on the same task
+	if req.ReplyToID != nil {
+		if _, err := database.GetTaskComment(h.db, id, *req.ReplyToID); err == sql.ErrNoRows {
+			return `{"error": "reply_to_id does not match a comment on this task"}`, true
+		} else if err != nil {
+			return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+		}
+	}
+
+	comment := models.TaskComment{
+		ID:        uuid.New(),
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		AuthorID:  authorID,
+		ReplyToID: req.ReplyToID,
+		Body:      req.Body,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := database.InsertTaskComment(h.db, comment); err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	// Broadcast the new comment
+	if h.hub != nil {
+		h.hub.BroadcastToProject(comment.ProjectID, "task_comment", map[string]interface{}{
+			"action":     "created",
+			"comment":    comment,
+			"task_id":    comment.TaskID,
+			"project_id": comment.ProjectID.String(),
+		})
+	}
+
+	result, _ := json.MarshalIndent(map[string]interface{}{
+		"success":    true,
+		"comment":    comment,
+		"task_title": task.Title,
+		"message":    fmt.Sprintf("Comment added to task '%s'", task.Title),
+	}, "", "  ")
+	return string(result), false
+}
+
+func (h *MCPHandler) executeListTaskComments(args map[string]interface{}) (string, bool) {
+	if h.db == nil {
+		return `{"error": "Database not connected"}`, true
+	}
+
+	taskID, ok := args["task_id"].(string)
+	if !ok || taskID == "" {
+		return `{"error": "task_id is required"}`, true
+	}
+	id, err := uuid.Parse(taskID)
+	if err != nil {
+		return `{"error": "Invalid task_id format"}`, true
+	}
+
+	var exists bool
+	if err := h.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+	if !exists {
+		return `{"error": "Task not found"}`, true
+	}
+
+	comments, err := database.ListTaskComments(h.db, id)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	result, _ := json.MarshalIndent(map[string]interface{}{
+		"task_id":  taskID,
+		"comments": models.ThreadTaskComments(comments),
+		"count":    len(comments),
+	}, "", "  ")
+	return string(result), false
+}
+
+func (h *MCPHandler) executeSearch(args map[string]interface{}, ctx MCPContext) (string, bool) {
+	if h.db == nil {
+		return `{"error": "Database not connected"}`, true
+	}
+
+	query := models.SearchQuery{}
+	query.Text, _ = args["query"].(string)
+
+	projectID, _ := args["project_id"].(string)
+	if projectID == "" {
+		projectID = ctx.ProjectID
+	}
+	if projectID != "" {
+		pid, err := uuid.Parse(projectID)
+		if err != nil {
+			return `{"error": "Invalid project_id format"}`, true
+		}
+		query.ProjectID = &pid
+	}
+
+	for _, t := range stringArgs(args["types"]) {
+		query.Types = append(query.Types, models.SearchResultType(t))
+	}
+	if limit, ok := args["limit"].(float64); ok {
+		query.Limit = int(limit)
+	}
+
+	if err := validator.ValidateSearchQuery(&query); err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	results, err := database.Search(h.db, query)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	result, _ := json.MarshalIndent(map[string]interface{}{
+		"query":   query.Text,
+		"results": results,
+		"count":   len(results),
 	}, "", "  ")
 	return string(result), false
 }
@@ -1179,15 +2122,27 @@ func (h *MCPHandler) executeListContexts(args map[string]interface{}) (string, b
 		return `{"error": "Database not connected"}`, true
 	}
 
-	query := `SELECT c.id, c.project_id, c.agent_id, a.name as agent_name, c.title, c.content, c.tags, c.created_at 
+	query := `SELECT c.id, c.project_id, c.agent_id, a.name as agent_name, c.title, c.content, c.tags, c.front_matter, c.version, c.created_at 
 	          FROM contexts c 
-	          LEFT JOIN agents a ON c.agent_id = a.id`
+	          LEFT JOIN agents a ON c.agent_id = a.id
+	          WHERE 1=1`
 	var queryArgs []interface{}
 
 	if args != nil {
 		if projectID, ok := args["project_id"].(string); ok && projectID != "" {
-			query += " WHERE c.project_id = $1"
 			queryArgs = append(queryArgs, projectID)
+			query += fmt.Sprintf(" AND c.project_id = $%d", len(queryArgs))
+		}
+		if filters, ok := args["front_matter"].(map[string]interface{}); ok {
+			for key, value := range filters {
+				if text := fmt.Sprint(value); value == nil || text == "" {
+					query += " AND " + database.FrontMatterKeyFilter(len(queryArgs)+1)
+					queryArgs = append(queryArgs, key)
+				} else {
+					query += " AND " + database.FrontMatterFilter(len(queryArgs)+1, len(queryArgs)+2)
+					queryArgs = append(queryArgs, key, text)
+				}
+			}
 		}
 	}
 	query += " ORDER BY c.created_at DESC"
@@ -1203,8 +2158,10 @@ func (h *MCPHandler) executeListContexts(args map[string]interface{}) (string, b
 		var id, projectID, agentID, title, content string
 		var agentName *string
 		var tags interface{}
+		var frontMatterJSON []byte
+		var version int
 		var createdAt interface{}
-		if err := rows.Scan(&id, &projectID, &agentID, &agentName, &title, &content, &tags, &createdAt); err != nil {
+		if err := rows.Scan(&id, &projectID, &agentID, &agentName, &title, &content, &tags, &frontMatterJSON, &version, &createdAt); err != nil {
 			continue
 		}
 
@@ -1220,111 +2177,310 @@ func (h *MCPHandler) executeListContexts(args map[string]interface{}) (string, b
 		}
 
 		contexts = append(contexts, map[string]interface{}{
-			"id":         id,
-			"project_id": projectID,
-			"agent_id":   agentID,
-			"agent_name": agentNameStr,
-			"title":      title,
-			"content":    content,
-			"preview":    preview,
-			"format":     "markdown",
-			"tags":       tags,
-			"created_at": createdAt,
+			"id":           id,
+			"project_id":   projectID,
+			"agent_id":     agentID,
+			"agent_name":   agentNameStr,
+			"title":        title,
+			"content":      content,
+			"preview":      preview,
+			"format":       "markdown",
+			"tags":         tags,
+			"front_matter": json.RawMessage(frontMatterJSON),
+			"version":      version,
+			"created_at":   createdAt,
+		})
+	}
+
+	result, _ := json.MarshalIndent(map[string]interface{}{
+		"contexts": contexts,
+		"count":    len(contexts),
+		"note":     "Content is in markdown format - render it for best readability",
+	}, "", "  ")
+	return string(result), false
+}
+
+func (h *MCPHandler) executeAddContext(args map[string]interface{}, ctx MCPContext) (string, bool) {
+	if h.db == nil {
+		return `{"error": "Database not connected"}`, true
+	}
+
+	projectID, ok := args["project_id"].(string)
+	if !ok || projectID == "" {
+		// Use project_id from context if not provided in args
+		if ctx.ProjectID != "" {
+			projectID = ctx.ProjectID
+		} else {
+			return `{"error": "project_id is required"}`, true
+		}
+	}
+
+	title, ok := args["title"].(string)
+	if !ok {
+		return `{"error": "title is required"}`, true
+	}
+	content, ok := args["content"].(string)
+	if !ok {
+		return `{"error": "content is required"}`, true
+	}
+
+	// Use agent_id from context if not provided in args
+	agentID, ok := actingAgent(args["agent_id"], ctx)
+	if !ok {
+		return errActAsOtherAgent, true
+	}
+
+	// If still no agent_id, try to use the first agent from the project
+	if agentID == "" {
+		err := h.db.QueryRow(`SELECT id FROM agents WHERE project_id = $1 LIMIT 1`, projectID).Scan(&agentID)
+		if err != nil {
+			return `{"error": "agent_id is required or no agents found in project"}`, true
+		}
+	}
+
+	// Convert tags to PostgreSQL array format using pq.Array
+	var tags []string
+	if tagsInterface, ok := args["tags"].([]interface{}); ok {
+		for _, tag := range tagsInterface {
+			if tagStr, ok := tag.(string); ok {
+				tags = append(tags, tagStr)
+			}
+		}
+	}
+
+	author, err := uuid.Parse(agentID)
+	if err != nil {
+		return `{"error": "Invalid agent_id format"}`, true
+	}
+
+	frontMatter, err := database.FrontMatterJSON(content)
+	if err != nil {
+		return fmt.Sprintf(`{"error": %q}`, err.Error()), true
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+	defer tx.Rollback()
+
+	// With a context_id the existing context is updated and the previous
+	// content stays available in its revision history
+	var saved models.Context
+	contextID, _ := args["context_id"].(string)
+	if contextID != "" {
+		cid, err := uuid.Parse(contextID)
+		if err != nil {
+			return `{"error": "Invalid context_id format"}`, true
+		}
+		existing, err := database.LockContext(tx, cid)
+		if err == sql.ErrNoRows {
+			return `{"error": "Context not found"}`, true
+		} else if err != nil {
+			return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+		}
+		if existing.ProjectID.String() != projectID {
+			return `{"error": "Context belongs to a different project"}`, true
+		}
+		if expected := expectedVersion(args); expected != nil && *expected != existing.Version {
+			return versionConflict("context", contextID, *expected, existing.Version), true
+		}
+		if _, ok := args["tags"]; !ok {
+			tags = existing.Tags
+		}
+		saved, err = database.ScanContext(tx.QueryRow(`
+			UPDATE contexts
+			SET title = $1, content = $2, tags = $3, front_matter = $4, updated_at = $5
+			WHERE id = $6
+			RETURNING `+database.ContextColumns,
+			title, content, pq.Array(tags), frontMatter, time.Now(), cid))
+		if err != nil {
+			return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+		}
+	} else {
+		// Use pq.Array for proper PostgreSQL array handling
+		saved, err = database.ScanContext(tx.QueryRow(`
+			INSERT INTO contexts (id, project_id, agent_id, title, content, tags, front_matter)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING `+database.ContextColumns,
+			uuid.New(), projectID, agentID, title, content, pq.Array(tags), frontMatter))
+		if err != nil {
+			return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+		}
+	}
+
+	revision, err := database.RecordContextRevision(tx, saved, &author)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	if err := database.ReplaceContextLinks(tx, saved); err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	if h.hub != nil {
+		event := "context_added"
+		if contextID != "" {
+			event = "context_updated"
+		}
+		h.hub.BroadcastToProject(saved.ProjectID, event, saved)
+	}
+
+	// Create a preview of the content (first 200 chars)
+	preview := content
+	if len(preview) > 200 {
+		preview = preview[:200] + "..."
+	}
+
+	// Get agent name for better feedback
+	var agentName string
+	h.db.QueryRow(`SELECT name FROM agents WHERE id = $1`, agentID).Scan(&agentName)
+	if agentName == "" {
+		agentName = "Unknown Agent"
+	}
+
+	result, _ := json.MarshalIndent(map[string]interface{}{
+		"success":      true,
+		"id":           saved.ID,
+		"title":        title,
+		"agent_id":     agentID,
+		"agent_name":   agentName,
+		"tags":         saved.Tags,
+		"preview":      preview,
+		"format":       "markdown",
+		"revision":     revision.Revision,
+		"version":      saved.Version,
+		"front_matter": saved.FrontMatter,
+		"created_at":   saved.CreatedAt,
+		"updated_at":   saved.UpdatedAt,
+		"shared_with":  "All agents in the project can now read this context",
+	}, "", "  ")
+	return string(result), false
+}
+
+func (h *MCPHandler) executeGetContextHistory(args map[string]interface{}) (string, bool) {
+	if h.db == nil {
+		return `{"error": "Database not connected"}`, true
+	}
+
+	contextIDStr, _ := args["context_id"].(string)
+	contextID, err := uuid.Parse(contextIDStr)
+	if err != nil {
+		return `{"error": "Invalid context_id format"}`, true
+	}
+
+	if _, err := database.GetContext(h.db, contextID); err == sql.ErrNoRows {
+		return `{"error": "Context not found"}`, true
+	} else if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	revisions, err := database.ListContextRevisions(h.db, contextID)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	// Revisions are listed newest first; revision 0 is the empty document
+	to := 0
+	if len(revisions) > 0 {
+		to = revisions[0].Revision
+	}
+	if v, ok := args["to_revision"].(float64); ok {
+		to = int(v)
+	}
+	from := to - 1
+	if v, ok := args["from_revision"].(float64); ok {
+		from = int(v)
+	}
+
+	contents := map[int]string{}
+	for _, rev := range revisions {
+		contents[rev.Revision] = rev.Content
+	}
+	for _, n := range []int{from, to} {
+		if _, ok := contents[n]; !ok && n != 0 {
+			return fmt.Sprintf(`{"error": "Revision %d not found"}`, n), true
+		}
+	}
+
+	history := make([]map[string]interface{}, 0, len(revisions))
+	for _, rev := range revisions {
+		history = append(history, map[string]interface{}{
+			"revision":   rev.Revision,
+			"author_id":  rev.AuthorID,
+			"title":      rev.Title,
+			"created_at": rev.CreatedAt,
 		})
 	}
 
 	result, _ := json.MarshalIndent(map[string]interface{}{
-		"contexts": contexts,
-		"count":    len(contexts),
-		"note":     "Content is in markdown format - render it for best readability",
+		"context_id": contextID,
+		"revisions":  history,
+		"diff": models.ContextDiff{
+			ContextID:    contextID,
+			FromRevision: from,
+			ToRevision:   to,
+			Diff: diff.Unified(fmt.Sprintf("revision %d", from), fmt.Sprintf("revision %d", to),
+				contents[from], contents[to], diff.DefaultContext),
+		},
 	}, "", "  ")
 	return string(result), false
 }
 
-func (h *MCPHandler) executeAddContext(args map[string]interface{}, ctx MCPContext) (string, bool) {
+func (h *MCPHandler) executeGetContextLinks(args map[string]interface{}, ctx MCPContext) (string, bool) {
 	if h.db == nil {
 		return `{"error": "Database not connected"}`, true
 	}
 
-	projectID, ok := args["project_id"].(string)
-	if !ok || projectID == "" {
-		// Use project_id from context if not provided in args
-		if ctx.ProjectID != "" {
-			projectID = ctx.ProjectID
-		} else {
-			return `{"error": "project_id is required"}`, true
+	contextIDStr, _ := args["context_id"].(string)
+	if contextIDStr == "" {
+		projectIDStr, _ := args["project_id"].(string)
+		if projectIDStr == "" {
+			projectIDStr = ctx.ProjectID
 		}
-	}
-
-	title, ok := args["title"].(string)
-	if !ok {
-		return `{"error": "title is required"}`, true
-	}
-	content, ok := args["content"].(string)
-	if !ok {
-		return `{"error": "content is required"}`, true
-	}
-
-	agentID, _ := args["agent_id"].(string)
-
-	// Use agent_id from context if not provided in args
-	if agentID == "" && ctx.AgentID != "" {
-		agentID = ctx.AgentID
-	}
-
-	// If still no agent_id, try to use the first agent from the project
-	if agentID == "" {
-		err := h.db.QueryRow(`SELECT id FROM agents WHERE project_id = $1 LIMIT 1`, projectID).Scan(&agentID)
+		projectID, err := uuid.Parse(projectIDStr)
 		if err != nil {
-			return `{"error": "agent_id is required or no agents found in project"}`, true
+			return `{"error": "context_id or project_id is required"}`, true
 		}
-	}
 
-	// Convert tags to PostgreSQL array format using pq.Array
-	var tags []string
-	if tagsInterface, ok := args["tags"].([]interface{}); ok {
-		for _, tag := range tagsInterface {
-			if tagStr, ok := tag.(string); ok {
-				tags = append(tags, tagStr)
-			}
+		broken, err := database.ListBrokenContextLinks(h.db, projectID)
+		if err != nil {
+			return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
 		}
-	}
 
-	id := uuid.New().String()
-	query := `INSERT INTO contexts (id, project_id, agent_id, title, content, tags) VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at`
+		result, _ := json.MarshalIndent(map[string]interface{}{
+			"project_id": projectID,
+			"broken":     broken,
+			"count":      len(broken),
+		}, "", "  ")
+		return string(result), false
+	}
 
-	var createdAt interface{}
-	// Use pq.Array for proper PostgreSQL array handling
-	err := h.db.QueryRow(query, id, projectID, agentID, title, content, pq.Array(tags)).Scan(&createdAt)
+	contextID, err := uuid.Parse(contextIDStr)
 	if err != nil {
-		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+		return `{"error": "Invalid context_id format"}`, true
 	}
 
-	// Create a preview of the content (first 200 chars)
-	preview := content
-	if len(preview) > 200 {
-		preview = preview[:200] + "..."
+	if _, err := database.GetContext(h.db, contextID); err == sql.ErrNoRows {
+		return `{"error": "Context not found"}`, true
+	} else if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
 	}
 
-	// Get agent name for better feedback
-	var agentName string
-	h.db.QueryRow(`SELECT name FROM agents WHERE id = $1`, agentID).Scan(&agentName)
-	if agentName == "" {
-		agentName = "Unknown Agent"
+	outgoing, err := database.ListContextLinks(h.db, contextID)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+	backlinks, err := database.ListContextBacklinks(h.db, contextID)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
 	}
 
-	result, _ := json.MarshalIndent(map[string]interface{}{
-		"success":     true,
-		"id":          id,
-		"title":       title,
-		"agent_id":    agentID,
-		"agent_name":  agentName,
-		"tags":        tags,
-		"preview":     preview,
-		"format":      "markdown",
-		"created_at":  createdAt,
-		"shared_with": "All agents in the project can now read this context",
-	}, "", "  ")
+	result, _ := json.MarshalIndent(models.NewContextLinks(contextID, outgoing, backlinks), "", "  ")
 	return string(result), false
 }
 
@@ -1334,7 +2490,7 @@ func (h *MCPHandler) executeGetDashboard() (string, bool) {
 	}
 
 	var projectCount, agentCount, taskCount, contextCount int
-	var pendingTasks, inProgressTasks, doneTasks, blockedTasks int
+	var pendingTasks, inProgressTasks, completedTasks, blockedTasks, failedTasks, cancelledTasks, overdueTasks int
 
 	h.db.QueryRow("SELECT COUNT(*) FROM projects").Scan(&projectCount)
 	h.db.QueryRow("SELECT COUNT(*) FROM agents").Scan(&agentCount)
@@ -1343,8 +2499,11 @@ func (h *MCPHandler) executeGetDashboard() (string, bool) {
 
 	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE status = 'pending'").Scan(&pendingTasks)
 	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE status = 'in_progress'").Scan(&inProgressTasks)
-	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE status = 'done'").Scan(&doneTasks)
+	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE status = 'completed'").Scan(&completedTasks)
 	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE status = 'blocked'").Scan(&blockedTasks)
+	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE status = 'failed'").Scan(&failedTasks)
+	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE status = 'cancelled'").Scan(&cancelledTasks)
+	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE " + database.OverdueFilter).Scan(&overdueTasks)
 
 	result, _ := json.MarshalIndent(map[string]interface{}{
 		"projects":          projectCount,
@@ -1353,8 +2512,11 @@ func (h *MCPHandler) executeGetDashboard() (string, bool) {
 		"contexts":          contextCount,
 		"pending_tasks":     pendingTasks,
 		"in_progress_tasks": inProgressTasks,
-		"done_tasks":        doneTasks,
+		"completed_tasks":   completedTasks,
 		"blocked_tasks":     blockedTasks,
+		"failed_tasks":      failedTasks,
+		"cancelled_tasks":   cancelledTasks,
+		"overdue_tasks":     overdueTasks,
 	}, "", "  ")
 	return string(result), false
 }
@@ -1557,16 +2719,17 @@ func (h *MCPHandler) executeGetMyIdentity(ctx MCPContext) (string, bool) {
 		identity["agent_id"] = ctx.AgentID
 		// Fetch agent details
 		if h.db != nil {
-			var name, role, status string
+			var name, role, accessRole, status string
 			var projectID interface{}
-			err := h.db.QueryRow("SELECT name, role, status, project_id FROM agents WHERE id = $1", ctx.AgentID).
-				Scan(&name, &role, &status, &projectID)
+			err := h.db.QueryRow("SELECT name, role, access_role, status, project_id FROM agents WHERE id = $1", ctx.AgentID).
+				Scan(&name, &role, &accessRole, &status, &projectID)
 			if err == nil {
 				identity["agent"] = map[string]interface{}{
-					"name":       name,
-					"role":       role,
-					"status":     status,
-					"project_id": projectID,
+					"name":        name,
+					"role":        role,
+					"access_role": accessRole,
+					"status":      status,
+					"project_id":  projectID,
 				}
 			}
 		}
@@ -1602,11 +2765,14 @@ func (h *MCPHandler) executeGetMyProject(ctx MCPContext) (string, bool) {
 	h.db.QueryRow("SELECT COUNT(*) FROM agents WHERE project_id = $1", ctx.ProjectID).Scan(&agentCount)
 
 	// Get tasks summary
-	var pendingTasks, inProgressTasks, doneTasks, blockedTasks int
+	var pendingTasks, inProgressTasks, completedTasks, blockedTasks, failedTasks, cancelledTasks, overdueTasks int
 	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE project_id = $1 AND status = 'pending'", ctx.ProjectID).Scan(&pendingTasks)
 	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE project_id = $1 AND status = 'in_progress'", ctx.ProjectID).Scan(&inProgressTasks)
-	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE project_id = $1 AND status = 'done'", ctx.ProjectID).Scan(&doneTasks)
+	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE project_id = $1 AND status = 'completed'", ctx.ProjectID).Scan(&completedTasks)
 	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE project_id = $1 AND status = 'blocked'", ctx.ProjectID).Scan(&blockedTasks)
+	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE project_id = $1 AND status = 'failed'", ctx.ProjectID).Scan(&failedTasks)
+	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE project_id = $1 AND status = 'cancelled'", ctx.ProjectID).Scan(&cancelledTasks)
+	h.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE project_id = $1 AND "+database.OverdueFilter, ctx.ProjectID).Scan(&overdueTasks)
 
 	result, _ := json.MarshalIndent(map[string]interface{}{
 		"id":          id,
@@ -1619,9 +2785,12 @@ func (h *MCPHandler) executeGetMyProject(ctx MCPContext) (string, bool) {
 		"tasks": map[string]int{
 			"pending":     pendingTasks,
 			"in_progress": inProgressTasks,
-			"done":        doneTasks,
 			"blocked":     blockedTasks,
-			"total":       pendingTasks + inProgressTasks + doneTasks + blockedTasks,
+			"completed":   completedTasks,
+			"failed":      failedTasks,
+			"cancelled":   cancelledTasks,
+			"total":       pendingTasks + inProgressTasks + blockedTasks + completedTasks + failedTasks + cancelledTasks,
+			"overdue":     overdueTasks,
 		},
 	}, "", "  ")
 	return string(result), false
@@ -1636,16 +2805,28 @@ func (h *MCPHandler) executeGetMyTasks(args map[string]interface{}, ctx MCPConte
 		return `{"error": "Database not connected"}`, true
 	}
 
-	query := "SELECT id, project_id, title, description, status, priority, assigned_to, created_at, updated_at FROM tasks WHERE assigned_to = $1"
+	query := `SELECT t.id, t.project_id, t.title, t.description, t.status, t.priority, t.assigned_to, t.created_at, t.updated_at, ` +
+		database.UnmetDependencyIDsColumn + ` AS blocked_by FROM tasks t WHERE t.assigned_to = $1`
 	queryArgs := []interface{}{ctx.AgentID}
 
+	readiness := ""
 	if args != nil {
 		if status, ok := args["status"].(string); ok && status != "" {
-			query += " AND status = $2"
+			query += " AND t.status = $2"
 			queryArgs = append(queryArgs, status)
 		}
+		readiness, _ = args["readiness"].(string)
+	}
+	switch readiness {
+	case "":
+	case "ready":
+		query += " AND NOT " + database.UnmetDependenciesFilter
+	case "blocked":
+		query += " AND " + database.UnmetDependenciesFilter
+	default:
+		return `{"error": "readiness must be one of: ready, blocked"}`, true
 	}
-	query += " ORDER BY created_at DESC"
+	query += " ORDER BY t.created_at DESC"
 
 	rows, err := h.db.Query(query, queryArgs...)
 	if err != nil {
@@ -1654,13 +2835,23 @@ func (h *MCPHandler) executeGetMyTasks(args map[string]interface{}, ctx MCPConte
 	defer rows.Close()
 
 	var tasks []map[string]interface{}
+	readyCount, blockedCount := 0, 0
 	for rows.Next() {
 		var id, projectID, title, status, priority string
 		var description, assignedTo interface{}
 		var createdAt, updatedAt interface{}
-		if err := rows.Scan(&id, &projectID, &title, &description, &status, &priority, &assignedTo, &createdAt, &updatedAt); err != nil {
+		var blockedBy pq.StringArray
+		if err := rows.Scan(&id, &projectID, &title, &description, &status, &priority, &assignedTo, &createdAt, &updatedAt, &blockedBy); err != nil {
 			continue
 		}
+
+		ready := len(blockedBy) == 0
+		if ready {
+			readyCount++
+		} else {
+			blockedCount++
+		}
+
 		tasks = append(tasks, map[string]interface{}{
 			"id":          id,
 			"project_id":  projectID,
@@ -1669,15 +2860,19 @@ func (h *MCPHandler) executeGetMyTasks(args map[string]interface{}, ctx MCPConte
 			"status":      status,
 			"priority":    priority,
 			"assigned_to": assignedTo,
+			"ready":       ready,
+			"blocked_by":  []string(blockedBy),
 			"created_at":  createdAt,
 			"updated_at":  updatedAt,
 		})
 	}
 
 	result, _ := json.MarshalIndent(map[string]interface{}{
-		"agent_id": ctx.AgentID,
-		"count":    len(tasks),
-		"tasks":    tasks,
+		"agent_id":      ctx.AgentID,
+		"count":         len(tasks),
+		"ready_count":   readyCount,
+		"blocked_count": blockedCount,
+		"tasks":         tasks,
 	}, "", "  ")
 	return string(result), false
 }
@@ -1751,28 +2946,158 @@ func (h *MCPHandler) executeClaimTask(args map[string]interface{}, ctx MCPContex
 		return `{"error": "task_id is required"}`, true
 	}
 
-	// Verify task exists and get its current assignment
-	var currentAssignment interface{}
+	id, err := uuid.Parse(taskID)
+	if err != nil {
+		return `{"error": "Invalid task_id format"}`, true
+	}
+	agentID, err := uuid.Parse(ctx.AgentID)
+	if err != nil {
+		return `{"error": "Invalid agent_id format"}`, true
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+	defer tx.Rollback()
+
+	// Lock the task so concurrent claims check it one after the other
 	var title string
-	err := h.db.QueryRow("SELECT title, assigned_to FROM tasks WHERE id = $1", taskID).Scan(&title, &currentAssignment)
+	err = tx.QueryRow("SELECT title FROM tasks WHERE id = $1 FOR UPDATE", id).Scan(&title)
 	if err != nil {
 		return fmt.Sprintf(`{"error": "Task not found: %s"}`, err.Error()), true
 	}
 
-	// Update task assignment and set status to in_progress
-	query := "UPDATE tasks SET assigned_to = $1, status = 'in_progress', updated_at = NOW() WHERE id = $2"
-	_, err = h.db.Exec(query, ctx.AgentID, taskID)
+	if _, errText, invalid := h.checkTaskTransition(tx, taskID, models.StatusInProgress); invalid {
+		return errText, true
+	}
+
+	// Refuse to start a task whose prerequisites are not completed
+	if errText, blocked := h.checkTaskReady(tx, taskID); blocked {
+		return errText, true
+	}
+
+	// Update task assignment, set status to in_progress and start the claim lease
+	task, err := database.ClaimTask(tx, id, agentID)
+	if errors.Is(err, database.ErrTaskAlreadyClaimed) {
+		resultJSON, _ := json.MarshalIndent(map[string]interface{}{
+			"error":    "Task is already claimed by another agent or is closed. Pick another task.",
+			"conflict": true,
+			"task_id":  taskID,
+		}, "", "  ")
+		return string(resultJSON), true
+	} else if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	if h.hub != nil {
+		h.hub.BroadcastToProject(task.ProjectID, "task_update", task)
+	}
+
+	resultJSON, _ := json.MarshalIndent(map[string]interface{}{
+		"success":          true,
+		"task_id":          taskID,
+		"title":            title,
+		"agent_id":         ctx.AgentID,
+		"status":           "in_progress",
+		"claim_expires_at": task.ClaimExpiresAt,
+		"message":          "Task claimed and status set to in_progress. Renew the claim before it expires.",
+	}, "", "  ")
+	return string(resultJSON), false
+}
+
+func (h *MCPHandler) executeNextTask(ctx MCPContext) (string, bool) {
+	if ctx.AgentID == "" {
+		return `{"error": "No agent_id configured in MCP connection URL. Add ?agent_id=UUID to the URL."}`, true
+	}
+
+	if h.db == nil {
+		return `{"error": "Database not connected"}`, true
+	}
+
+	agentID, err := uuid.Parse(ctx.AgentID)
+	if err != nil {
+		return `{"error": "Invalid agent_id format"}`, true
+	}
+
+	agent, err := database.GetAgent(h.db, agentID)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "Agent not found: %s"}`, err.Error()), true
+	}
+
+	tx, err := h.db.Begin()
 	if err != nil {
 		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
 	}
+	defer tx.Rollback()
+
+	task, err := database.ClaimNextTask(tx, agent)
+	if err == sql.ErrNoRows {
+		resultJSON, _ := json.MarshalIndent(map[string]interface{}{
+			"success": true,
+			"task":    nil,
+			"message": "No ready task available for this agent's role and team",
+		}, "", "  ")
+		return string(resultJSON), false
+	} else if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	if h.hub != nil {
+		h.hub.BroadcastToProject(task.ProjectID, "task_update", task)
+	}
 
 	resultJSON, _ := json.MarshalIndent(map[string]interface{}{
-		"success":  true,
-		"task_id":  taskID,
-		"title":    title,
-		"agent_id": ctx.AgentID,
-		"status":   "in_progress",
-		"message":  "Task claimed and status set to in_progress",
+		"success": true,
+		"task":    task,
+		"message": "Task claimed and status set to in_progress. Renew the claim before it expires.",
+	}, "", "  ")
+	return string(resultJSON), false
+}
+
+func (h *MCPHandler) executeRenewClaim(args map[string]interface{}, ctx MCPContext) (string, bool) {
+	if ctx.AgentID == "" {
+		return `{"error": "No agent_id configured in MCP connection URL. Add ?agent_id=UUID to the URL."}`, true
+	}
+
+	if h.db == nil {
+		return `{"error": "Database not connected"}`, true
+	}
+
+	taskID, ok := args["task_id"].(string)
+	if !ok {
+		return `{"error": "task_id is required"}`, true
+	}
+
+	id, err := uuid.Parse(taskID)
+	if err != nil {
+		return `{"error": "Invalid task_id format"}`, true
+	}
+	agentID, err := uuid.Parse(ctx.AgentID)
+	if err != nil {
+		return `{"error": "Invalid agent_id format"}`, true
+	}
+
+	task, err := database.RenewTaskClaim(h.db, id, agentID)
+	if err == sql.ErrNoRows {
+		return `{"error": "No active claim on this task for the current agent; it may have expired and been released"}`, true
+	} else if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+
+	resultJSON, _ := json.MarshalIndent(map[string]interface{}{
+		"success":          true,
+		"task_id":          taskID,
+		"title":            task.Title,
+		"claim_expires_at": task.ClaimExpiresAt,
+		"message":          "Claim renewed",
 	}, "", "  ")
 	return string(resultJSON), false
 }
@@ -1807,25 +3132,46 @@ func (h *MCPHandler) executeCompleteTask(args map[string]interface{}, ctx MCPCon
 		}
 	}
 
-	// Update task status to done
-	query := "UPDATE tasks SET status = 'done', updated_at = NOW() WHERE id = $1"
-	_, err = h.db.Exec(query, taskID)
+	current, errText, invalid := h.checkTaskTransition(h.db, taskID, models.StatusCompleted)
+	if invalid {
+		return errText, true
+	}
+
+	expected := expectedVersion(args)
+	if expected != nil && *expected != current.Version {
+		return versionConflict("task", taskID, *expected, current.Version), true
+	}
+
+	// Update task status to completed
+	query := "UPDATE tasks SET status = $1, claim_expires_at = NULL, updated_at = NOW() WHERE id = $2 AND status = $3 AND ($4::int IS NULL OR version = $4)"
+	result, err := h.db.Exec(query, models.StatusCompleted, taskID, current.Status, expected)
 	if err != nil {
 		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
 	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		if expected != nil {
+			return h.taskVersionConflict(current.ID, *expected), true
+		}
+		return `{"error": "Task status was changed concurrently, retry"}`, true
+	}
+	h.recordTaskChanges(current, ctx)
+
+	// Finishing the last open subtask completes the parent
+	h.completeFinishedAncestors(taskID)
 
 	resultJSON, _ := json.MarshalIndent(map[string]interface{}{
 		"success":  true,
 		"task_id":  taskID,
 		"title":    title,
 		"agent_id": ctx.AgentID,
-		"status":   "done",
+		"status":   models.StatusCompleted,
+		"version":  h.taskVersion(current.ID),
 		"message":  "Task marked as completed",
 	}, "", "  ")
 	return string(resultJSON), false
 }
 
-func (h *MCPHandler) executeReassignTask(args map[string]interface{}) (string, bool) {
+func (h *MCPHandler) executeReassignTask(args map[string]interface{}, ctx MCPContext) (string, bool) {
 	if h.db == nil {
 		return `{"error": "Database not connected"}`, true
 	}
@@ -1848,17 +3194,22 @@ func (h *MCPHandler) executeReassignTask(args map[string]interface{}) (string, b
 	}
 
 	// Verify the task exists
-	var taskTitle string
-	err = h.db.QueryRow("SELECT title FROM tasks WHERE id = $1", taskID).Scan(&taskTitle)
+	id, err := uuid.Parse(taskID)
+	if err != nil {
+		return `{"error": "Invalid task_id format"}`, true
+	}
+	previous, err := database.GetTask(h.db, id)
 	if err != nil {
 		return fmt.Sprintf(`{"error": "Task not found: %s"}`, err.Error()), true
 	}
+	taskTitle := previous.Title
 
 	// Update the task's assigned_to field
 	_, err = h.db.Exec("UPDATE tasks SET assigned_to = $1, updated_at = NOW() WHERE id = $2", agentID, taskID)
 	if err != nil {
 		return fmt.Sprintf(`{"error": "Failed to reassign task: %s"}`, err.Error()), true
 	}
+	h.recordTaskChanges(previous, ctx)
 
 	resultJSON, _ := json.MarshalIndent(map[string]interface{}{
 		"success":    true,
@@ -1870,3 +3221,220 @@ func (h *MCPHandler) executeReassignTask(args map[string]interface{}) (string, b
 	}, "", "  ")
 	return string(resultJSON), false
 }
+
+func (h *MCPHandler) executeReopenTask(args map[string]interface{}, ctx MCPContext) (string, bool) {
+	if h.db == nil {
+		return `{"error": "Database not connected"}`, true
+	}
+
+	taskID, ok := args["task_id"].(string)
+	if !ok || taskID == "" {
+		return `{"error": "task_id is required"}`, true
+	}
+
+	id, err := uuid.Parse(taskID)
+	if err != nil {
+		return `{"error": "Invalid task_id format"}`, true
+	}
+
+	current, err := database.GetTask(h.db, id)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "Task not found: %s"}`, err.Error()), true
+	}
+	if err := validator.ValidateTaskReopen(current.Status); err != nil {
+		return fmt.Sprintf(`{"error": "%s", "status": "%s"}`, err.Error(), current.Status), true
+	}
+
+	task, err := database.ScanTask(h.db.QueryRow(`
+		UPDATE tasks
+		SET status = $1, claim_expires_at = NULL, overdue_notified_at = NULL, updated_at = NOW()
+		WHERE id = $2 AND status = $3
+		RETURNING `+database.TaskColumns,
+		models.StatusPending, id, current.Status))
+	if err == sql.ErrNoRows {
+		return `{"error": "Task status was changed concurrently, retry"}`, true
+	} else if err != nil {
+		return fmt.Sprintf(`{"error": "%s"}`, err.Error()), true
+	}
+	h.recordTaskEvents(models.TaskChangeEvents(current, task, mcpActor(ctx))...)
+
+	if h.hub != nil {
+		h.hub.BroadcastToProject(task.ProjectID, "task_update", task)
+	}
+
+	resultJSON, _ := json.MarshalIndent(map[string]interface{}{
+		"success":         true,
+		"task_id":         taskID,
+		"previous_status": current.Status,
+		"status":          models.StatusPending,
+		"message":         "Task reopened",
+	}, "", "  ")
+	return string(resultJSON), false
+}
+
+// checkTaskTransition returns the task as q sees it now, or an error result
+// if the status is unknown or the task may not move to it
+func (h *MCPHandler) checkTaskTransition(q database.Querier, taskID string, to models.TaskStatus) (models.Task, string, bool) {
+	id, err := uuid.Parse(taskID)
+	if err != nil {
+		return models.Task{}, `{"error": "Invalid task_id format"}`, true
+	}
+
+	current, err := database.GetTask(q, id)
+	if err != nil {
+		return current, fmt.Sprintf(`{"error": "Task not found: %s"}`, err.Error()), true
+	}
+
+	if err := validator.ValidateTaskTransition(current.Status, to); err != nil {
+		result, _ := json.MarshalIndent(map[string]interface{}{
+			"error":   err.Error(),
+			"task_id": taskID,
+			"from":    current.Status,
+			"to":      to,
+		}, "", "  ")
+		return current, string(result), true
+	}
+	return current, "", false
+}
+
+// recordTaskChanges compares a task with its state before a tool changed it
+// and appends the differences to the task history
+func (h *MCPHandler) recordTaskChanges(before models.Task, ctx MCPContext) {
+	after, err := database.GetTask(h.db, before.ID)
+	if err != nil {
+		log.Printf("Failed to load task %s for history: %v", before.ID, err)
+		return
+	}
+	h.recordTaskEvents(models.TaskChangeEvents(before, after, mcpActor(ctx))...)
+}
+
+// recordTaskEvents appends events to the task history, logging failures
+func (h *MCPHandler) recordTaskEvents(events ...models.TaskEvent) {
+	if err := database.RecordTaskEvents(h.db, events...); err != nil {
+		log.Printf("Failed to record task history: %v", err)
+	}
+}
+
+// mcpActor returns the agent of the MCP connection, or nil if none is configured
+func mcpActor(ctx MCPContext) *uuid.UUID {
+	id, err := uuid.Parse(ctx.AgentID)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// checkTaskReady returns an error result if the task still waits on
+// unfinished prerequisites. Call it in the transaction that moves the task to
+// in_progress, which then holds the locks on the task and its prerequisites.
+func (h *MCPHandler) checkTaskReady(q database.Querier, taskID string) (string, bool) {
+	id, err := uuid.Parse(taskID)
+	if err != nil {
+		return `{"error": "Invalid task_id format"}`, true
+	}
+
+	blockedBy, err := database.LockTaskForStart(q, id)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "Failed to check task dependencies: %s"}`, err.Error()), true
+	}
+	if len(blockedBy) == 0 {
+		return "", false
+	}
+
+	result, _ := json.MarshalIndent(map[string]interface{}{
+		"error":      "Task is blocked by unfinished prerequisite tasks",
+		"task_id":    taskID,
+		"blocked_by": blockedBy,
+	}, "", "  ")
+	return string(result), true
+}
+
+// completeFinishedAncestors completes and broadcasts every ancestor of the task
+// whose subtasks are now all completed
+func (h *MCPHandler) completeFinishedAncestors(taskID string) {
+	id, err := uuid.Parse(taskID)
+	if err != nil {
+		return
+	}
+
+	parents, err := database.CompleteFinishedAncestors(h.db, id)
+	if err != nil {
+		log.Printf("Failed to complete parent of task %s: %v", taskID, err)
+	}
+	if h.hub == nil {
+		return
+	}
+	for _, parent := range parents {
+		h.hub.BroadcastToProject(parent.ProjectID, "task_update", parent)
+	}
+}
+
+// buildTaskTree nests tasks under their parents in a "subtasks" field.
+// Tasks whose parent is not part of the list are returned as roots.
+func buildTaskTree(tasks []map[string]interface{}) []map[string]interface{} {
+	byID := make(map[string]map[string]interface{}, len(tasks))
+	for _, task := range tasks {
+		task["subtasks"] = []map[string]interface{}{}
+		byID[task["id"].(string)] = task
+	}
+
+	var roots []map[string]interface{}
+	for _, task := range tasks {
+		parentID, _ := task["parent_id"].(string)
+		parent, ok := byID[parentID]
+		if !ok {
+			roots = append(roots, task)
+			continue
+		}
+		parent["subtasks"] = append(parent["subtasks"].([]map[string]interface{}), task)
+	}
+	return roots
+}
+
+// stringArgs converts a JSON array argument into a slice of strings, skipping
+// non-string items
+func stringArgs(arg interface{}) []string {
+	items, _ := arg.([]interface{})
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// expectedVersion reads the optional expected_version argument of tools that
+// update tasks or contexts. Nil means the caller accepts any current version.
+func expectedVersion(args map[string]interface{}) *int {
+	v, ok := args["expected_version"].(float64)
+	if !ok {
+		return nil
+	}
+	version := int(v)
+	return &version
+}
+
+// versionConflict reports that an entity changed since the caller read it
+func versionConflict(kind, id string, expected, current int) string {
+	result, _ := json.MarshalIndent(map[string]interface{}{
+		"error":            fmt.Sprintf("Version conflict: the %s was changed by someone else. Reload it and retry.", kind),
+		"conflict":         true,
+		kind + "_id":       id,
+		"expected_version": expected,
+		"current_version":  current,
+	}, "", "  ")
+	return string(result)
+}
+
+// taskVersionConflict reports a conflict detected by a version-guarded update
+func (h *MCPHandler) taskVersionConflict(taskID uuid.UUID, expected int) string {
+	return versionConflict("task", taskID.String(), expected, h.taskVersion(taskID))
+}
+
+// taskVersion returns the current version of a task, or 0 if it cannot be read
+func (h *MCPHandler) taskVersion(taskID uuid.UUID) int {
+	var version int
+	h.db.QueryRow(`SELECT version FROM tasks WHERE id = $1`, taskID).Scan(&version)
+	return version
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActivityGranularity is the size of the buckets of an activity timeline
+type ActivityGranularity string
+
+const (
+	ActivityHour ActivityGranularity = "hour"
+	ActivityDay  ActivityGranularity = "day"
+)
+
+// Valid reports whether g is a known granularity
+func (g ActivityGranularity) Valid() bool {
+	return g == ActivityHour || g == ActivityDay
+}
+
+// Duration returns the length of a bucket
+func (g ActivityGranularity) Duration() time.Duration {
+	if g == ActivityDay {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// Truncate returns the start of the bucket holding t
+func (g ActivityGranularity) Truncate(t time.Time) time.Time {
+	if g == ActivityDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return t.Truncate(time.Hour)
+}
+
+// ActivityBucket is the activity of an agent during one hour or day. An
+// agent counts as active from each heartbeat until the next one, for at most
+// its project's idle threshold.
+type ActivityBucket struct {
+	Start         time.Time `json:"start"`
+	Heartbeats    int       `json:"heartbeats"`
+	ActiveSeconds int       `json:"active_seconds"`
+	Uptime        float64   `json:"uptime"` // Share of the bucket the agent was active, from 0 to 1
+}
+
+// ActivityTimeline is the activity of an agent over a time range, one bucket
+// per hour or day
+type ActivityTimeline struct {
+	AgentID       uuid.UUID           `json:"agent_id"`
+	From          time.Time           `json:"from"`
+	To            time.Time           `json:"to"`
+	Granularity   ActivityGranularity `json:"granularity"`
+	Buckets       []ActivityBucket    `json:"buckets"`
+	Heartbeats    int                 `json:"heartbeats"`
+	ActiveSeconds int                 `json:"active_seconds"`
+	ActiveHours   float64             `json:"active_hours"`
+	Uptime        float64             `json:"uptime"` // Share of the whole range the agent was active
+}
+
+// BuildActivityTimeline lays out the buckets of an agent between from and
+// to, adding empty buckets for periods without activity and summing
+// buckets that start in the same period
+func BuildActivityTimeline(agentID uuid.UUID, g ActivityGranularity, from, to time.Time, buckets []ActivityBucket) ActivityTimeline {
+	timeline := ActivityTimeline{AgentID: agentID, From: from, To: to, Granularity: g, Buckets: []ActivityBucket{}}
+
+	index := map[int64]int{}
+	for start := g.Truncate(from); start.Before(to); start = start.Add(g.Duration()) {
+		index[start.Unix()] = len(timeline.Buckets)
+		timeline.Buckets = append(timeline.Buckets, ActivityBucket{Start: start})
+	}
+
+	for _, b := range buckets {
+		i, ok := index[g.Truncate(b.Start).Unix()]
+		if !ok {
+			continue
+		}
+		timeline.Buckets[i].Heartbeats += b.Heartbeats
+		timeline.Buckets[i].ActiveSeconds += b.ActiveSeconds
+		timeline.Heartbeats += b.Heartbeats
+		timeline.ActiveSeconds += b.ActiveSeconds
+	}
+
+	for i := range timeline.Buckets {
+		timeline.Buckets[i].Uptime = uptime(timeline.Buckets[i].ActiveSeconds, g.Duration())
+	}
+	timeline.ActiveHours = float64(timeline.ActiveSeconds) / 3600
+	timeline.Uptime = uptime(timeline.ActiveSeconds, to.Sub(from))
+	return timeline
+}
+
+// uptime returns the share of span covered by activeSeconds, at most 1
+func uptime(activeSeconds int, span time.Duration) float64 {
+	if span <= 0 {
+		return 0
+	}
+	share := float64(activeSeconds) / span.Seconds()
+	if share > 1 {
+		return 1
+	}
+	return share
+}
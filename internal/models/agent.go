@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // AgentRole represents the role of an agent
@@ -14,22 +15,54 @@ const (
 	RoleFrontend AgentRole = "frontend"
 )
 
+// AccessRole decides what an agent may do in its project, independently of
+// the kind of work its Role describes
+type AccessRole string
+
+const (
+	AccessCoordinator AccessRole = "coordinator" // Manages tasks and the project
+	AccessWorker      AccessRole = "worker"      // Claims and completes its own tasks
+	AccessObserver    AccessRole = "observer"    // Read-only, e.g. dashboards
+)
+
+// Valid reports whether r is a known access role
+func (r AccessRole) Valid() bool {
+	return r == AccessCoordinator || r == AccessWorker || r == AccessObserver
+}
+
 type Agent struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	ProjectID uuid.UUID `json:"project_id" db:"project_id"`
-	Name      string    `json:"name" db:"name"`
-	Role      AgentRole `json:"role" db:"role"`
-	Team      string    `json:"team" db:"team"`
-	Status    string    `json:"status" db:"status"`
-	LastSeen  time.Time `json:"last_seen" db:"last_seen"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID         uuid.UUID      `json:"id" db:"id"`
+	ProjectID  uuid.UUID      `json:"project_id" db:"project_id"`
+	Name       string         `json:"name" db:"name"`
+	Role       AgentRole      `json:"role" db:"role"`
+	AccessRole AccessRole     `json:"access_role" db:"access_role"`
+	Team       string         `json:"team" db:"team"`
+	Skills     pq.StringArray `json:"skills" db:"skills"`     // Languages, services and tools the agent works with
+	Capacity   int            `json:"capacity" db:"capacity"` // Most open tasks the agent takes on at once, 0 for no limit
+	Status     string         `json:"status" db:"status"`
+	LastSeen   time.Time      `json:"last_seen" db:"last_seen"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
 }
 
 type CreateAgentRequest struct {
-	ProjectID uuid.UUID `json:"project_id"`
-	Name      string    `json:"name"`
-	Role      AgentRole `json:"role"`
-	Team      string    `json:"team"`
+	ProjectID  uuid.UUID  `json:"project_id"`
+	Name       string     `json:"name"`
+	Role       AgentRole  `json:"role"`
+	Team       string     `json:"team"`
+	AccessRole AccessRole `json:"access_role,omitempty"` // Defaults to worker
+	Skills     []string   `json:"skills"`
+	Capacity   int        `json:"capacity"`
+}
+
+// UpdateAgentProfileRequest changes an agent's skills or capacity. Fields
+// left out keep their value.
+type UpdateAgentProfileRequest struct {
+	Skills   *[]string `json:"skills"`
+	Capacity *int      `json:"capacity"`
+}
+
+type UpdateAgentAccessRequest struct {
+	AccessRole AccessRole `json:"access_role"`
 }
 
 type UpdateAgentStatusRequest struct {
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey authenticates requests as an agent. The key itself is only shown
+// when it is issued; Prefix identifies it afterwards.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	AgentID    uuid.UUID  `json:"agent_id" db:"agent_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"` // Describes where the key is used, optional
+}
+
+// IssuedAPIKey is a newly created key together with its secret, which the
+// server does not store
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProjectBundle is a portable snapshot of a project used to move it between
+// environments. IDs are those of the exporting server and are replaced when
+// the bundle is imported.
+type ProjectBundle struct {
+	FormatVersion    int                     `json:"format_version"`
+	ExportedAt       time.Time               `json:"exported_at"`
+	Project          Project                 `json:"project"`
+	Agents           []Agent                 `json:"agents"`
+	CustomFields     []CustomFieldDefinition `json:"custom_fields"`
+	Milestones       []Milestone             `json:"milestones"`
+	Tasks            []Task                  `json:"tasks"`
+	TaskDependencies []TaskDependency        `json:"task_dependencies"`
+	TaskComments     []TaskComment           `json:"task_comments"`
+	Contexts         []Context               `json:"contexts"`
+	Standups         []DailyStandup          `json:"standups"`
+	Heartbeats       []HeartbeatSummary      `json:"heartbeats"` // Informational, not imported
+}
+
+// HeartbeatSummary condenses the heartbeats of one agent
+type HeartbeatSummary struct {
+	AgentID    uuid.UUID  `json:"agent_id"`
+	Count      int        `json:"count"`
+	FirstAt    *time.Time `json:"first_at"`
+	LastAt     *time.Time `json:"last_at"`
+	LastStatus string     `json:"last_status"`
+}
+
+// ProjectImportResult describes the project created from a bundle. IDMap maps
+// every ID of the bundle to the ID it was given on import.
+type ProjectImportResult struct {
+	Project      Project                 `json:"project"`
+	Agents       int                     `json:"agents"`
+	Milestones   int                     `json:"milestones"`
+	Tasks        int                     `json:"tasks"`
+	TaskComments int                     `json:"task_comments"`
+	Contexts     int                     `json:"contexts"`
+	Standups     int                     `json:"standups"`
+	IDMap        map[uuid.UUID]uuid.UUID `json:"id_map"`
+}
@@ -5,18 +5,29 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/techbuzzz/agent-shaker/internal/markdown"
 )
 
 type Context struct {
-	ID        uuid.UUID      `json:"id" db:"id"`
-	ProjectID uuid.UUID      `json:"project_id" db:"project_id"`
-	AgentID   uuid.UUID      `json:"agent_id" db:"agent_id"`
-	TaskID    *uuid.UUID     `json:"task_id" db:"task_id"`
-	Title     string         `json:"title" db:"title"`
-	Content   string         `json:"content" db:"content"`
-	Tags      pq.StringArray `json:"tags" db:"tags"`
-	CreatedAt time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at" db:"updated_at"`
+	ID          uuid.UUID            `json:"id" db:"id"`
+	ProjectID   uuid.UUID            `json:"project_id" db:"project_id"`
+	AgentID     uuid.UUID            `json:"agent_id" db:"agent_id"`
+	TaskID      *uuid.UUID           `json:"task_id" db:"task_id"`
+	Title       string               `json:"title" db:"title"`
+	Content     string               `json:"content" db:"content"`
+	Tags        pq.StringArray       `json:"tags" db:"tags"`
+	FrontMatter markdown.FrontMatter `json:"front_matter" db:"front_matter"` // Parsed from the YAML block at the top of the content
+	SourcePath  *string              `json:"source_path" db:"source_path"`   // File the context was imported from
+	Version     int                  `json:"version" db:"version"`           // Bumped on every update, sent as the ETag
+	CreatedAt   time.Time            `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at" db:"updated_at"`
+}
+
+// RenderedContext is a context returned with ?format=html: its markdown
+// content rendered to sanitized HTML, with a table of contents and code blocks
+type RenderedContext struct {
+	Context
+	markdown.Document
 }
 
 type CreateContextRequest struct {
@@ -29,8 +40,48 @@ type CreateContextRequest struct {
 }
 
 type UpdateContextRequest struct {
+	AgentID *uuid.UUID `json:"agent_id"` // Author of the new revision, defaults to the acting agent
 	TaskID  *uuid.UUID `json:"task_id"`
 	Title   string     `json:"title"`
 	Content string     `json:"content"`
 	Tags    []string   `json:"tags"`
 }
+
+// ContextImportAction is what an import did with one file
+type ContextImportAction string
+
+const (
+	ContextImportCreated ContextImportAction = "created"
+	ContextImportUpdated ContextImportAction = "updated"
+	ContextImportSkipped ContextImportAction = "skipped"
+)
+
+// ContextImportFile reports the outcome of importing one file of an archive
+type ContextImportFile struct {
+	Path      string              `json:"path"`
+	Action    ContextImportAction `json:"action"`
+	ContextID *uuid.UUID          `json:"context_id,omitempty"`
+	Reason    string              `json:"reason,omitempty"` // Why the file was skipped
+}
+
+// ContextImportResult summarizes a bulk import of markdown files into contexts
+type ContextImportResult struct {
+	ProjectID uuid.UUID           `json:"project_id"`
+	Created   int                 `json:"created"`
+	Updated   int                 `json:"updated"`
+	Skipped   int                 `json:"skipped"`
+	Files     []ContextImportFile `json:"files"`
+}
+
+// Add records the outcome of one file and updates the counts
+func (r *ContextImportResult) Add(file ContextImportFile) {
+	switch file.Action {
+	case ContextImportCreated:
+		r.Created++
+	case ContextImportUpdated:
+		r.Updated++
+	default:
+		r.Skipped++
+	}
+	r.Files = append(r.Files, file)
+}
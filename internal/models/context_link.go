@@ -0,0 +1,96 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ContextLinkKind is how a context refers to another one
+type ContextLinkKind string
+
+const (
+	ContextLinkTitle ContextLinkKind = "title" // [[Context Title]]
+	ContextLinkID    ContextLinkKind = "id"    // agent-shaker://contexts/{id}
+)
+
+// ContextLinkRef is a link parsed from the content of a context
+type ContextLinkRef struct {
+	Kind   ContextLinkKind `json:"kind"`
+	Target string          `json:"target"` // Title or context ID as written
+}
+
+// ContextLink is a link between two contexts, resolved against the current
+// contexts of the project. Links to a title or ID that does not exist are broken.
+type ContextLink struct {
+	SourceID    uuid.UUID       `json:"source_id"`
+	SourceTitle string          `json:"source_title"`
+	Kind        ContextLinkKind `json:"kind"`
+	Target      string          `json:"target"`
+	TargetID    *uuid.UUID      `json:"target_id"`
+	TargetTitle string          `json:"target_title,omitempty"`
+	Broken      bool            `json:"broken"`
+}
+
+// ContextLinks is the link graph around a single context
+type ContextLinks struct {
+	ContextID uuid.UUID     `json:"context_id"`
+	Outgoing  []ContextLink `json:"outgoing"`
+	Backlinks []ContextLink `json:"backlinks"`
+	Broken    []ContextLink `json:"broken"`
+}
+
+// NewContextLinks builds the link graph of a context, listing its broken
+// outgoing links separately
+func NewContextLinks(contextID uuid.UUID, outgoing, backlinks []ContextLink) ContextLinks {
+	links := ContextLinks{
+		ContextID: contextID,
+		Outgoing:  outgoing,
+		Backlinks: backlinks,
+		Broken:    []ContextLink{},
+	}
+	for _, link := range outgoing {
+		if link.Broken {
+			links.Broken = append(links.Broken, link)
+		}
+	}
+	return links
+}
+
+var (
+	titleLinkPattern = regexp.MustCompile(`\[\[([^\[\]|\n]+)(?:\|[^\[\]\n]*)?\]\]`)
+	idLinkPattern    = regexp.MustCompile(`agent-shaker://contexts/([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})`)
+)
+
+// ParseContextLinks extracts [[Title]] (or [[Title|label]]) and
+// agent-shaker://contexts/{id} links from markdown content. Links inside
+// fenced code blocks are ignored. Each link is returned once, in order.
+func ParseContextLinks(content string) []ContextLinkRef {
+	refs := []ContextLinkRef{}
+	seen := make(map[ContextLinkRef]bool)
+	add := func(ref ContextLinkRef) {
+		if ref.Target != "" && !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	inFence := false
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		for _, m := range titleLinkPattern.FindAllStringSubmatch(line, -1) {
+			add(ContextLinkRef{Kind: ContextLinkTitle, Target: strings.TrimSpace(m[1])})
+		}
+		for _, m := range idLinkPattern.FindAllStringSubmatch(line, -1) {
+			add(ContextLinkRef{Kind: ContextLinkID, Target: strings.ToLower(m[1])})
+		}
+	}
+	return refs
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ContextRevision is a snapshot of a context taken each time it is created,
+// updated or restored. Revisions are numbered from 1 per context.
+type ContextRevision struct {
+	ID        uuid.UUID      `json:"id" db:"id"`
+	ContextID uuid.UUID      `json:"context_id" db:"context_id"`
+	Revision  int            `json:"revision" db:"revision"`
+	AuthorID  *uuid.UUID     `json:"author_id" db:"author_id"`
+	TaskID    *uuid.UUID     `json:"task_id" db:"task_id"`
+	Title     string         `json:"title" db:"title"`
+	Content   string         `json:"content" db:"content"`
+	Tags      pq.StringArray `json:"tags" db:"tags"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// ContextDiff is a unified diff of the content between two revisions of a context
+type ContextDiff struct {
+	ContextID    uuid.UUID `json:"context_id"`
+	FromRevision int       `json:"from_revision"`
+	ToRevision   int       `json:"to_revision"`
+	Diff         string    `json:"diff"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// CustomFieldType is the type of value a custom field holds
+type CustomFieldType string
+
+const (
+	FieldTypeString CustomFieldType = "string"
+	FieldTypeNumber CustomFieldType = "number"
+	FieldTypeEnum   CustomFieldType = "enum"
+	FieldTypeDate   CustomFieldType = "date" // YYYY-MM-DD
+)
+
+// CustomFieldTypes lists every supported custom field type
+var CustomFieldTypes = []CustomFieldType{FieldTypeString, FieldTypeNumber, FieldTypeEnum, FieldTypeDate}
+
+// CustomFieldDateLayout is the format of date custom field values
+const CustomFieldDateLayout = "2006-01-02"
+
+// CustomFieldDefinition is one field of a project's task schema. Options
+// lists the allowed values of an enum field.
+type CustomFieldDefinition struct {
+	ProjectID uuid.UUID       `json:"project_id" db:"project_id"`
+	Name      string          `json:"name" db:"name"`
+	Type      CustomFieldType `json:"type" db:"field_type"`
+	Options   pq.StringArray  `json:"options" db:"options"`
+	Required  bool            `json:"required" db:"required"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
+type CreateCustomFieldRequest struct {
+	Name     string          `json:"name"`
+	Type     CustomFieldType `json:"type"`
+	Options  []string        `json:"options"`
+	Required bool            `json:"required"`
+}
+
+// CustomFields holds a task's custom field values keyed by field name
+type CustomFields map[string]interface{}
+
+type UpdateTaskLabelsRequest struct {
+	Labels []string `json:"labels"`
+}
+
+type UpdateTaskCustomFieldsRequest struct {
+	CustomFields CustomFields `json:"custom_fields"`
+}
+
+// NormalizeLabels lowercases and trims labels and drops empty and duplicate
+// ones, keeping the first occurrence order
+func NormalizeLabels(labels []string) []string {
+	seen := make(map[string]bool, len(labels))
+	normalized := []string{}
+	for _, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		if label == "" || seen[label] {
+			continue
+		}
+		seen[label] = true
+		normalized = append(normalized, label)
+	}
+	return normalized
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Milestone groups the tasks of a project that must be done by a target date
+type Milestone struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	ProjectID   uuid.UUID  `json:"project_id" db:"project_id"`
+	Name        string     `json:"name" db:"name"`
+	Description string     `json:"description" db:"description"`
+	TargetDate  *time.Time `json:"target_date" db:"target_date"` // Date only, the milestone is due by the end of that day
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type CreateMilestoneRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	TargetDate  string `json:"target_date"` // YYYY-MM-DD, optional
+}
+
+// UpdateMilestoneRequest changes a milestone. Fields left out keep their
+// value; an empty target_date clears it.
+type UpdateMilestoneRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	TargetDate  *string `json:"target_date"`
+}
+
+// UpdateTaskMilestoneRequest attaches a task to a milestone, or detaches it when null
+type UpdateTaskMilestoneRequest struct {
+	MilestoneID *uuid.UUID `json:"milestone_id"`
+}
+
+// MilestoneDateLayout is the format of milestone target dates
+const MilestoneDateLayout = "2006-01-02"
+
+// ParseMilestoneDate parses a target date in MilestoneDateLayout. An empty
+// value means no target date and returns nil.
+func ParseMilestoneDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse(MilestoneDateLayout, value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+// MilestoneStatus summarizes where a milestone stands against its target date
+type MilestoneStatus string
+
+const (
+	MilestoneOpen      MilestoneStatus = "open"      // Work remains and the target date has not passed
+	MilestoneLate      MilestoneStatus = "late"      // Work remains after the target date
+	MilestoneCompleted MilestoneStatus = "completed" // Every task is completed or cancelled
+)
+
+// MilestoneProgress reports how far a milestone's tasks are. Cancelled tasks
+// do not count towards the work of the milestone. SlipDays is how many days
+// past the target date the milestone was completed, or is still open.
+type MilestoneProgress struct {
+	Milestone
+	TotalTasks      int             `json:"total_tasks"`
+	CompletedTasks  int             `json:"completed_tasks"`
+	CancelledTasks  int             `json:"cancelled_tasks"`
+	RemainingTasks  int             `json:"remaining_tasks"`
+	PercentComplete int             `json:"percent_complete"`
+	CompletedAt     *time.Time      `json:"completed_at"` // When the last task was closed, once the milestone is completed
+	SlipDays        int             `json:"slip_days"`
+	Status          MilestoneStatus `json:"status"`
+}
+
+// Evaluate derives the remaining work, completion, status and slip of the
+// milestone from its task counts. lastClosedAt is when its most recently
+// closed task was closed.
+func (p *MilestoneProgress) Evaluate(lastClosedAt *time.Time, now time.Time) {
+	work := p.TotalTasks - p.CancelledTasks
+	p.RemainingTasks = work - p.CompletedTasks
+	p.PercentComplete = 0
+	if work > 0 {
+		p.PercentComplete = p.CompletedTasks * 100 / work
+	}
+
+	p.CompletedAt = nil
+	end := now
+	if p.TotalTasks > 0 && p.RemainingTasks == 0 {
+		p.Status = MilestoneCompleted
+		p.CompletedAt = lastClosedAt
+		if lastClosedAt != nil {
+			end = *lastClosedAt
+		}
+	} else {
+		p.Status = MilestoneOpen
+	}
+
+	p.SlipDays = 0
+	if p.TargetDate != nil && end.After(*p.TargetDate) {
+		p.SlipDays = int(end.Sub(*p.TargetDate) / (24 * time.Hour))
+	}
+	if p.Status == MilestoneOpen && p.SlipDays > 0 {
+		p.Status = MilestoneLate
+	}
+}
@@ -169,3 +169,403 @@ func TestContextModel(t *testing.T) {
 		t.Errorf("Expected TaskID to match task UUID, got '%s'", ctx.TaskID.String())
 	}
 }
+
+func TestTaskStatusTransitions(t *testing.T) {
+	tests := []struct {
+		from TaskStatus
+		to   TaskStatus
+		want bool
+	}{
+		{StatusPending, StatusInProgress, true},
+		{StatusPending, StatusCompleted, true},
+		{StatusPending, StatusFailed, false},
+		{StatusInProgress, StatusCompleted, true},
+		{StatusInProgress, StatusFailed, true},
+		{StatusInProgress, StatusPending, true},
+		{StatusBlocked, StatusInProgress, true},
+		{StatusBlocked, StatusCompleted, false},
+		{StatusCompleted, StatusCompleted, true},
+		{StatusCompleted, StatusPending, false},
+		{StatusCompleted, StatusInProgress, false},
+		{StatusFailed, StatusInProgress, false},
+		{StatusCancelled, StatusPending, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%s -> %s: expected %v, got %v", tt.from, tt.to, tt.want, got)
+		}
+	}
+}
+
+func TestTaskStatusValues(t *testing.T) {
+	for _, status := range TaskStatuses {
+		if !status.IsValid() {
+			t.Errorf("Expected %s to be valid", status)
+		}
+	}
+
+	for _, status := range []TaskStatus{"done", "canceled", ""} {
+		if status.IsValid() {
+			t.Errorf("Expected %q to be invalid", status)
+		}
+	}
+
+	if !StatusCompleted.IsTerminal() || !StatusFailed.IsTerminal() || !StatusCancelled.IsTerminal() {
+		t.Error("Expected completed, failed and cancelled to be terminal")
+	}
+	if StatusPending.IsTerminal() || StatusInProgress.IsTerminal() || StatusBlocked.IsTerminal() {
+		t.Error("Expected pending, in_progress and blocked not to be terminal")
+	}
+}
+
+func TestTaskChangeEvents(t *testing.T) {
+	actor := uuid.New()
+	assignee := uuid.New()
+	before := Task{ID: uuid.New(), ProjectID: uuid.New(), Status: StatusPending}
+	after := before
+	after.Status = StatusInProgress
+	after.AssignedTo = &assignee
+
+	events := TaskChangeEvents(before, after, &actor)
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	if events[0].EventType != TaskEventStatusChanged || *events[0].OldValue != "pending" || *events[0].NewValue != "in_progress" {
+		t.Errorf("Unexpected status event: %+v", events[0])
+	}
+
+	if events[1].EventType != TaskEventReassigned || events[1].OldValue != nil || *events[1].NewValue != assignee.String() {
+		t.Errorf("Unexpected reassign event: %+v", events[1])
+	}
+
+	for _, e := range events {
+		if e.TaskID != before.ID || e.ProjectID != before.ProjectID || *e.ActorID != actor {
+			t.Errorf("Event not attributed to task and actor: %+v", e)
+		}
+	}
+
+	if events := TaskChangeEvents(after, after, nil); len(events) != 0 {
+		t.Errorf("Expected no events for unchanged task, got %d", len(events))
+	}
+}
+
+func TestThreadTaskComments(t *testing.T) {
+	root := TaskComment{ID: uuid.New(), Body: "Root"}
+	reply := TaskComment{ID: uuid.New(), ReplyToID: &root.ID, Body: "Reply"}
+	nested := TaskComment{ID: uuid.New(), ReplyToID: &reply.ID, Body: "Nested reply"}
+	missing := uuid.New()
+	orphan := TaskComment{ID: uuid.New(), ReplyToID: &missing, Body: "Orphan"}
+
+	threads := ThreadTaskComments([]TaskComment{root, reply, orphan, nested})
+	if len(threads) != 2 {
+		t.Fatalf("Expected 2 threads, got %d", len(threads))
+	}
+
+	if threads[0].ID != root.ID || threads[1].ID != orphan.ID {
+		t.Errorf("Expected root and orphan at top level in order, got %s and %s", threads[0].Body, threads[1].Body)
+	}
+
+	if len(threads[0].Replies) != 1 || threads[0].Replies[0].ID != reply.ID {
+		t.Fatalf("Expected reply nested under root, got %+v", threads[0].Replies)
+	}
+
+	if len(threads[0].Replies[0].Replies) != 1 || threads[0].Replies[0].Replies[0].ID != nested.ID {
+		t.Errorf("Expected nested reply under reply, got %+v", threads[0].Replies[0].Replies)
+	}
+}
+
+func TestTaskIsOverdue(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		task Task
+		want bool
+	}{
+		{name: "no due date", task: Task{Status: StatusPending}, want: false},
+		{name: "due in the future", task: Task{Status: StatusInProgress, DueAt: &future}, want: false},
+		{name: "past due and open", task: Task{Status: StatusBlocked, DueAt: &past}, want: true},
+		{name: "past due but completed", task: Task{Status: StatusCompleted, DueAt: &past}, want: false},
+		{name: "past due but cancelled", task: Task{Status: StatusCancelled, DueAt: &past}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.task.IsOverdue(now); got != tt.want {
+				t.Errorf("IsOverdue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSLAHoursDueAt(t *testing.T) {
+	created := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	sla := SLAHours{"high": 24}
+
+	due := sla.DueAt("high", created)
+	if due == nil || !due.Equal(created.Add(24*time.Hour)) {
+		t.Errorf("Expected high priority task due a day later, got %v", due)
+	}
+
+	if due := sla.DueAt("low", created); due != nil {
+		t.Errorf("Expected no due date without an SLA for the priority, got %v", due)
+	}
+}
+
+func TestNormalizeLabels(t *testing.T) {
+	got := NormalizeLabels([]string{" Backend", "auth", "", "backend", "AUTH ", "api"})
+	want := []string{"backend", "auth", "api"}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestParseContextLinks(t *testing.T) {
+	content := "See [[API Design]] and [[ Auth Flow | the auth doc]].\n" +
+		"Details in agent-shaker://contexts/3F2504E0-4F89-11D3-9A0C-0305E82C3301 and [[API Design]] again.\n" +
+		"```\n[[Not A Link]]\n```\n" +
+		"Empty [[]] and [[ ]] are ignored."
+
+	got := ParseContextLinks(content)
+	want := []ContextLinkRef{
+		{Kind: ContextLinkTitle, Target: "API Design"},
+		{Kind: ContextLinkTitle, Target: "Auth Flow"},
+		{Kind: ContextLinkID, Target: "3f2504e0-4f89-11d3-9a0c-0305e82c3301"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want[i], got[i])
+		}
+	}
+}
+
+func TestProjectSettings(t *testing.T) {
+	var settings ProjectSettings
+	if got := settings.TaskPriority(""); got != "medium" {
+		t.Errorf("Expected medium without a default, got %s", got)
+	}
+	settings.DefaultPriority = "high"
+	if got := settings.TaskPriority(""); got != "high" {
+		t.Errorf("Expected the default priority, got %s", got)
+	}
+	if got := settings.TaskPriority("low"); got != "low" {
+		t.Errorf("Expected the requested priority, got %s", got)
+	}
+
+	if !settings.AllowsRole("anything") {
+		t.Error("Expected every role to be allowed without a list")
+	}
+	settings.AllowedAgentRoles = []AgentRole{RoleBackend}
+	if !settings.AllowsRole(RoleBackend) || settings.AllowsRole(RoleFrontend) {
+		t.Error("Expected only the listed roles to be allowed")
+	}
+}
+
+func TestWIPLimitsReached(t *testing.T) {
+	tests := []struct {
+		limits         WIPLimits
+		agent, project int
+		want           bool
+	}{
+		{WIPLimits{}, 50, 500, false},
+		{WIPLimits{PerAgent: 2}, 1, 10, false},
+		{WIPLimits{PerAgent: 2}, 2, 10, true},
+		{WIPLimits{Project: 5}, 0, 4, false},
+		{WIPLimits{Project: 5}, 0, 5, true},
+	}
+	for _, tt := range tests {
+		if got := tt.limits.Reached(tt.agent, tt.project); got != tt.want {
+			t.Errorf("%+v.Reached(%d, %d) = %v, want %v", tt.limits, tt.agent, tt.project, got, tt.want)
+		}
+	}
+}
+
+func TestMilestoneProgressEvaluate(t *testing.T) {
+	target := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	before := target.Add(-48 * time.Hour)
+	after := target.Add(3*24*time.Hour + time.Hour)
+
+	tests := []struct {
+		name                     string
+		total, completed, cancel int
+		lastClosedAt             *time.Time
+		now                      time.Time
+		wantStatus               MilestoneStatus
+		wantRemaining, wantPct   int
+		wantSlip                 int
+	}{
+		{"empty", 0, 0, 0, nil, before, MilestoneOpen, 0, 0, 0},
+		{"on track", 4, 1, 0, &before, before, MilestoneOpen, 3, 25, 0},
+		{"late", 4, 1, 1, &before, after, MilestoneLate, 2, 33, 3},
+		{"completed on time", 3, 2, 1, &before, after, MilestoneCompleted, 0, 100, 0},
+		{"completed late", 2, 2, 0, &after, after.Add(240 * time.Hour), MilestoneCompleted, 0, 100, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := MilestoneProgress{
+				Milestone:      Milestone{TargetDate: &target},
+				TotalTasks:     tt.total,
+				CompletedTasks: tt.completed,
+				CancelledTasks: tt.cancel,
+			}
+			p.Evaluate(tt.lastClosedAt, tt.now)
+			if p.Status != tt.wantStatus || p.RemainingTasks != tt.wantRemaining || p.PercentComplete != tt.wantPct || p.SlipDays != tt.wantSlip {
+				t.Errorf("Evaluate() = status %s, remaining %d, %d%%, slip %d; want %s, %d, %d%%, %d",
+					p.Status, p.RemainingTasks, p.PercentComplete, p.SlipDays, tt.wantStatus, tt.wantRemaining, tt.wantPct, tt.wantSlip)
+			}
+			if (p.CompletedAt != nil) != (tt.wantStatus == MilestoneCompleted) {
+				t.Errorf("Evaluate() completed_at = %v for status %s", p.CompletedAt, p.Status)
+			}
+		})
+	}
+
+	open := MilestoneProgress{TotalTasks: 1}
+	open.Evaluate(nil, after)
+	if open.Status != MilestoneOpen || open.SlipDays != 0 {
+		t.Errorf("Expected a milestone without a target date never to slip, got %s with %d days", open.Status, open.SlipDays)
+	}
+}
+
+func TestPickAssignee(t *testing.T) {
+	projectID := uuid.New()
+	now := time.Now()
+	agent := func(name string, skills ...string) Agent {
+		return Agent{ID: uuid.New(), ProjectID: projectID, Name: name, AccessRole: AccessWorker, Skills: skills, Status: "active", LastSeen: now}
+	}
+
+	goDev := agent("go", "go")
+	fullStack := agent("full stack", "go", "postgres", "vue")
+	busy := agent("busy", "go", "postgres")
+	busy.Capacity = 2
+	offline := agent("offline", "go", "postgres")
+	offline.Status = "offline"
+	observer := agent("observer", "go", "postgres")
+	observer.AccessRole = AccessObserver
+	candidates := []AssignmentCandidate{{goDev, 0}, {fullStack, 3}, {busy, 2}, {offline, 0}, {observer, 0}}
+
+	task := Task{ProjectID: projectID, RequiredSkills: []string{"Go", "postgres"}}
+	if got, ok := PickAssignee(task, candidates); !ok || got.ID != fullStack.ID {
+		t.Errorf("Expected the best skill match, got %s", got.Name)
+	}
+
+	task.RequiredSkills = []string{"go"}
+	if got, ok := PickAssignee(task, candidates); !ok || got.ID != goDev.ID {
+		t.Errorf("Expected the least loaded of equal matches, got %s", got.Name)
+	}
+
+	task.RequiredSkills = nil
+	idle := agent("idle")
+	idle.Status = "idle"
+	if got, ok := PickAssignee(task, []AssignmentCandidate{{goDev, 0}, {idle, 0}}); !ok || got.ID != idle.ID {
+		t.Errorf("Expected idle agents to be preferred, got %s", got.Name)
+	}
+
+	task.Team = "payments"
+	if got, ok := PickAssignee(task, candidates); ok {
+		t.Errorf("Expected no agent of the payments team, got %s", got.Name)
+	}
+
+	task = Task{ProjectID: projectID, RequiredSkills: []string{"rust"}}
+	if got, ok := PickAssignee(task, candidates); ok {
+		t.Errorf("Expected no agent without a required skill, got %s", got.Name)
+	}
+}
+
+func TestStatusAfterHeartbeat(t *testing.T) {
+	tests := []struct {
+		current, reported, want string
+	}{
+		{current: PresenceOffline, reported: "", want: PresenceActive},
+		{current: PresenceIdle, reported: "ping", want: PresenceActive},
+		{current: "blocked", reported: "", want: "blocked"},
+		{current: PresenceActive, reported: "working", want: "working"},
+		{current: "working", reported: PresenceIdle, want: PresenceIdle},
+	}
+	for _, tt := range tests {
+		if got := StatusAfterHeartbeat(tt.current, tt.reported); got != tt.want {
+			t.Errorf("StatusAfterHeartbeat(%q, %q) = %q, want %q", tt.current, tt.reported, got, tt.want)
+		}
+	}
+}
+
+func TestPresenceThresholds(t *testing.T) {
+	var defaults PresenceThresholds
+	if defaults.IdleAfter() != DefaultIdleAfterSeconds*time.Second || defaults.OfflineAfter() != DefaultOfflineAfterSeconds*time.Second {
+		t.Errorf("Expected the default thresholds, got %s and %s", defaults.IdleAfter(), defaults.OfflineAfter())
+	}
+	custom := PresenceThresholds{IdleAfterSeconds: 60, OfflineAfterSeconds: 120}
+	if custom.IdleAfter() != time.Minute || custom.OfflineAfter() != 2*time.Minute {
+		t.Errorf("Expected the configured thresholds, got %s and %s", custom.IdleAfter(), custom.OfflineAfter())
+	}
+}
+
+func TestBuildActivityTimeline(t *testing.T) {
+	agentID := uuid.New()
+	from := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	buckets := []ActivityBucket{
+		{Start: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC), Heartbeats: 12, ActiveSeconds: 1800},
+		{Start: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC), Heartbeats: 2, ActiveSeconds: 600},
+		{Start: time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC), Heartbeats: 5, ActiveSeconds: 900},
+	}
+
+	timeline := BuildActivityTimeline(agentID, ActivityHour, from, to, buckets)
+	if len(timeline.Buckets) != 3 || !timeline.Buckets[0].Start.Equal(from.Truncate(time.Hour)) {
+		t.Fatalf("Expected 3 hourly buckets from 09:00, got %+v", timeline.Buckets)
+	}
+	if b := timeline.Buckets[1]; b.Heartbeats != 14 || b.ActiveSeconds != 2400 || b.Uptime != 2400.0/3600 {
+		t.Errorf("Expected buckets of the same hour to be summed, got %+v", b)
+	}
+	if timeline.Buckets[0].Heartbeats != 0 || timeline.Buckets[2].Heartbeats != 0 {
+		t.Errorf("Expected empty buckets around the activity, got %+v", timeline.Buckets)
+	}
+	if timeline.Heartbeats != 14 || timeline.ActiveSeconds != 2400 {
+		t.Errorf("Expected buckets outside the range to be left out, got %d heartbeats and %d seconds", timeline.Heartbeats, timeline.ActiveSeconds)
+	}
+	if timeline.ActiveHours != 2400.0/3600 || timeline.Uptime != 2400.0/9000 {
+		t.Errorf("Unexpected totals %.3f hours, uptime %.3f", timeline.ActiveHours, timeline.Uptime)
+	}
+
+	daily := BuildActivityTimeline(agentID, ActivityDay, from, to.Add(48*time.Hour), buckets)
+	if len(daily.Buckets) != 3 || daily.Buckets[0].ActiveSeconds != 3300 {
+		t.Errorf("Expected the day's buckets to be summed, got %+v", daily.Buckets)
+	}
+}
+
+func TestNewTaskProgress(t *testing.T) {
+	taskID := uuid.New()
+	tests := []struct {
+		name                           string
+		subtasks, completed, cancelled int
+		wantTotal                      int
+		wantPercent                    float64
+	}{
+		{name: "no subtasks", wantTotal: 0, wantPercent: 0},
+		{name: "partly done", subtasks: 4, completed: 3, wantTotal: 4, wantPercent: 75},
+		{name: "completed and cancelled", subtasks: 2, completed: 1, cancelled: 1, wantTotal: 1, wantPercent: 100},
+		{name: "all cancelled", subtasks: 2, cancelled: 2, wantTotal: 0, wantPercent: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewTaskProgress(taskID, tt.subtasks, tt.completed, tt.cancelled)
+			if p.TaskID != taskID || p.Total != tt.wantTotal || p.Completed != tt.completed || p.Percent != tt.wantPercent {
+				t.Errorf("Expected total %d at %.0f%%, got %+v", tt.wantTotal, tt.wantPercent, p)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Agent statuses set by presence tracking. Agents may also report working
+// or blocked themselves.
+const (
+	PresenceActive  = "active"
+	PresenceIdle    = "idle"
+	PresenceOffline = "offline"
+)
+
+// reportableStatuses are the statuses an agent may report in a heartbeat
+var reportableStatuses = map[string]bool{
+	PresenceActive:  true,
+	PresenceIdle:    true,
+	PresenceOffline: true,
+	"working":       true,
+	"blocked":       true,
+}
+
+// Default presence thresholds of projects that do not configure them
+const (
+	DefaultIdleAfterSeconds    = 300
+	DefaultOfflineAfterSeconds = 900
+)
+
+// PresenceThresholds sets how long an agent may stay silent before it is
+// marked idle and then offline. Zero means the default.
+type PresenceThresholds struct {
+	IdleAfterSeconds    int `json:"idle_after_seconds,omitempty"`
+	OfflineAfterSeconds int `json:"offline_after_seconds,omitempty"`
+}
+
+// IdleAfter returns the silence after which an active agent becomes idle
+func (t PresenceThresholds) IdleAfter() time.Duration {
+	if t.IdleAfterSeconds > 0 {
+		return time.Duration(t.IdleAfterSeconds) * time.Second
+	}
+	return DefaultIdleAfterSeconds * time.Second
+}
+
+// OfflineAfter returns the silence after which an agent becomes offline
+func (t PresenceThresholds) OfflineAfter() time.Duration {
+	if t.OfflineAfterSeconds > 0 {
+		return time.Duration(t.OfflineAfterSeconds) * time.Second
+	}
+	return DefaultOfflineAfterSeconds * time.Second
+}
+
+// StatusAfterHeartbeat returns the status of an agent in status current that
+// sent a heartbeat reporting reported. A reported status the agent may set
+// is taken as is; otherwise an idle or offline agent comes back active and
+// any other status is kept.
+func StatusAfterHeartbeat(current, reported string) string {
+	if reportableStatuses[reported] {
+		return reported
+	}
+	if current == PresenceIdle || current == PresenceOffline || current == "" {
+		return PresenceActive
+	}
+	return current
+}
+
+// AgentPresence is the payload of an agent_presence event, sent when an
+// agent's status changes because of a heartbeat or the lack of one
+type AgentPresence struct {
+	AgentID        uuid.UUID `json:"agent_id"`
+	ProjectID      uuid.UUID `json:"project_id"`
+	Status         string    `json:"status"`
+	PreviousStatus string    `json:"previous_status"`
+	LastSeen       time.Time `json:"last_seen"`
+}
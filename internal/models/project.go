@@ -1,21 +1,127 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Project struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name"`
-	Description string    `json:"description" db:"description"`
-	Status      string    `json:"status" db:"status"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID                uuid.UUID       `json:"id" db:"id"`
+	Name              string          `json:"name" db:"name"`
+	Description       string          `json:"description" db:"description"`
+	Status            string          `json:"status" db:"status"`
+	ClaimLeaseSeconds int             `json:"claim_lease_seconds" db:"claim_lease_seconds"`
+	SLAHours          SLAHours        `json:"sla_hours" db:"priority_sla_hours"`
+	IsTemplate        bool            `json:"is_template" db:"is_template"` // Offered as a starting point for new projects
+	Settings          ProjectSettings `json:"settings" db:"settings"`
+	CreatedAt         time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at" db:"updated_at"`
+
+	Milestones []MilestoneProgress `json:"milestones,omitempty" db:"-"` // Filled in when a single project is retrieved
 }
 
 type CreateProjectRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	ClaimLeaseSeconds int      `json:"claim_lease_seconds"`
+	SLAHours          SLAHours `json:"sla_hours"`
+	IsTemplate        bool     `json:"is_template"`
+}
+
+type UpdateProjectLeaseRequest struct {
+	ClaimLeaseSeconds int `json:"claim_lease_seconds"`
+}
+
+type UpdateProjectSLARequest struct {
+	SLAHours SLAHours `json:"sla_hours"`
+}
+
+// UpdateProjectRequest changes a project's metadata. Fields left out keep
+// their value, including the fields of settings.
+type UpdateProjectRequest struct {
+	Name        *string         `json:"name"`
+	Description *string         `json:"description"`
+	Settings    json.RawMessage `json:"settings"`
 }
+
+type UpdateProjectTemplateRequest struct {
+	IsTemplate bool `json:"is_template"`
+}
+
+// CloneProjectRequest creates a new project from an existing one. Agents and
+// custom fields are always copied; contexts only if selected by ID or tag.
+type CloneProjectRequest struct {
+	Name         string      `json:"name"`
+	Description  *string     `json:"description"`   // Defaults to the source project's description
+	ContextIDs   []uuid.UUID `json:"context_ids"`   // Contexts to copy
+	ContextTags  []string    `json:"context_tags"`  // Copy contexts with any of these tags
+	IncludeTasks *bool       `json:"include_tasks"` // Copy the task tree, default true
+}
+
+// SLAHours maps a task priority to the number of hours a task of that
+// priority may stay open before it is due
+type SLAHours map[string]int
+
+// DueAt returns the due date of a task with the given priority created at
+// from, or nil if the project has no SLA for that priority
+func (s SLAHours) DueAt(priority string, from time.Time) *time.Time {
+	hours, ok := s[priority]
+	if !ok || hours <= 0 {
+		return nil
+	}
+	due := from.Add(time.Duration(hours) * time.Hour)
+	return &due
+}
+
+// ProjectSettings tunes how a project's tasks and agents behave
+type ProjectSettings struct {
+	DefaultPriority   string             `json:"default_priority,omitempty"`    // Priority of tasks created without one, medium if unset
+	WIPLimits         WIPLimits          `json:"wip_limits"`                    // Limits on in_progress tasks
+	ClaimLeaseSeconds int                `json:"claim_lease_seconds,omitempty"` // Mirrors the project's claim_lease_seconds
+	AllowedAgentRoles []AgentRole        `json:"allowed_agent_roles"`           // Roles agents may register with, any if empty
+	Presence          PresenceThresholds `json:"presence"`                      // When silent agents become idle and offline
+}
+
+// WIPLimits caps the number of tasks in progress at the same time. Zero
+// means unlimited.
+type WIPLimits struct {
+	PerAgent int `json:"per_agent"`
+	Project  int `json:"project"`
+}
+
+// Reached reports whether an agent may not start another task given the
+// number of tasks it and the whole project already have in progress
+func (l WIPLimits) Reached(agentInProgress, projectInProgress int) bool {
+	return (l.PerAgent > 0 && agentInProgress >= l.PerAgent) ||
+		(l.Project > 0 && projectInProgress >= l.Project)
+}
+
+// TaskPriority returns the priority of a new task: the requested one, else
+// the project's default priority, else medium
+func (s ProjectSettings) TaskPriority(requested string) string {
+	if requested != "" {
+		return requested
+	}
+	if s.DefaultPriority != "" {
+		return s.DefaultPriority
+	}
+	return "medium"
+}
+
+// AllowsRole reports whether agents with the role may join the project
+func (s ProjectSettings) AllowsRole(role AgentRole) bool {
+	if len(s.AllowedAgentRoles) == 0 {
+		return true
+	}
+	for _, allowed := range s.AllowedAgentRoles {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultClaimLeaseSeconds is the claim lease used when a project does not configure one
+const DefaultClaimLeaseSeconds = 1800
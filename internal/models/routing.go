@@ -0,0 +1,102 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// AssignmentCandidate is an agent that could be given a task, with the
+// number of open tasks already assigned to it
+type AssignmentCandidate struct {
+	Agent Agent
+	Load  int
+}
+
+// NormalizeSkills lowercases and trims skills and drops empty and duplicate
+// ones, the same way labels are normalized
+func NormalizeSkills(skills []string) []string {
+	return NormalizeLabels(skills)
+}
+
+// SkillMatch returns how many of the required skills the agent has
+func (a Agent) SkillMatch(required []string) int {
+	have := make(map[string]bool, len(a.Skills))
+	for _, skill := range a.Skills {
+		have[strings.ToLower(skill)] = true
+	}
+	matched := 0
+	for _, skill := range required {
+		if have[strings.ToLower(skill)] {
+			matched++
+		}
+	}
+	return matched
+}
+
+// HasCapacity reports whether the agent can take on another task while load
+// open tasks are assigned to it
+func (a Agent) HasCapacity(load int) bool {
+	return a.Capacity == 0 || load < a.Capacity
+}
+
+// IsAvailable reports whether the agent is around to take on work
+func (a Agent) IsAvailable() bool {
+	return a.Status != PresenceOffline && a.Status != "blocked"
+}
+
+// presenceRank orders available agents, idle ones first as they are waiting for work
+func (a Agent) presenceRank() int {
+	if a.Status == PresenceIdle {
+		return 0
+	}
+	return 1
+}
+
+// PickAssignee chooses the agent a task is automatically assigned to. Only
+// available agents that may work on tasks, have capacity left and match the
+// task's role and team are considered; when the task requires skills, the
+// agent must have at least one of them. Among those it prefers the most
+// matching skills, then the lowest load, then idle agents, then the most
+// recently seen. It returns false if no agent qualifies.
+func PickAssignee(task Task, candidates []AssignmentCandidate) (Agent, bool) {
+	type scored struct {
+		AssignmentCandidate
+		match int
+	}
+	var eligible []scored
+	for _, c := range candidates {
+		a := c.Agent
+		if a.ProjectID != task.ProjectID || a.AccessRole == AccessObserver || !a.IsAvailable() || !a.HasCapacity(c.Load) {
+			continue
+		}
+		if (task.Role != "" && a.Role != task.Role) || (task.Team != "" && a.Team != task.Team) {
+			continue
+		}
+		match := a.SkillMatch(task.RequiredSkills)
+		if len(task.RequiredSkills) > 0 && match == 0 {
+			continue
+		}
+		eligible = append(eligible, scored{c, match})
+	}
+	if len(eligible) == 0 {
+		return Agent{}, false
+	}
+
+	sort.SliceStable(eligible, func(i, j int) bool {
+		a, b := eligible[i], eligible[j]
+		if a.match != b.match {
+			return a.match > b.match
+		}
+		if a.Load != b.Load {
+			return a.Load < b.Load
+		}
+		if a.Agent.presenceRank() != b.Agent.presenceRank() {
+			return a.Agent.presenceRank() < b.Agent.presenceRank()
+		}
+		if !a.Agent.LastSeen.Equal(b.Agent.LastSeen) {
+			return a.Agent.LastSeen.After(b.Agent.LastSeen)
+		}
+		return a.Agent.ID.String() < b.Agent.ID.String()
+	})
+	return eligible[0].Agent, true
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SearchResultType identifies the kind of record a search result points to
+type SearchResultType string
+
+const (
+	SearchTypeTask    SearchResultType = "task"
+	SearchTypeContext SearchResultType = "context"
+	SearchTypeStandup SearchResultType = "standup"
+)
+
+// SearchResultTypes lists every searchable record type
+var SearchResultTypes = []SearchResultType{SearchTypeTask, SearchTypeContext, SearchTypeStandup}
+
+// SearchQuery describes a full-text search. An empty Types searches every type.
+type SearchQuery struct {
+	Text      string
+	ProjectID *uuid.UUID
+	Types     []SearchResultType
+	Limit     int
+}
+
+// SearchResult is one ranked match. Matched terms in Snippet are wrapped in **.
+type SearchResult struct {
+	Type      SearchResultType `json:"type"`
+	ID        uuid.UUID        `json:"id"`
+	ProjectID uuid.UUID        `json:"project_id"`
+	Title     string           `json:"title"`
+	Snippet   string           `json:"snippet"`
+	Rank      float64          `json:"rank"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// Bounds for the number of search results returned
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // TaskStatus represents the status of a task
@@ -12,31 +13,104 @@ type TaskStatus string
 const (
 	StatusPending    TaskStatus = "pending"
 	StatusInProgress TaskStatus = "in_progress"
+	StatusBlocked    TaskStatus = "blocked"
 	StatusCompleted  TaskStatus = "completed"
 	StatusFailed     TaskStatus = "failed"
+	StatusCancelled  TaskStatus = "cancelled"
 )
 
+// TaskStatuses lists every canonical task status
+var TaskStatuses = []TaskStatus{
+	StatusPending, StatusInProgress, StatusBlocked, StatusCompleted, StatusFailed, StatusCancelled,
+}
+
+// taskTransitions defines the legal status changes. Terminal statuses
+// (completed, failed, cancelled) can only be left through Reopen.
+var taskTransitions = map[TaskStatus][]TaskStatus{
+	StatusPending:    {StatusInProgress, StatusBlocked, StatusCompleted, StatusCancelled},
+	StatusInProgress: {StatusPending, StatusBlocked, StatusCompleted, StatusFailed, StatusCancelled},
+	StatusBlocked:    {StatusPending, StatusInProgress, StatusCancelled},
+}
+
+// IsValid reports whether the status is one of the canonical task statuses
+func (s TaskStatus) IsValid() bool {
+	for _, status := range TaskStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCompleted reports whether the status counts as finished work
+func (s TaskStatus) IsCompleted() bool {
+	return s == StatusCompleted
+}
+
+// IsTerminal reports whether the task is closed and must be reopened to change status
+func (s TaskStatus) IsTerminal() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCancelled
+}
+
+// CanTransitionTo reports whether a task may move from s to next.
+// Keeping the current status is always allowed.
+func (s TaskStatus) CanTransitionTo(next TaskStatus) bool {
+	if s == next {
+		return true
+	}
+	for _, allowed := range taskTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 type Task struct {
-	ID          uuid.UUID  `json:"id" db:"id"`
-	ProjectID   uuid.UUID  `json:"project_id" db:"project_id"`
-	Title       string     `json:"title" db:"title"`
-	Description string     `json:"description" db:"description"`
-	Status      TaskStatus `json:"status" db:"status"`
-	Priority    string     `json:"priority" db:"priority"`
-	CreatedBy   uuid.UUID  `json:"created_by" db:"created_by"`
-	AssignedTo  *uuid.UUID `json:"assigned_to" db:"assigned_to"`
-	Output      string     `json:"output" db:"output"`
-	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	ID             uuid.UUID      `json:"id" db:"id"`
+	ProjectID      uuid.UUID      `json:"project_id" db:"project_id"`
+	ParentID       *uuid.UUID     `json:"parent_id" db:"parent_id"`
+	Title          string         `json:"title" db:"title"`
+	Description    string         `json:"description" db:"description"`
+	Status         TaskStatus     `json:"status" db:"status"`
+	Priority       string         `json:"priority" db:"priority"`
+	Role           AgentRole      `json:"role" db:"role"` // Only agents with this role may pull the task, empty for any
+	Team           string         `json:"team" db:"team"` // Only agents of this team may pull the task, empty for any
+	CreatedBy      uuid.UUID      `json:"created_by" db:"created_by"`
+	AssignedTo     *uuid.UUID     `json:"assigned_to" db:"assigned_to"`
+	Output         string         `json:"output" db:"output"`
+	ClaimExpiresAt *time.Time     `json:"claim_expires_at" db:"claim_expires_at"` // Claim lease, released unless renewed
+	DueAt          *time.Time     `json:"due_at" db:"due_at"`
+	Labels         pq.StringArray `json:"labels" db:"labels"`
+	CustomFields   CustomFields   `json:"custom_fields" db:"custom_fields"`
+	MilestoneID    *uuid.UUID     `json:"milestone_id" db:"milestone_id"`
+	RequiredSkills pq.StringArray `json:"required_skills" db:"required_skills"` // Skills an agent needs for the task
+	Version        int            `json:"version" db:"version"`                 // Bumped on every update, sent as the ETag
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// IsOverdue reports whether the task is still open after its due date
+func (t Task) IsOverdue(now time.Time) bool {
+	return t.DueAt != nil && t.DueAt.Before(now) && !t.Status.IsTerminal()
 }
 
 type CreateTaskRequest struct {
-	ProjectID   uuid.UUID  `json:"project_id"`
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Priority    string     `json:"priority"`
-	CreatedBy   uuid.UUID  `json:"created_by"`
-	AssignedTo  *uuid.UUID `json:"assigned_to"`
+	ProjectID      uuid.UUID    `json:"project_id"`
+	Title          string       `json:"title"`
+	Description    string       `json:"description"`
+	Priority       string       `json:"priority"`
+	Role           AgentRole    `json:"role"`
+	Team           string       `json:"team"`
+	CreatedBy      uuid.UUID    `json:"created_by"`
+	AssignedTo     *uuid.UUID   `json:"assigned_to"`
+	ParentID       *uuid.UUID   `json:"parent_id"`
+	DueAt          *time.Time   `json:"due_at"` // Defaults to the project's SLA for the priority
+	Labels         []string     `json:"labels"`
+	CustomFields   CustomFields `json:"custom_fields"` // Validated against the project's custom field definitions
+	MilestoneID    *uuid.UUID   `json:"milestone_id"`  // Must be a milestone of the same project
+	RequiredSkills []string     `json:"required_skills"`
+	AutoAssign     bool         `json:"auto_assign"` // Picks the assignee by skills, load and presence instead of AssignedTo
 }
 
 type UpdateTaskRequest struct {
@@ -44,6 +118,66 @@ type UpdateTaskRequest struct {
 	Output string     `json:"output"`
 }
 
+// NextTaskRequest asks the work queue for the next task an agent should work on
+type NextTaskRequest struct {
+	AgentID uuid.UUID `json:"agent_id"`
+}
+
+// UpdateTaskDueDateRequest sets or, with a null due_at, clears a task's due date
+type UpdateTaskDueDateRequest struct {
+	DueAt *time.Time `json:"due_at"`
+}
+
 type ReassignTaskRequest struct {
 	AssignedTo uuid.UUID `json:"assigned_to"`
 }
+
+// TaskProgress rolls up the completion of a task's direct subtasks
+type TaskProgress struct {
+	TaskID    uuid.UUID `json:"task_id"`
+	Total     int       `json:"total"`
+	Completed int       `json:"completed"`
+	Percent   float64   `json:"percent"`
+}
+
+// NewTaskProgress rolls up the counts of a task's direct subtasks. Cancelled
+// subtasks do not count towards the work, just as they do not hold up the
+// automatic completion of the parent, so a task whose subtasks are all
+// cancelled is 100% done.
+func NewTaskProgress(taskID uuid.UUID, subtasks, completed, cancelled int) TaskProgress {
+	progress := TaskProgress{TaskID: taskID, Total: subtasks - cancelled, Completed: completed}
+	if progress.Total > 0 {
+		progress.Percent = float64(progress.Completed) * 100 / float64(progress.Total)
+	} else if subtasks > 0 {
+		progress.Percent = 100
+	}
+	return progress
+}
+
+// TaskDependency represents a "depends on" edge between two tasks.
+// TaskID cannot be started until DependsOnID is completed.
+type TaskDependency struct {
+	TaskID      uuid.UUID `json:"task_id" db:"task_id"`
+	DependsOnID uuid.UUID `json:"depends_on_id" db:"depends_on_id"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// TaskRef is a lightweight reference to a related task
+type TaskRef struct {
+	ID     uuid.UUID  `json:"id"`
+	Title  string     `json:"title"`
+	Status TaskStatus `json:"status"`
+}
+
+// TaskDependencies describes a task's position in the blocking graph
+type TaskDependencies struct {
+	TaskID     uuid.UUID `json:"task_id"`
+	Ready      bool      `json:"ready"`
+	DependsOn  []TaskRef `json:"depends_on"`
+	BlockedBy  []TaskRef `json:"blocked_by"`
+	Dependents []TaskRef `json:"dependents"`
+}
+
+type AddTaskDependencyRequest struct {
+	DependsOnID uuid.UUID `json:"depends_on_id"`
+}
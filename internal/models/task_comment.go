@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskComment is a markdown note left on a task by an agent. Comments with a
+// ReplyToID form a thread under the comment they answer.
+type TaskComment struct {
+	ID        uuid.UUID     `json:"id" db:"id"`
+	TaskID    uuid.UUID     `json:"task_id" db:"task_id"`
+	ProjectID uuid.UUID     `json:"project_id" db:"project_id"`
+	AuthorID  uuid.UUID     `json:"author_id" db:"author_id"`
+	ReplyToID *uuid.UUID    `json:"reply_to_id" db:"reply_to_id"`
+	Body      string        `json:"body" db:"body"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at" db:"updated_at"`
+	Replies   []TaskComment `json:"replies,omitempty" db:"-"`
+}
+
+type CreateTaskCommentRequest struct {
+	AuthorID  uuid.UUID  `json:"author_id"`
+	ReplyToID *uuid.UUID `json:"reply_to_id"`
+	Body      string     `json:"body"`
+}
+
+type UpdateTaskCommentRequest struct {
+	Body string `json:"body"`
+}
+
+// ThreadTaskComments nests replies under the comments they answer. Comments
+// must be ordered oldest first; replies to unknown comments are kept at the top level.
+func ThreadTaskComments(comments []TaskComment) []TaskComment {
+	children := make(map[uuid.UUID][]TaskComment)
+	known := make(map[uuid.UUID]bool, len(comments))
+	for _, c := range comments {
+		known[c.ID] = true
+	}
+
+	var roots []TaskComment
+	for _, c := range comments {
+		if c.ReplyToID != nil && known[*c.ReplyToID] && *c.ReplyToID != c.ID {
+			children[*c.ReplyToID] = append(children[*c.ReplyToID], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	var attach func(c TaskComment) TaskComment
+	attach = func(c TaskComment) TaskComment {
+		for _, reply := range children[c.ID] {
+			c.Replies = append(c.Replies, attach(reply))
+		}
+		return c
+	}
+
+	threads := make([]TaskComment, 0, len(roots))
+	for _, c := range roots {
+		threads = append(threads, attach(c))
+	}
+	return threads
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskEventType identifies the kind of change recorded in a task's history
+type TaskEventType string
+
+const (
+	TaskEventCreated          TaskEventType = "created"
+	TaskEventStatusChanged    TaskEventType = "status_changed"
+	TaskEventReassigned       TaskEventType = "reassigned"
+	TaskEventOutputUpdated    TaskEventType = "output_updated"
+	TaskEventDueChanged       TaskEventType = "due_date_changed"
+	TaskEventLabelsChanged    TaskEventType = "labels_changed"
+	TaskEventFieldsChanged    TaskEventType = "custom_fields_changed"
+	TaskEventMilestoneChanged TaskEventType = "milestone_changed"
+	TaskEventDeleted          TaskEventType = "deleted"
+)
+
+// TaskEvent is one entry of a task's audit trail. ActorID is nil for changes
+// made by the system, e.g. an expired claim lease or automatic parent completion.
+type TaskEvent struct {
+	ID        uuid.UUID     `json:"id" db:"id"`
+	TaskID    uuid.UUID     `json:"task_id" db:"task_id"`
+	ProjectID uuid.UUID     `json:"project_id" db:"project_id"`
+	ActorID   *uuid.UUID    `json:"actor_id" db:"actor_id"`
+	EventType TaskEventType `json:"event_type" db:"event_type"`
+	OldValue  *string       `json:"old_value" db:"old_value"`
+	NewValue  *string       `json:"new_value" db:"new_value"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+}
+
+// NewTaskEvent creates an event for a task. Empty values are stored as null.
+func NewTaskEvent(task Task, actorID *uuid.UUID, eventType TaskEventType, oldValue, newValue string) TaskEvent {
+	return TaskEvent{
+		ID:        uuid.New(),
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		ActorID:   actorID,
+		EventType: eventType,
+		OldValue:  nullableString(oldValue),
+		NewValue:  nullableString(newValue),
+		CreatedAt: time.Now(),
+	}
+}
+
+// TaskChangeEvents compares two snapshots of the same task and returns an
+// event for every audited field that changed
+func TaskChangeEvents(before, after Task, actorID *uuid.UUID) []TaskEvent {
+	var events []TaskEvent
+	if before.Status != after.Status {
+		events = append(events, NewTaskEvent(after, actorID, TaskEventStatusChanged, string(before.Status), string(after.Status)))
+	}
+	if oldAssignee, newAssignee := uuidString(before.AssignedTo), uuidString(after.AssignedTo); oldAssignee != newAssignee {
+		events = append(events, NewTaskEvent(after, actorID, TaskEventReassigned, oldAssignee, newAssignee))
+	}
+	if before.Output != after.Output {
+		events = append(events, NewTaskEvent(after, actorID, TaskEventOutputUpdated, before.Output, after.Output))
+	}
+	return events
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func uuidString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"github.com/techbuzzz/agent-shaker/internal/database"
+)
+
+// Defaults of the heartbeat rollup
+const (
+	DefaultHeartbeatRollupInterval = 10 * time.Minute
+	DefaultHeartbeatRetention      = 7 * 24 * time.Hour  // Raw heartbeats
+	DefaultHourlyActivityRetention = 90 * 24 * time.Hour // Hourly activity buckets
+)
+
+// HeartbeatRollup periodically rolls raw heartbeats into hourly and daily
+// activity buckets and deletes the raw heartbeats and hourly buckets that
+// are past their retention
+type HeartbeatRollup struct {
+	db              *database.DB
+	interval        time.Duration
+	rawRetention    time.Duration
+	hourlyRetention time.Duration
+}
+
+// NewHeartbeatRollup creates a rollup that runs every interval. Raw
+// heartbeats are kept for at least an hour, and hourly buckets for at least
+// as long as raw heartbeats so that no hour is rolled up twice.
+func NewHeartbeatRollup(db *database.DB, interval, rawRetention, hourlyRetention time.Duration) *HeartbeatRollup {
+	if interval <= 0 {
+		interval = DefaultHeartbeatRollupInterval
+	}
+	if rawRetention < time.Hour {
+		rawRetention = DefaultHeartbeatRetention
+	}
+	if hourlyRetention < rawRetention {
+		hourlyRetention = rawRetention
+	}
+	return &HeartbeatRollup{db: db, interval: interval, rawRetention: rawRetention, hourlyRetention: hourlyRetention}
+}
+
+// Run rolls up heartbeats on every tick. It blocks forever and should be
+// started in its own goroutine.
+func (r *HeartbeatRollup) Run() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		r.Rollup()
+	}
+}
+
+// Rollup performs a single rollup pass in one transaction
+func (r *HeartbeatRollup) Rollup() {
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Printf("Heartbeat rollup: failed to start transaction: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := database.RollupHeartbeats(tx, r.rawRetention, r.hourlyRetention)
+	if err != nil {
+		log.Printf("Heartbeat rollup: %v", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Heartbeat rollup: failed to commit: %v", err)
+		return
+	}
+
+	if result.HoursRolled > 0 || result.RawDeleted > 0 || result.HoursDeleted > 0 {
+		log.Printf("Heartbeat rollup: rolled up %d agent hours, deleted %d heartbeats and %d hourly buckets",
+			result.HoursRolled, result.RawDeleted, result.HoursDeleted)
+	}
+}
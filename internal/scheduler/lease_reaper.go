@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/websocket"
+)
+
+// DefaultLeaseReaperInterval is how often expired claim leases are checked
+const DefaultLeaseReaperInterval = 30 * time.Second
+
+// LeaseReaper periodically returns tasks whose claim lease expired to pending
+// so that another agent can pick them up
+type LeaseReaper struct {
+	db       *database.DB
+	hub      *websocket.Hub
+	interval time.Duration
+}
+
+// NewLeaseReaper creates a reaper that runs every interval
+func NewLeaseReaper(db *database.DB, hub *websocket.Hub, interval time.Duration) *LeaseReaper {
+	if interval <= 0 {
+		interval = DefaultLeaseReaperInterval
+	}
+	return &LeaseReaper{db: db, hub: hub, interval: interval}
+}
+
+// Run releases expired claims on every tick. It blocks forever and should be
+// started in its own goroutine.
+func (r *LeaseReaper) Run() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		r.ReleaseExpired()
+	}
+}
+
+// ReleaseExpired performs a single reaper pass and broadcasts every released task
+func (r *LeaseReaper) ReleaseExpired() {
+	released, err := database.ReleaseExpiredClaims(r.db)
+	if err != nil {
+		log.Printf("Lease reaper: %v", err)
+	}
+
+	for _, task := range released {
+		log.Printf("Lease reaper: released expired claim on task %s", task.ID)
+		if r.hub != nil {
+			r.hub.BroadcastToProject(task.ProjectID, "task_update", task)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/websocket"
+)
+
+// DefaultOverdueCheckInterval is how often tasks are checked against their due date
+const DefaultOverdueCheckInterval = time.Minute
+
+// OverdueDetector periodically flags open tasks that passed their due date
+// and announces each of them once with a task_overdue event
+type OverdueDetector struct {
+	db       *database.DB
+	hub      *websocket.Hub
+	interval time.Duration
+}
+
+// NewOverdueDetector creates a detector that runs every interval
+func NewOverdueDetector(db *database.DB, hub *websocket.Hub, interval time.Duration) *OverdueDetector {
+	if interval <= 0 {
+		interval = DefaultOverdueCheckInterval
+	}
+	return &OverdueDetector{db: db, hub: hub, interval: interval}
+}
+
+// Run flags overdue tasks on every tick. It blocks forever and should be
+// started in its own goroutine.
+func (d *OverdueDetector) Run() {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		d.FlagOverdue()
+	}
+}
+
+// FlagOverdue performs a single detector pass and broadcasts every newly overdue task
+func (d *OverdueDetector) FlagOverdue() {
+	overdue, err := database.FlagOverdueTasks(d.db)
+	if err != nil {
+		log.Printf("Overdue detector: %v", err)
+	}
+
+	for _, task := range overdue {
+		log.Printf("Overdue detector: task %s was due at %s", task.ID, task.DueAt.Format(time.RFC3339))
+		if d.hub != nil {
+			d.hub.BroadcastToProject(task.ProjectID, "task_overdue", task)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"github.com/techbuzzz/agent-shaker/internal/database"
+	"github.com/techbuzzz/agent-shaker/internal/websocket"
+)
+
+// DefaultPresenceCheckInterval is how often agents are checked for missing heartbeats
+const DefaultPresenceCheckInterval = 30 * time.Second
+
+// PresenceTracker periodically marks agents that stopped sending heartbeats
+// idle and then offline, following the thresholds of their project
+type PresenceTracker struct {
+	db       *database.DB
+	hub      *websocket.Hub
+	interval time.Duration
+}
+
+// NewPresenceTracker creates a tracker that runs every interval
+func NewPresenceTracker(db *database.DB, hub *websocket.Hub, interval time.Duration) *PresenceTracker {
+	if interval <= 0 {
+		interval = DefaultPresenceCheckInterval
+	}
+	return &PresenceTracker{db: db, hub: hub, interval: interval}
+}
+
+// Run marks silent agents on every tick. It blocks forever and should be
+// started in its own goroutine.
+func (t *PresenceTracker) Run() {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		t.MarkSilent()
+	}
+}
+
+// MarkSilent performs a single tracker pass and broadcasts an agent_presence
+// event for every agent whose status changed
+func (t *PresenceTracker) MarkSilent() {
+	changed, err := database.MarkSilentAgents(t.db)
+	if err != nil {
+		log.Printf("Presence tracker: %v", err)
+	}
+
+	for _, presence := range changed {
+		log.Printf("Presence tracker: agent %s went %s, last seen at %s", presence.AgentID, presence.Status, presence.LastSeen.Format(time.RFC3339))
+		if t.hub != nil {
+			t.hub.BroadcastToProject(presence.ProjectID, "agent_presence", presence)
+		}
+	}
+}
@@ -2,20 +2,65 @@ package validator
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/techbuzzz/agent-shaker/internal/models"
 )
 
 var (
-	ErrEmptyName        = errors.New("name cannot be empty")
-	ErrNameTooLong      = errors.New("name cannot exceed 255 characters")
-	ErrEmptyTitle       = errors.New("title cannot be empty")
-	ErrTitleTooLong     = errors.New("title cannot exceed 255 characters")
-	ErrInvalidPriority  = errors.New("priority must be low, medium, or high")
-	ErrInvalidStatus    = errors.New("invalid status value")
-	ErrInvalidProjectID = errors.New("project_id is required")
-	ErrInvalidAgentID   = errors.New("agent_id is required")
+	ErrEmptyName          = errors.New("name cannot be empty")
+	ErrNameTooLong        = errors.New("name cannot exceed 255 characters")
+	ErrEmptyTitle         = errors.New("title cannot be empty")
+	ErrTitleTooLong       = errors.New("title cannot exceed 255 characters")
+	ErrInvalidPriority    = errors.New("priority must be low, medium, or high")
+	ErrInvalidStatus      = errors.New("invalid status value, must be one of: pending, in_progress, blocked, completed, failed, cancelled")
+	ErrInvalidProjectID   = errors.New("project_id is required")
+	ErrInvalidAgentID     = errors.New("agent_id is required")
+	ErrInvalidLease       = errors.New("claim_lease_seconds must be between 60 and 86400")
+	ErrInvalidTransition  = errors.New("illegal status transition")
+	ErrNotReopenable      = errors.New("only completed, failed or cancelled tasks can be reopened")
+	ErrInvalidSLA         = errors.New("sla_hours must map low, medium or high to between 1 and 8760 hours")
+	ErrInvalidLabel       = errors.New("labels must be between 1 and 64 characters")
+	ErrTooManyLabels      = errors.New("a task cannot have more than 20 labels")
+	ErrInvalidFieldName   = errors.New("custom field name must start with a lowercase letter and contain only lowercase letters, digits and underscores (max 63)")
+	ErrInvalidFieldType   = errors.New("custom field type must be one of: string, number, enum, date")
+	ErrInvalidFieldEnum   = errors.New("enum custom fields need at least one option, other types cannot have options")
+	ErrInvalidCustomField = errors.New("invalid custom field")
+	ErrEmptySearchQuery   = errors.New("search query cannot be empty")
+	ErrInvalidSearchType  = errors.New("search type must be one of: task, context, standup")
+	ErrInvalidSearchLimit = errors.New("search limit must be between 1 and 100")
+	ErrEmptyCommentBody   = errors.New("comment body cannot be empty")
+	ErrCommentTooLong     = errors.New("comment body cannot exceed 65536 characters")
+	ErrInvalidWIPLimit    = errors.New("wip_limits must be between 0 (unlimited) and 1000")
+	ErrInvalidAgentRole   = errors.New("allowed_agent_roles must be unique roles of 1 to 100 characters")
+	ErrInvalidTargetDate  = errors.New("target_date must be a date formatted as YYYY-MM-DD")
+	ErrKeyNameTooLong     = errors.New("API key name cannot exceed 255 characters")
+	ErrInvalidAccessRole  = errors.New("access_role must be one of: coordinator, worker, observer")
+	ErrInvalidSkill       = errors.New("skills must be between 1 and 64 characters")
+	ErrTooManySkills      = errors.New("no more than 50 skills can be listed")
+	ErrInvalidCapacity    = errors.New("capacity must be between 0 (unlimited) and 1000")
+	ErrAutoAssignConflict = errors.New("auto_assign cannot be combined with assigned_to")
+	ErrInvalidGranularity = errors.New("granularity must be hour or day")
+	ErrInvalidRange       = errors.New("from must be before to and the range cannot span more than 1000 buckets")
+	ErrInvalidPresence    = errors.New("presence thresholds must be between 60 and 604800 seconds, with offline_after_seconds above idle_after_seconds")
+)
+
+// MaxCommentBodyLength is the maximum size of a task comment, in bytes
+const MaxCommentBodyLength = 65536
+
+// Bounds for a project's claim lease duration, in seconds
+const (
+	MinClaimLeaseSeconds = 60
+	MaxClaimLeaseSeconds = 86400
+)
+
+// Bounds for a project's presence thresholds, in seconds
+const (
+	MinPresenceSeconds = 60
+	MaxPresenceSeconds = 604800
 )
 
 // ValidateCreateProjectRequest validates project creation request
@@ -26,6 +71,155 @@ func ValidateCreateProjectRequest(req *models.CreateProjectRequest) error {
 	if len(req.Name) > 255 {
 		return ErrNameTooLong
 	}
+	if req.ClaimLeaseSeconds != 0 {
+		if err := ValidateClaimLeaseSeconds(req.ClaimLeaseSeconds); err != nil {
+			return err
+		}
+	}
+	return ValidateSLAHours(req.SLAHours)
+}
+
+// ValidateCloneProjectRequest validates a request to clone a project
+func ValidateCloneProjectRequest(req *models.CloneProjectRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return ErrEmptyName
+	}
+	if len(req.Name) > 255 {
+		return ErrNameTooLong
+	}
+	return nil
+}
+
+// ValidateUpdateProjectRequest validates the name of a project update. The
+// merged settings are checked with ValidateProjectSettings.
+func ValidateUpdateProjectRequest(req *models.UpdateProjectRequest) error {
+	if req.Name == nil {
+		return nil
+	}
+	if strings.TrimSpace(*req.Name) == "" {
+		return ErrEmptyName
+	}
+	if len(*req.Name) > 255 {
+		return ErrNameTooLong
+	}
+	return nil
+}
+
+// ValidateCreateMilestoneRequest validates milestone creation request
+func ValidateCreateMilestoneRequest(req *models.CreateMilestoneRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return ErrEmptyName
+	}
+	if len(req.Name) > 255 {
+		return ErrNameTooLong
+	}
+	return validateTargetDate(req.TargetDate)
+}
+
+// ValidateUpdateMilestoneRequest validates the fields present in a milestone update
+func ValidateUpdateMilestoneRequest(req *models.UpdateMilestoneRequest) error {
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return ErrEmptyName
+		}
+		if len(*req.Name) > 255 {
+			return ErrNameTooLong
+		}
+	}
+	if req.TargetDate != nil {
+		return validateTargetDate(*req.TargetDate)
+	}
+	return nil
+}
+
+// validateTargetDate checks a milestone target date, which may be empty
+func validateTargetDate(value string) error {
+	if _, err := models.ParseMilestoneDate(value); err != nil {
+		return ErrInvalidTargetDate
+	}
+	return nil
+}
+
+// MaxWIPLimit is the largest WIP limit a project may set
+const MaxWIPLimit = 1000
+
+// ValidateProjectSettings validates a project's settings document
+func ValidateProjectSettings(settings models.ProjectSettings) error {
+	if settings.DefaultPriority != "" && settings.DefaultPriority != "low" && settings.DefaultPriority != "medium" && settings.DefaultPriority != "high" {
+		return ErrInvalidPriority
+	}
+	for _, limit := range []int{settings.WIPLimits.PerAgent, settings.WIPLimits.Project} {
+		if limit < 0 || limit > MaxWIPLimit {
+			return ErrInvalidWIPLimit
+		}
+	}
+	if err := ValidateClaimLeaseSeconds(settings.ClaimLeaseSeconds); err != nil {
+		return err
+	}
+	if err := ValidatePresenceThresholds(settings.Presence); err != nil {
+		return err
+	}
+	seen := map[models.AgentRole]bool{}
+	for _, role := range settings.AllowedAgentRoles {
+		if strings.TrimSpace(string(role)) == "" || len(role) > 100 || seen[role] {
+			return ErrInvalidAgentRole
+		}
+		seen[role] = true
+	}
+	return nil
+}
+
+// ValidatePresenceThresholds validates when a project's silent agents become
+// idle and offline. Unset thresholds take their default and must still leave
+// offline after idle.
+func ValidatePresenceThresholds(t models.PresenceThresholds) error {
+	for _, seconds := range []int{t.IdleAfterSeconds, t.OfflineAfterSeconds} {
+		if seconds != 0 && (seconds < MinPresenceSeconds || seconds > MaxPresenceSeconds) {
+			return ErrInvalidPresence
+		}
+	}
+	if t.OfflineAfter() <= t.IdleAfter() {
+		return ErrInvalidPresence
+	}
+	return nil
+}
+
+// MaxActivityBuckets is the most buckets an activity timeline may hold
+const MaxActivityBuckets = 1000
+
+// ValidateActivityRange validates the range and granularity of an agent's
+// activity timeline
+func ValidateActivityRange(from, to time.Time, granularity models.ActivityGranularity) error {
+	if !granularity.Valid() {
+		return ErrInvalidGranularity
+	}
+	if !from.Before(to) || to.Sub(from) > MaxActivityBuckets*granularity.Duration() {
+		return ErrInvalidRange
+	}
+	return nil
+}
+
+// ValidateClaimLeaseSeconds validates a project's claim lease duration
+func ValidateClaimLeaseSeconds(seconds int) error {
+	if seconds < MinClaimLeaseSeconds || seconds > MaxClaimLeaseSeconds {
+		return ErrInvalidLease
+	}
+	return nil
+}
+
+// MaxSLAHours is the longest SLA a project may define for a priority (one year)
+const MaxSLAHours = 8760
+
+// ValidateSLAHours validates a project's SLA per task priority
+func ValidateSLAHours(sla models.SLAHours) error {
+	for priority, hours := range sla {
+		if priority != "low" && priority != "medium" && priority != "high" {
+			return ErrInvalidSLA
+		}
+		if hours < 1 || hours > MaxSLAHours {
+			return ErrInvalidSLA
+		}
+	}
 	return nil
 }
 
@@ -40,6 +234,37 @@ func ValidateCreateAgentRequest(req *models.CreateAgentRequest) error {
 	if req.ProjectID.String() == "00000000-0000-0000-0000-000000000000" {
 		return ErrInvalidProjectID
 	}
+	if req.AccessRole != "" && !req.AccessRole.Valid() {
+		return ErrInvalidAccessRole
+	}
+	if req.Capacity < 0 || req.Capacity > MaxCapacity {
+		return ErrInvalidCapacity
+	}
+	return nil
+}
+
+// ValidateUpdateAgentProfileRequest validates agent profile update request.
+// Skills are checked after normalization with ValidateSkills.
+func ValidateUpdateAgentProfileRequest(req *models.UpdateAgentProfileRequest) error {
+	if req.Capacity != nil && (*req.Capacity < 0 || *req.Capacity > MaxCapacity) {
+		return ErrInvalidCapacity
+	}
+	return nil
+}
+
+// ValidateUpdateAgentAccessRequest validates agent access role update request
+func ValidateUpdateAgentAccessRequest(req *models.UpdateAgentAccessRequest) error {
+	if !req.AccessRole.Valid() {
+		return ErrInvalidAccessRole
+	}
+	return nil
+}
+
+// ValidateCreateAPIKeyRequest validates API key creation request
+func ValidateCreateAPIKeyRequest(req *models.CreateAPIKeyRequest) error {
+	if len(req.Name) > 255 {
+		return ErrKeyNameTooLong
+	}
 	return nil
 }
 
@@ -60,24 +285,47 @@ func ValidateCreateTaskRequest(req *models.CreateTaskRequest) error {
 	if req.Priority != "" && req.Priority != "low" && req.Priority != "medium" && req.Priority != "high" {
 		return ErrInvalidPriority
 	}
+	if req.AutoAssign && req.AssignedTo != nil {
+		return ErrAutoAssignConflict
+	}
 	return nil
 }
 
 // ValidateUpdateTaskRequest validates task update request
 func ValidateUpdateTaskRequest(req *models.UpdateTaskRequest) error {
-	validStatuses := map[string]bool{
-		"pending":     true,
-		"in_progress": true,
-		"blocked":     true,
-		"done":        true,
-		"cancelled":   true,
-	}
-	if !validStatuses[string(req.Status)] {
+	return ValidateTaskStatus(req.Status)
+}
+
+// ValidateTaskStatus checks that a status is one of the canonical task statuses
+func ValidateTaskStatus(status models.TaskStatus) error {
+	if !status.IsValid() {
 		return ErrInvalidStatus
 	}
 	return nil
 }
 
+// ValidateTaskTransition checks that a task may move from one status to another
+func ValidateTaskTransition(from, to models.TaskStatus) error {
+	if err := ValidateTaskStatus(to); err != nil {
+		return err
+	}
+	if from.CanTransitionTo(to) {
+		return nil
+	}
+	if from.IsTerminal() {
+		return fmt.Errorf("%w: task is %s and must be reopened before moving to %s", ErrInvalidTransition, from, to)
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+}
+
+// ValidateTaskReopen checks that a task is closed and can be reopened
+func ValidateTaskReopen(from models.TaskStatus) error {
+	if !from.IsTerminal() {
+		return ErrNotReopenable
+	}
+	return nil
+}
+
 // ValidateUpdateAgentStatusRequest validates agent status update request
 func ValidateUpdateAgentStatusRequest(req *models.UpdateAgentStatusRequest) error {
 	validStatuses := map[string]bool{
@@ -118,3 +366,170 @@ func ValidateUpdateContextRequest(req *models.UpdateContextRequest) error {
 	}
 	return nil
 }
+
+// ValidateCreateTaskCommentRequest validates task comment creation request
+func ValidateCreateTaskCommentRequest(req *models.CreateTaskCommentRequest) error {
+	if req.AuthorID.String() == "00000000-0000-0000-0000-000000000000" {
+		return ErrInvalidAgentID
+	}
+	return ValidateCommentBody(req.Body)
+}
+
+// ValidateCommentBody checks that a task comment body is present and not too long
+func ValidateCommentBody(body string) error {
+	if strings.TrimSpace(body) == "" {
+		return ErrEmptyCommentBody
+	}
+	if len(body) > MaxCommentBodyLength {
+		return ErrCommentTooLong
+	}
+	return nil
+}
+
+// Limits for task labels
+const (
+	MaxLabels      = 20
+	MaxLabelLength = 64
+)
+
+// Limits for agent skills and task required skills
+const (
+	MaxSkills      = 50
+	MaxSkillLength = 64
+	MaxCapacity    = 1000
+)
+
+// ValidateSkills validates agent skills or task required skills after normalization
+func ValidateSkills(skills []string) error {
+	if len(skills) > MaxSkills {
+		return ErrTooManySkills
+	}
+	for _, skill := range skills {
+		if skill == "" || len(skill) > MaxSkillLength {
+			return ErrInvalidSkill
+		}
+	}
+	return nil
+}
+
+// ValidateLabels validates task labels after normalization
+func ValidateLabels(labels []string) error {
+	if len(labels) > MaxLabels {
+		return ErrTooManyLabels
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > MaxLabelLength {
+			return ErrInvalidLabel
+		}
+	}
+	return nil
+}
+
+var customFieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// ValidateCreateCustomFieldRequest validates a custom field definition
+func ValidateCreateCustomFieldRequest(req *models.CreateCustomFieldRequest) error {
+	if !customFieldNamePattern.MatchString(req.Name) {
+		return ErrInvalidFieldName
+	}
+
+	validType := false
+	for _, t := range models.CustomFieldTypes {
+		if req.Type == t {
+			validType = true
+		}
+	}
+	if !validType {
+		return ErrInvalidFieldType
+	}
+
+	if (req.Type == models.FieldTypeEnum) != (len(req.Options) > 0) {
+		return ErrInvalidFieldEnum
+	}
+	for _, option := range req.Options {
+		if strings.TrimSpace(option) == "" {
+			return ErrInvalidFieldEnum
+		}
+	}
+	return nil
+}
+
+// ValidateCustomFields checks custom field values against a project's schema.
+// Every field must be defined, hold a value of the field's type and every
+// required field must be set.
+func ValidateCustomFields(defs []models.CustomFieldDefinition, values models.CustomFields) error {
+	byName := make(map[string]models.CustomFieldDefinition, len(defs))
+	for _, def := range defs {
+		byName[def.Name] = def
+	}
+
+	for name, value := range values {
+		def, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("%w: %s is not defined for this project", ErrInvalidCustomField, name)
+		}
+		if err := validateCustomFieldValue(def, value); err != nil {
+			return err
+		}
+	}
+
+	for _, def := range defs {
+		if _, ok := values[def.Name]; def.Required && !ok {
+			return fmt.Errorf("%w: %s is required", ErrInvalidCustomField, def.Name)
+		}
+	}
+	return nil
+}
+
+func validateCustomFieldValue(def models.CustomFieldDefinition, value interface{}) error {
+	switch def.Type {
+	case models.FieldTypeNumber:
+		if _, ok := value.(float64); ok {
+			return nil
+		}
+		return fmt.Errorf("%w: %s must be a number", ErrInvalidCustomField, def.Name)
+	case models.FieldTypeDate:
+		if s, ok := value.(string); ok {
+			if _, err := time.Parse(models.CustomFieldDateLayout, s); err == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s must be a date formatted as YYYY-MM-DD", ErrInvalidCustomField, def.Name)
+	case models.FieldTypeEnum:
+		if s, ok := value.(string); ok {
+			for _, option := range def.Options {
+				if s == option {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("%w: %s must be one of: %s", ErrInvalidCustomField, def.Name, strings.Join(def.Options, ", "))
+	default:
+		if _, ok := value.(string); ok {
+			return nil
+		}
+		return fmt.Errorf("%w: %s must be a string", ErrInvalidCustomField, def.Name)
+	}
+}
+
+// ValidateSearchQuery validates a full-text search. A zero limit uses the default.
+func ValidateSearchQuery(query *models.SearchQuery) error {
+	if strings.TrimSpace(query.Text) == "" {
+		return ErrEmptySearchQuery
+	}
+	for _, t := range query.Types {
+		valid := false
+		for _, known := range models.SearchResultTypes {
+			if t == known {
+				valid = true
+			}
+		}
+		if !valid {
+			return ErrInvalidSearchType
+		}
+	}
+	if query.Limit < 0 || query.Limit > models.MaxSearchLimit {
+		return ErrInvalidSearchLimit
+	}
+	return nil
+}
@@ -1,7 +1,10 @@
 package validator
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/techbuzzz/agent-shaker/internal/models"
@@ -33,6 +36,16 @@ func TestValidateCreateProjectRequest(t *testing.T) {
 			req:     models.CreateProjectRequest{Name: string(make([]byte, 256)), Description: "Test"},
 			wantErr: true,
 		},
+		{
+			name:    "custom claim lease",
+			req:     models.CreateProjectRequest{Name: "Test Project", ClaimLeaseSeconds: 600},
+			wantErr: false,
+		},
+		{
+			name:    "claim lease too short",
+			req:     models.CreateProjectRequest{Name: "Test Project", ClaimLeaseSeconds: 10},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -45,6 +58,165 @@ func TestValidateCreateProjectRequest(t *testing.T) {
 	}
 }
 
+func TestValidateCloneProjectRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.CloneProjectRequest
+		wantErr bool
+	}{
+		{name: "valid clone", req: models.CloneProjectRequest{Name: "Sprint 12", ContextTags: []string{"onboarding"}}, wantErr: false},
+		{name: "empty name", req: models.CloneProjectRequest{Name: " "}, wantErr: true},
+		{name: "name too long", req: models.CloneProjectRequest{Name: string(make([]byte, 256))}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCloneProjectRequest(&tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCloneProjectRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateUpdateProjectRequest(t *testing.T) {
+	name := func(s string) *string { return &s }
+	tests := []struct {
+		name    string
+		req     models.UpdateProjectRequest
+		wantErr bool
+	}{
+		{name: "no changes", req: models.UpdateProjectRequest{}, wantErr: false},
+		{name: "rename", req: models.UpdateProjectRequest{Name: name("Renamed")}, wantErr: false},
+		{name: "empty name", req: models.UpdateProjectRequest{Name: name("  ")}, wantErr: true},
+		{name: "name too long", req: models.UpdateProjectRequest{Name: name(string(make([]byte, 256)))}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUpdateProjectRequest(&tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpdateProjectRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateProjectSettings(t *testing.T) {
+	valid := models.ProjectSettings{
+		DefaultPriority:   "high",
+		WIPLimits:         models.WIPLimits{PerAgent: 2, Project: 10},
+		ClaimLeaseSeconds: 1800,
+		AllowedAgentRoles: []models.AgentRole{models.RoleBackend, models.RoleFrontend},
+	}
+	tests := []struct {
+		name    string
+		modify  func(*models.ProjectSettings)
+		wantErr bool
+	}{
+		{name: "valid settings", modify: func(s *models.ProjectSettings) {}, wantErr: false},
+		{name: "unlimited WIP", modify: func(s *models.ProjectSettings) { s.WIPLimits = models.WIPLimits{} }, wantErr: false},
+		{name: "invalid default priority", modify: func(s *models.ProjectSettings) { s.DefaultPriority = "urgent" }, wantErr: true},
+		{name: "negative WIP limit", modify: func(s *models.ProjectSettings) { s.WIPLimits.PerAgent = -1 }, wantErr: true},
+		{name: "WIP limit too high", modify: func(s *models.ProjectSettings) { s.WIPLimits.Project = MaxWIPLimit + 1 }, wantErr: true},
+		{name: "lease too short", modify: func(s *models.ProjectSettings) { s.ClaimLeaseSeconds = 5 }, wantErr: true},
+		{name: "custom presence", modify: func(s *models.ProjectSettings) {
+			s.Presence = models.PresenceThresholds{IdleAfterSeconds: 120, OfflineAfterSeconds: 600}
+		}, wantErr: false},
+		{name: "presence too short", modify: func(s *models.ProjectSettings) { s.Presence.IdleAfterSeconds = 10 }, wantErr: true},
+		{name: "offline before idle", modify: func(s *models.ProjectSettings) {
+			s.Presence = models.PresenceThresholds{IdleAfterSeconds: 600, OfflineAfterSeconds: 300}
+		}, wantErr: true},
+		{name: "offline before default idle", modify: func(s *models.ProjectSettings) { s.Presence.OfflineAfterSeconds = 120 }, wantErr: true},
+		{name: "empty role", modify: func(s *models.ProjectSettings) { s.AllowedAgentRoles = []models.AgentRole{" "} }, wantErr: true},
+		{name: "duplicate role", modify: func(s *models.ProjectSettings) {
+			s.AllowedAgentRoles = []models.AgentRole{models.RoleBackend, models.RoleBackend}
+		}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := valid
+			settings.AllowedAgentRoles = append([]models.AgentRole(nil), valid.AllowedAgentRoles...)
+			tt.modify(&settings)
+			err := ValidateProjectSettings(settings)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProjectSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateActivityRange(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		to          time.Time
+		granularity models.ActivityGranularity
+		wantErr     error
+	}{
+		{name: "one day hourly", to: from.Add(24 * time.Hour), granularity: models.ActivityHour},
+		{name: "one year daily", to: from.AddDate(1, 0, 0), granularity: models.ActivityDay},
+		{name: "one year hourly", to: from.AddDate(1, 0, 0), granularity: models.ActivityHour, wantErr: ErrInvalidRange},
+		{name: "empty range", to: from, granularity: models.ActivityHour, wantErr: ErrInvalidRange},
+		{name: "unknown granularity", to: from.Add(time.Hour), granularity: "minute", wantErr: ErrInvalidGranularity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateActivityRange(from, tt.to, tt.granularity); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateActivityRange() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateClaimLeaseSeconds(t *testing.T) {
+	tests := []struct {
+		name    string
+		seconds int
+		wantErr bool
+	}{
+		{name: "minimum", seconds: MinClaimLeaseSeconds, wantErr: false},
+		{name: "maximum", seconds: MaxClaimLeaseSeconds, wantErr: false},
+		{name: "zero", seconds: 0, wantErr: true},
+		{name: "negative", seconds: -30, wantErr: true},
+		{name: "too long", seconds: MaxClaimLeaseSeconds + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateClaimLeaseSeconds(tt.seconds)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateClaimLeaseSeconds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSLAHours(t *testing.T) {
+	tests := []struct {
+		name    string
+		sla     models.SLAHours
+		wantErr bool
+	}{
+		{name: "no sla", sla: nil, wantErr: false},
+		{name: "valid sla", sla: models.SLAHours{"high": 24, "medium": 72, "low": 168}, wantErr: false},
+		{name: "unknown priority", sla: models.SLAHours{"urgent": 4}, wantErr: true},
+		{name: "zero hours", sla: models.SLAHours{"high": 0}, wantErr: true},
+		{name: "too long", sla: models.SLAHours{"low": MaxSLAHours + 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSLAHours(tt.sla)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSLAHours() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateCreateAgentRequest(t *testing.T) {
 	validProjectID := uuid.New()
 	zeroUUID := uuid.UUID{}
@@ -69,6 +241,16 @@ func TestValidateCreateAgentRequest(t *testing.T) {
 			req:     models.CreateAgentRequest{Name: "Test Agent", ProjectID: zeroUUID},
 			wantErr: true,
 		},
+		{
+			name:    "observer",
+			req:     models.CreateAgentRequest{Name: "Dashboard", ProjectID: validProjectID, AccessRole: models.AccessObserver},
+			wantErr: false,
+		},
+		{
+			name:    "unknown access role",
+			req:     models.CreateAgentRequest{Name: "Test Agent", ProjectID: validProjectID, AccessRole: "admin"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -129,6 +311,17 @@ func TestValidateCreateTaskRequest(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "auto assign with assignee",
+			req: models.CreateTaskRequest{
+				Title:      "Test",
+				ProjectID:  validProjectID,
+				CreatedBy:  validAgentID,
+				AssignedTo: &validAgentID,
+				AutoAssign: true,
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -158,13 +351,18 @@ func TestValidateUpdateTaskRequest(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:    "valid status - done",
-			req:     models.UpdateTaskRequest{Status: "done"},
+			name:    "valid status - completed",
+			req:     models.UpdateTaskRequest{Status: "completed"},
 			wantErr: false,
 		},
 		{
-			name:    "invalid status",
-			req:     models.UpdateTaskRequest{Status: "completed"},
+			name:    "valid status - cancelled",
+			req:     models.UpdateTaskRequest{Status: "cancelled"},
+			wantErr: false,
+		},
+		{
+			name:    "legacy status - done",
+			req:     models.UpdateTaskRequest{Status: "done"},
 			wantErr: true,
 		},
 	}
@@ -179,6 +377,39 @@ func TestValidateUpdateTaskRequest(t *testing.T) {
 	}
 }
 
+func TestValidateTaskTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    models.TaskStatus
+		to      models.TaskStatus
+		wantErr error
+	}{
+		{name: "start pending task", from: models.StatusPending, to: models.StatusInProgress},
+		{name: "complete running task", from: models.StatusInProgress, to: models.StatusCompleted},
+		{name: "completed back to pending", from: models.StatusCompleted, to: models.StatusPending, wantErr: ErrInvalidTransition},
+		{name: "blocked to completed", from: models.StatusBlocked, to: models.StatusCompleted, wantErr: ErrInvalidTransition},
+		{name: "unknown target", from: models.StatusPending, to: "done", wantErr: ErrInvalidStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTaskTransition(tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateTaskTransition() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTaskReopen(t *testing.T) {
+	if err := ValidateTaskReopen(models.StatusCompleted); err != nil {
+		t.Errorf("Expected completed task to be reopenable, got %v", err)
+	}
+	if err := ValidateTaskReopen(models.StatusInProgress); err != ErrNotReopenable {
+		t.Errorf("Expected ErrNotReopenable, got %v", err)
+	}
+}
+
 func TestValidateUpdateAgentStatusRequest(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -216,3 +447,219 @@ func TestValidateUpdateAgentStatusRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateCreateTaskCommentRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.CreateTaskCommentRequest
+		wantErr bool
+	}{
+		{
+			name:    "valid comment",
+			req:     models.CreateTaskCommentRequest{AuthorID: uuid.New(), Body: "Found the root cause, see **logs**"},
+			wantErr: false,
+		},
+		{
+			name:    "missing author",
+			req:     models.CreateTaskCommentRequest{Body: "Hello"},
+			wantErr: true,
+		},
+		{
+			name:    "whitespace body",
+			req:     models.CreateTaskCommentRequest{AuthorID: uuid.New(), Body: "  \n "},
+			wantErr: true,
+		},
+		{
+			name:    "body too long",
+			req:     models.CreateTaskCommentRequest{AuthorID: uuid.New(), Body: strings.Repeat("a", MaxCommentBodyLength+1)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCreateTaskCommentRequest(&tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreateTaskCommentRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCreateCustomFieldRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.CreateCustomFieldRequest
+		wantErr bool
+	}{
+		{name: "string field", req: models.CreateCustomFieldRequest{Name: "component", Type: models.FieldTypeString}, wantErr: false},
+		{name: "enum field", req: models.CreateCustomFieldRequest{Name: "severity", Type: models.FieldTypeEnum, Options: []string{"minor", "major"}}, wantErr: false},
+		{name: "enum without options", req: models.CreateCustomFieldRequest{Name: "severity", Type: models.FieldTypeEnum}, wantErr: true},
+		{name: "options on number", req: models.CreateCustomFieldRequest{Name: "points", Type: models.FieldTypeNumber, Options: []string{"1"}}, wantErr: true},
+		{name: "unknown type", req: models.CreateCustomFieldRequest{Name: "points", Type: "integer"}, wantErr: true},
+		{name: "invalid name", req: models.CreateCustomFieldRequest{Name: "Story Points", Type: models.FieldTypeNumber}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCreateCustomFieldRequest(&tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreateCustomFieldRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCustomFields(t *testing.T) {
+	defs := []models.CustomFieldDefinition{
+		{Name: "component", Type: models.FieldTypeString, Required: true},
+		{Name: "points", Type: models.FieldTypeNumber},
+		{Name: "severity", Type: models.FieldTypeEnum, Options: []string{"minor", "major"}},
+		{Name: "release", Type: models.FieldTypeDate},
+	}
+
+	tests := []struct {
+		name    string
+		values  models.CustomFields
+		wantErr bool
+	}{
+		{
+			name:    "valid values",
+			values:  models.CustomFields{"component": "auth", "points": float64(3), "severity": "major", "release": "2024-06-01"},
+			wantErr: false,
+		},
+		{name: "missing required field", values: models.CustomFields{"points": float64(3)}, wantErr: true},
+		{name: "undefined field", values: models.CustomFields{"component": "auth", "owner": "me"}, wantErr: true},
+		{name: "number as string", values: models.CustomFields{"component": "auth", "points": "3"}, wantErr: true},
+		{name: "unknown enum option", values: models.CustomFields{"component": "auth", "severity": "critical"}, wantErr: true},
+		{name: "invalid date", values: models.CustomFields{"component": "auth", "release": "06/01/2024"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCustomFields(defs, tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCustomFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidCustomField) {
+				t.Errorf("ValidateCustomFields() error = %v, want ErrInvalidCustomField", err)
+			}
+		})
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	if err := ValidateLabels([]string{"backend", "auth"}); err != nil {
+		t.Errorf("ValidateLabels() unexpected error = %v", err)
+	}
+	if err := ValidateLabels([]string{strings.Repeat("a", MaxLabelLength+1)}); err == nil {
+		t.Error("ValidateLabels() expected error for a label that is too long")
+	}
+	if err := ValidateLabels(make([]string, MaxLabels+1)); err == nil {
+		t.Error("ValidateLabels() expected error for too many labels")
+	}
+}
+
+func TestValidateSkills(t *testing.T) {
+	if err := ValidateSkills([]string{"go", "postgres"}); err != nil {
+		t.Errorf("ValidateSkills() unexpected error = %v", err)
+	}
+	if err := ValidateSkills([]string{strings.Repeat("a", MaxSkillLength+1)}); err != ErrInvalidSkill {
+		t.Errorf("ValidateSkills() error = %v, want %v", err, ErrInvalidSkill)
+	}
+	if err := ValidateSkills(make([]string, MaxSkills+1)); err != ErrTooManySkills {
+		t.Errorf("ValidateSkills() error = %v, want %v", err, ErrTooManySkills)
+	}
+}
+
+func TestValidateUpdateAgentProfileRequest(t *testing.T) {
+	capacity := 3
+	if err := ValidateUpdateAgentProfileRequest(&models.UpdateAgentProfileRequest{Capacity: &capacity}); err != nil {
+		t.Errorf("ValidateUpdateAgentProfileRequest() unexpected error = %v", err)
+	}
+	capacity = -1
+	if err := ValidateUpdateAgentProfileRequest(&models.UpdateAgentProfileRequest{Capacity: &capacity}); err != ErrInvalidCapacity {
+		t.Errorf("ValidateUpdateAgentProfileRequest() error = %v, want %v", err, ErrInvalidCapacity)
+	}
+}
+
+func TestValidateSearchQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   models.SearchQuery
+		wantErr bool
+	}{
+		{name: "text only", query: models.SearchQuery{Text: "retry backoff"}, wantErr: false},
+		{name: "with types and limit", query: models.SearchQuery{Text: "auth", Types: []models.SearchResultType{models.SearchTypeTask, models.SearchTypeContext}, Limit: 50}, wantErr: false},
+		{name: "empty text", query: models.SearchQuery{Text: "  "}, wantErr: true},
+		{name: "unknown type", query: models.SearchQuery{Text: "auth", Types: []models.SearchResultType{"agent"}}, wantErr: true},
+		{name: "limit too high", query: models.SearchQuery{Text: "auth", Limit: models.MaxSearchLimit + 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSearchQuery(&tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSearchQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMilestoneRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.CreateMilestoneRequest
+		wantErr bool
+	}{
+		{name: "name only", req: models.CreateMilestoneRequest{Name: "Beta"}, wantErr: false},
+		{name: "with target date", req: models.CreateMilestoneRequest{Name: "Beta", TargetDate: "2026-03-31"}, wantErr: false},
+		{name: "empty name", req: models.CreateMilestoneRequest{Name: " "}, wantErr: true},
+		{name: "name too long", req: models.CreateMilestoneRequest{Name: strings.Repeat("a", 256)}, wantErr: true},
+		{name: "timestamp target date", req: models.CreateMilestoneRequest{Name: "Beta", TargetDate: "2026-03-31T00:00:00Z"}, wantErr: true},
+		{name: "invalid target date", req: models.CreateMilestoneRequest{Name: "Beta", TargetDate: "2026-02-30"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCreateMilestoneRequest(&tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreateMilestoneRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	empty, blank, bad := "", " ", "31/03/2026"
+	if err := ValidateUpdateMilestoneRequest(&models.UpdateMilestoneRequest{TargetDate: &empty}); err != nil {
+		t.Errorf("ValidateUpdateMilestoneRequest() unexpected error clearing target date: %v", err)
+	}
+	if err := ValidateUpdateMilestoneRequest(&models.UpdateMilestoneRequest{Name: &blank}); err != ErrEmptyName {
+		t.Errorf("ValidateUpdateMilestoneRequest() error = %v, want %v", err, ErrEmptyName)
+	}
+	if err := ValidateUpdateMilestoneRequest(&models.UpdateMilestoneRequest{TargetDate: &bad}); err != ErrInvalidTargetDate {
+		t.Errorf("ValidateUpdateMilestoneRequest() error = %v, want %v", err, ErrInvalidTargetDate)
+	}
+}
+
+func TestValidateUpdateAgentAccessRequest(t *testing.T) {
+	if err := ValidateUpdateAgentAccessRequest(&models.UpdateAgentAccessRequest{AccessRole: models.AccessCoordinator}); err != nil {
+		t.Errorf("ValidateUpdateAgentAccessRequest() unexpected error: %v", err)
+	}
+	for _, role := range []models.AccessRole{"", "admin"} {
+		if err := ValidateUpdateAgentAccessRequest(&models.UpdateAgentAccessRequest{AccessRole: role}); err != ErrInvalidAccessRole {
+			t.Errorf("ValidateUpdateAgentAccessRequest(%q) error = %v, want %v", role, err, ErrInvalidAccessRole)
+		}
+	}
+}
+
+func TestValidateCreateAPIKeyRequest(t *testing.T) {
+	if err := ValidateCreateAPIKeyRequest(&models.CreateAPIKeyRequest{}); err != nil {
+		t.Errorf("ValidateCreateAPIKeyRequest() unexpected error without a name: %v", err)
+	}
+	if err := ValidateCreateAPIKeyRequest(&models.CreateAPIKeyRequest{Name: "ci"}); err != nil {
+		t.Errorf("ValidateCreateAPIKeyRequest() unexpected error: %v", err)
+	}
+	if err := ValidateCreateAPIKeyRequest(&models.CreateAPIKeyRequest{Name: strings.Repeat("a", 256)}); err != ErrKeyNameTooLong {
+		t.Errorf("ValidateCreateAPIKeyRequest() error = %v, want %v", err, ErrKeyNameTooLong)
+	}
+}
@@ -0,0 +1,5 @@
+-- Add parent/child hierarchy to tasks
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES tasks(id) ON DELETE CASCADE;
+
+-- Create index for listing subtasks
+CREATE INDEX IF NOT EXISTS idx_tasks_parent ON tasks(parent_id);
@@ -0,0 +1,8 @@
+-- Turn task claims into leases that expire unless renewed
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS claim_expires_at TIMESTAMP;
+
+-- Lease duration is configurable per project (default 30 minutes)
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS claim_lease_seconds INTEGER NOT NULL DEFAULT 1800;
+
+-- Create index for the lease reaper
+CREATE INDEX IF NOT EXISTS idx_tasks_claim_expires ON tasks(claim_expires_at) WHERE status = 'in_progress';
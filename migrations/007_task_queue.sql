@@ -0,0 +1,6 @@
+-- Let tasks target a role and/or team so agents can pull matching work from the queue
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS role VARCHAR(50);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS team VARCHAR(100);
+
+-- Create index for picking the next unassigned task of a project
+CREATE INDEX IF NOT EXISTS idx_tasks_queue ON tasks(project_id, priority, created_at) WHERE status = 'pending' AND assigned_to IS NULL;
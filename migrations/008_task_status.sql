@@ -0,0 +1,12 @@
+-- Normalize task statuses to the canonical set:
+-- pending, in_progress, blocked, completed, failed, cancelled
+UPDATE tasks SET status = 'completed' WHERE status = 'done';
+UPDATE tasks SET status = 'cancelled' WHERE status = 'canceled';
+UPDATE tasks SET status = 'pending', claim_expires_at = NULL
+WHERE status IS NULL OR status NOT IN ('pending', 'in_progress', 'blocked', 'completed', 'failed', 'cancelled');
+
+-- Reject non-canonical values from now on
+ALTER TABLE tasks ALTER COLUMN status SET NOT NULL;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_status_check;
+ALTER TABLE tasks ADD CONSTRAINT tasks_status_check
+    CHECK (status IN ('pending', 'in_progress', 'blocked', 'completed', 'failed', 'cancelled'));
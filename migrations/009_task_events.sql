@@ -0,0 +1,16 @@
+-- Create task_events table recording the audit trail of every task mutation.
+-- task_id has no foreign key so the history outlives a deleted task.
+CREATE TABLE IF NOT EXISTS task_events (
+    id UUID PRIMARY KEY,
+    seq BIGSERIAL,
+    task_id UUID NOT NULL,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES agents(id) ON DELETE SET NULL,
+    event_type VARCHAR(50) NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create index for reading a task's history in order
+CREATE INDEX IF NOT EXISTS idx_task_events_task ON task_events(task_id, seq);
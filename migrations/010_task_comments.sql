@@ -0,0 +1,15 @@
+-- Create task_comments table for threaded discussion on tasks.
+-- Replies whose parent comment is deleted become top-level comments.
+CREATE TABLE IF NOT EXISTS task_comments (
+    id UUID PRIMARY KEY,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+    reply_to_id UUID REFERENCES task_comments(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create index for reading a task's comments in order
+CREATE INDEX IF NOT EXISTS idx_task_comments_task ON task_comments(task_id, created_at);
@@ -0,0 +1,10 @@
+-- Tasks can carry a due date; overdue_notified_at records when the overdue
+-- detector last flagged the task so each overdue task is announced once
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_at TIMESTAMP;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS overdue_notified_at TIMESTAMP;
+
+-- Projects define an SLA in hours per task priority, e.g. {"high": 24, "medium": 72}
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS priority_sla_hours JSONB NOT NULL DEFAULT '{}';
+
+-- Create index for due date filters and the overdue detector
+CREATE INDEX IF NOT EXISTS idx_tasks_due_at ON tasks(due_at) WHERE due_at IS NOT NULL;
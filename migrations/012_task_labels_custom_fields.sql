@@ -0,0 +1,20 @@
+-- Free-form labels on tasks, comparable to context tags
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS labels TEXT[] NOT NULL DEFAULT '{}';
+
+-- Values of the project-defined custom fields, keyed by field name
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}';
+
+-- Create custom_field_definitions table holding each project's custom field schema
+CREATE TABLE IF NOT EXISTS custom_field_definitions (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(63) NOT NULL,
+    field_type VARCHAR(20) NOT NULL,
+    options TEXT[] NOT NULL DEFAULT '{}',
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (project_id, name)
+);
+
+-- Create indexes for label and custom field filters
+CREATE INDEX IF NOT EXISTS idx_tasks_labels ON tasks USING GIN(labels);
+CREATE INDEX IF NOT EXISTS idx_tasks_custom_fields ON tasks USING GIN(custom_fields);
@@ -0,0 +1,22 @@
+-- Full-text search vectors, kept up to date by PostgreSQL as generated columns.
+-- Titles rank above bodies (weight A), outputs and secondary fields lowest.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(output, '')), 'C')
+) STORED;
+
+ALTER TABLE contexts ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(content, '')), 'B')
+) STORED;
+
+ALTER TABLE daily_standups ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(did, '') || ' ' || coalesce(doing, '') || ' ' || coalesce(done, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(blockers, '') || ' ' || coalesce(challenges, '')), 'C')
+) STORED;
+
+-- Create indexes for full-text search
+CREATE INDEX IF NOT EXISTS idx_tasks_search ON tasks USING GIN(search_vector);
+CREATE INDEX IF NOT EXISTS idx_contexts_search ON contexts USING GIN(search_vector);
+CREATE INDEX IF NOT EXISTS idx_standups_search ON daily_standups USING GIN(search_vector);
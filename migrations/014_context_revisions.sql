@@ -0,0 +1,19 @@
+-- Create context_revisions table keeping a snapshot of every version of a context
+CREATE TABLE IF NOT EXISTS context_revisions (
+    id UUID PRIMARY KEY,
+    context_id UUID NOT NULL REFERENCES contexts(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    author_id UUID REFERENCES agents(id) ON DELETE SET NULL,
+    task_id UUID,
+    title VARCHAR(255) NOT NULL,
+    content TEXT,
+    tags TEXT[],
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_context_revision UNIQUE (context_id, revision)
+);
+
+-- Existing contexts start their history with their current version
+INSERT INTO context_revisions (id, context_id, revision, author_id, task_id, title, content, tags, created_at)
+SELECT gen_random_uuid(), id, 1, agent_id, task_id, title, content, tags, updated_at
+FROM contexts
+ON CONFLICT (context_id, revision) DO NOTHING;
@@ -0,0 +1,21 @@
+-- Row versions for optimistic concurrency control, exposed to clients as ETags.
+-- The version is bumped by PostgreSQL on every update so no write path can forget it.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE contexts ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION bump_row_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS tasks_bump_version ON tasks;
+CREATE TRIGGER tasks_bump_version
+    BEFORE UPDATE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION bump_row_version();
+
+DROP TRIGGER IF EXISTS contexts_bump_version ON contexts;
+CREATE TRIGGER contexts_bump_version
+    BEFORE UPDATE ON contexts
+    FOR EACH ROW EXECUTE FUNCTION bump_row_version();
@@ -0,0 +1,25 @@
+-- Create context_links table holding the [[Title]] and agent-shaker://contexts/{id}
+-- links parsed from context content. Targets are resolved when read, so a link
+-- to a missing title starts working as soon as a context with that title exists.
+CREATE TABLE IF NOT EXISTS context_links (
+    source_id UUID NOT NULL REFERENCES contexts(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('title', 'id')),
+    target TEXT NOT NULL,
+    PRIMARY KEY (source_id, kind, target)
+);
+
+CREATE INDEX IF NOT EXISTS idx_context_links_target ON context_links(project_id, kind, lower(target));
+CREATE INDEX IF NOT EXISTS idx_contexts_project_title ON contexts(project_id, lower(title));
+
+-- Index links of existing contexts
+INSERT INTO context_links (source_id, project_id, kind, target)
+SELECT c.id, c.project_id, 'title', btrim(m[1])
+FROM contexts c, regexp_matches(coalesce(c.content, ''), '\[\[([^][|]+)(\|[^][]*)?\]\]', 'g') AS m
+WHERE btrim(m[1]) <> ''
+ON CONFLICT DO NOTHING;
+
+INSERT INTO context_links (source_id, project_id, kind, target)
+SELECT c.id, c.project_id, 'id', lower(m[1])
+FROM contexts c, regexp_matches(coalesce(c.content, ''), 'agent-shaker://contexts/([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})', 'g') AS m
+ON CONFLICT DO NOTHING;
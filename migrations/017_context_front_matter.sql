@@ -0,0 +1,5 @@
+-- YAML front matter of a context, parsed on save and stored as JSON for filtering.
+-- Existing contexts get their front matter the next time they are saved.
+ALTER TABLE contexts ADD COLUMN IF NOT EXISTS front_matter JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_contexts_front_matter ON contexts USING GIN(front_matter);
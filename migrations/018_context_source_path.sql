@@ -0,0 +1,5 @@
+-- Path of the file a context was imported from. Re-importing the same path
+-- updates the context instead of creating a duplicate.
+ALTER TABLE contexts ADD COLUMN IF NOT EXISTS source_path TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_contexts_source_path ON contexts(project_id, source_path) WHERE source_path IS NOT NULL;
@@ -0,0 +1,5 @@
+-- Projects marked as templates are the starting point of new projects cloned from them
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS is_template BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Create index for listing templates
+CREATE INDEX IF NOT EXISTS idx_projects_template ON projects(is_template) WHERE is_template;
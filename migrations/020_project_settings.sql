@@ -0,0 +1,3 @@
+-- Project settings such as the default task priority, WIP limits and allowed
+-- agent roles. The claim lease duration keeps its own column.
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS settings JSONB NOT NULL DEFAULT '{}';
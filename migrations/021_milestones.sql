@@ -0,0 +1,17 @@
+-- Create milestones table for planning a project's work towards target dates
+CREATE TABLE IF NOT EXISTS milestones (
+    id UUID PRIMARY KEY,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    target_date DATE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tasks leave a milestone when it is deleted
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS milestone_id UUID REFERENCES milestones(id) ON DELETE SET NULL;
+
+-- Create indexes for listing a project's milestones and a milestone's tasks
+CREATE INDEX IF NOT EXISTS idx_milestones_project ON milestones(project_id, target_date);
+CREATE INDEX IF NOT EXISTS idx_tasks_milestone ON tasks(milestone_id);
@@ -0,0 +1,15 @@
+-- Create API keys that authenticate agents. Only a SHA-256 hash of each key
+-- is stored; the prefix identifies a key in listings.
+CREATE TABLE IF NOT EXISTS agent_api_keys (
+    id UUID PRIMARY KEY,
+    agent_id UUID NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+-- Create index for listing an agent's keys
+CREATE INDEX IF NOT EXISTS idx_agent_api_keys_agent ON agent_api_keys(agent_id, created_at);
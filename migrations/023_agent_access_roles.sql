@@ -0,0 +1,7 @@
+-- Add access roles to agents. Coordinators manage tasks and the project,
+-- workers claim and complete their own tasks, observers only read.
+-- Existing agents become coordinators so they keep the access they had;
+-- new agents are workers unless registered otherwise.
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS access_role VARCHAR(20) NOT NULL DEFAULT 'coordinator'
+    CHECK (access_role IN ('coordinator', 'worker', 'observer'));
+ALTER TABLE agents ALTER COLUMN access_role SET DEFAULT 'worker';
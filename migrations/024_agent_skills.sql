@@ -0,0 +1,5 @@
+-- Let agents declare their skills and capacity, and tasks the skills they
+-- need. Capacity is the most open tasks an agent takes on at once, 0 for no limit.
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS skills TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS capacity INTEGER NOT NULL DEFAULT 0 CHECK (capacity >= 0);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS required_skills TEXT[] NOT NULL DEFAULT '{}';
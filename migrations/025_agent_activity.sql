@@ -0,0 +1,13 @@
+-- Hourly and daily activity of agents, rolled up from their heartbeats so
+-- that raw heartbeats can be deleted after the retention window
+CREATE TABLE IF NOT EXISTS agent_activity (
+    agent_id UUID NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+    granularity VARCHAR(10) NOT NULL CHECK (granularity IN ('hour', 'day')),
+    bucket_start TIMESTAMP NOT NULL,
+    heartbeats INTEGER NOT NULL DEFAULT 0,
+    active_seconds INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (agent_id, granularity, bucket_start)
+);
+
+-- Create index for rolling up and deleting heartbeats of an agent in time order
+CREATE INDEX IF NOT EXISTS idx_heartbeats_agent_time ON agent_heartbeats(agent_id, heartbeat_time);
@@ -0,0 +1,20 @@
+-- Only bump a task's version when a column clients see changes. Renewing a
+-- claim lease or flagging a task overdue must not invalidate ETags and
+-- expected_version held by clients. search_vector is derived from the
+-- other columns and not yet computed when the trigger runs.
+CREATE OR REPLACE FUNCTION bump_task_version() RETURNS trigger AS $$
+BEGIN
+    IF (to_jsonb(NEW) - ARRAY['version', 'claim_expires_at', 'overdue_notified_at', 'updated_at', 'search_vector'])
+       IS DISTINCT FROM (to_jsonb(OLD) - ARRAY['version', 'claim_expires_at', 'overdue_notified_at', 'updated_at', 'search_vector']) THEN
+        NEW.version := OLD.version + 1;
+    ELSE
+        NEW.version := OLD.version;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS tasks_bump_version ON tasks;
+CREATE TRIGGER tasks_bump_version
+    BEFORE UPDATE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION bump_task_version();
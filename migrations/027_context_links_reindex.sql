@@ -0,0 +1,26 @@
+-- Re-index the links of existing contexts with the rules of the server: the
+-- backfill in 016 also matched links inside fenced code blocks and titles
+-- spanning several lines. Content is parsed line by line and lines inside
+-- ``` fences, counted as an odd number of fence lines before them, are skipped.
+DELETE FROM context_links;
+
+CREATE TEMPORARY TABLE context_link_lines ON COMMIT DROP AS
+SELECT id, project_id, line
+FROM (
+    SELECT c.id, c.project_id, l.line,
+           btrim(l.line, E' \t\r\v\f') LIKE '```%' AS is_fence,
+           COUNT(*) FILTER (WHERE btrim(l.line, E' \t\r\v\f') LIKE '```%') OVER (PARTITION BY c.id ORDER BY l.n) AS fences
+    FROM contexts c, regexp_split_to_table(coalesce(c.content, ''), E'\n') WITH ORDINALITY AS l(line, n)
+) lines
+WHERE NOT is_fence AND fences % 2 = 0;
+
+INSERT INTO context_links (source_id, project_id, kind, target)
+SELECT l.id, l.project_id, 'title', btrim(m[1], E' \t\r\v\f')
+FROM context_link_lines l, regexp_matches(l.line, '\[\[([^][|]+)(\|[^][]*)?\]\]', 'g') AS m
+WHERE btrim(m[1], E' \t\r\v\f') <> ''
+ON CONFLICT DO NOTHING;
+
+INSERT INTO context_links (source_id, project_id, kind, target)
+SELECT l.id, l.project_id, 'id', lower(m[1])
+FROM context_link_lines l, regexp_matches(l.line, 'agent-shaker://contexts/([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})', 'g') AS m
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,110 @@
+package a2a_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/techbuzzz/agent-shaker/internal/a2a/models"
+	a2aserver "github.com/techbuzzz/agent-shaker/internal/a2a/server"
+)
+
+// staticContextStorage serves a fixed set of contexts
+type staticContextStorage map[string]a2aserver.ContextData
+
+func (s staticContextStorage) ListContexts() ([]a2aserver.ContextData, error) {
+	contexts := make([]a2aserver.ContextData, 0, len(s))
+	for _, ctx := range s {
+		contexts = append(contexts, ctx)
+	}
+	return contexts, nil
+}
+
+func (s staticContextStorage) GetContext(id string) (*a2aserver.ContextData, error) {
+	ctx, ok := s[id]
+	if !ok {
+		return nil, fmt.Errorf("context not found")
+	}
+	return &ctx, nil
+}
+
+func getArtifact(t *testing.T, target, accept string) models.Artifact {
+	t.Helper()
+
+	storage := staticContextStorage{
+		"ctx-1": {
+			ID:          "ctx-1",
+			Name:        "API Design",
+			Content:     "---\nservice: billing\n---\n# API Design\n\n<script>alert(1)</script>\n\n```go\nfunc main() {}\n```\n",
+			FrontMatter: map[string]any{"service": "billing"},
+		},
+	}
+	handler := a2aserver.NewArtifactHandler(storage, "http://localhost:8080")
+
+	r := mux.NewRouter()
+	r.HandleFunc("/a2a/v1/artifacts/{artifactId}", handler.GetArtifact)
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var artifact models.Artifact
+	if err := json.NewDecoder(rec.Body).Decode(&artifact); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return artifact
+}
+
+func TestGetArtifactMarkdownByDefault(t *testing.T) {
+	artifact := getArtifact(t, "/a2a/v1/artifacts/ctx-1", "")
+
+	if artifact.ContentType != "text/markdown" {
+		t.Errorf("Expected content type text/markdown, got %s", artifact.ContentType)
+	}
+	if !strings.HasPrefix(artifact.Content, "---\nservice: billing") {
+		t.Errorf("Expected raw markdown content, got %q", artifact.Content)
+	}
+	if _, ok := artifact.Metadata["alternates"].(map[string]any)["text/html"]; !ok {
+		t.Errorf("Expected text/html alternate in metadata, got %v", artifact.Metadata)
+	}
+	if fm, ok := artifact.Metadata["front_matter"].(map[string]any); !ok || fm["service"] != "billing" {
+		t.Errorf("Expected front matter in metadata, got %v", artifact.Metadata["front_matter"])
+	}
+}
+
+func TestGetArtifactHTML(t *testing.T) {
+	for _, tc := range []struct {
+		name, target, accept string
+	}{
+		{"format query", "/a2a/v1/artifacts/ctx-1?format=html", ""},
+		{"accept header", "/a2a/v1/artifacts/ctx-1", "text/html"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			artifact := getArtifact(t, tc.target, tc.accept)
+
+			if artifact.ContentType != "text/html" || artifact.Type != "html" {
+				t.Errorf("Expected html artifact, got %s (%s)", artifact.ContentType, artifact.Type)
+			}
+			if strings.Contains(artifact.Content, "<script") {
+				t.Errorf("Expected sanitized HTML, got %s", artifact.Content)
+			}
+			if !strings.Contains(artifact.Content, `<code class="language-go">`) {
+				t.Errorf("Expected tagged code block, got %s", artifact.Content)
+			}
+			if toc, ok := artifact.Metadata["toc"].([]any); !ok || len(toc) != 1 {
+				t.Errorf("Expected one TOC entry, got %v", artifact.Metadata["toc"])
+			}
+		})
+	}
+}
@@ -317,7 +317,7 @@ function Update-TaskStatus {
         [Parameter(Mandatory=$true)]
         [string]$TaskId,
         [Parameter(Mandatory=$true)]
-        [ValidateSet("pending", "in_progress", "done", "blocked")]
+        [ValidateSet("pending", "in_progress", "blocked", "completed", "failed", "cancelled")]
         [string]$Status
     )
     
@@ -379,7 +379,7 @@ get_my_tasks() {
 
 # Update task status
 # Usage: update_task_status <task_id> <status>
-# Status: pending, in_progress, done, blocked
+# Status: pending, in_progress, blocked, completed, failed, cancelled
 update_task_status() {
     local task_id=$1
     local status=$2
@@ -433,7 +433,11 @@ get_project_contexts() {
       "servers": {
         "agent-shaker": {
           "type": "http",
-          "url": mcpUrl 
+          "url": mcpUrl,
+          // Issue a key with POST /api/agents/{id}/keys
+          "headers": {
+            "Authorization": "Bearer <agent API key>"
+          }
         }
       }
     }
@@ -456,7 +460,11 @@ get_project_contexts() {
       "servers": {
         "agent-shaker": {
           "type": "http",
-          "url": mcpUrl 
+          "url": mcpUrl,
+          // Issue a key with POST /api/agents/{id}/keys
+          "headers": {
+            "Authorization": "Bearer <agent API key>"
+          }
         }
       }
     }
@@ -1,4 +1,5 @@
 import { ref } from 'vue'
+import { API_KEY_STORAGE_KEY } from '../services/api'
 
 const ws = ref(null)
 const isConnected = ref(false)
@@ -12,7 +13,12 @@ export function useWebSocket(projectId) {
     }
 
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
-    const wsUrl = `${protocol}//${window.location.hostname}:${window.location.port}/ws?project_id=${encodeURIComponent(projectId)}`
+    let wsUrl = `${protocol}//${window.location.hostname}:${window.location.port}/ws?project_id=${encodeURIComponent(projectId)}`
+    // Browsers cannot set headers on WebSockets, so the API key goes in the URL
+    const apiKey = localStorage.getItem(API_KEY_STORAGE_KEY)
+    if (apiKey) {
+      wsUrl += `&access_token=${encodeURIComponent(apiKey)}`
+    }
     
     ws.value = new WebSocket(wsUrl)
 
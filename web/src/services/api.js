@@ -31,9 +31,16 @@ export const updateApiBaseUrl = (serverUrl) => {
   }
 }
 
+// API key sent as a bearer token, set from the server settings
+export const API_KEY_STORAGE_KEY = 'mcp-api-key'
+
 // Request interceptor
 api.interceptors.request.use(
   (config) => {
+    const apiKey = localStorage.getItem(API_KEY_STORAGE_KEY)
+    if (apiKey) {
+      config.headers.Authorization = `Bearer ${apiKey}`
+    }
     return config
   },
   (error) => {
@@ -93,6 +100,12 @@ export default {
   updateAgentStatus(id, status) {
     return api.put(`/agents/${id}/status`, { status })
   },
+  updateAgentAccess(id, accessRole) {
+    return api.put(`/agents/${id}/access`, { access_role: accessRole })
+  },
+  updateAgentProfile(id, profile) {
+    return api.put(`/agents/${id}/profile`, profile)
+  },
   deleteAgent(id) {
     return api.delete(`/agents/${id}`)
   },
@@ -183,6 +196,9 @@ export default {
   getAgentHeartbeats(agentId, limit = 50) {
     return api.get(`/agents/${agentId}/heartbeats`, { params: { limit } })
   },
+  getAgentActivity(agentId, params = {}) {
+    return api.get(`/agents/${agentId}/activity`, { params })
+  },
 
   // Health
   checkHealth() {
@@ -1,12 +1,13 @@
 import { defineStore } from 'pinia'
 import { ref, computed } from 'vue'
-import { updateApiBaseUrl } from '../services/api'
+import { updateApiBaseUrl, API_KEY_STORAGE_KEY } from '../services/api'
 
 const STORAGE_KEY = 'mcp-server-url'
 const DEFAULT_URL = 'http://localhost:8080'
 
 export const useSettingsStore = defineStore('settings', () => {
   const serverUrl = ref(localStorage.getItem(STORAGE_KEY) || DEFAULT_URL)
+  const apiKey = ref(localStorage.getItem(API_KEY_STORAGE_KEY) || '')
   const isConnected = ref(false)
   const connectionError = ref(null)
   const isConnecting = ref(false)
@@ -38,6 +39,15 @@ export const useSettingsStore = defineStore('settings', () => {
     connectionError.value = null
   }
 
+  const setApiKey = (key) => {
+    apiKey.value = key
+    if (key) {
+      localStorage.setItem(API_KEY_STORAGE_KEY, key)
+    } else {
+      localStorage.removeItem(API_KEY_STORAGE_KEY)
+    }
+  }
+
   const testConnection = async () => {
     isConnecting.value = true
     connectionError.value = null
@@ -72,12 +82,14 @@ export const useSettingsStore = defineStore('settings', () => {
 
   return {
     serverUrl,
+    apiKey,
     apiBaseUrl,
     wsBaseUrl,
     isConnected,
     connectionError,
     isConnecting,
     setServerUrl,
+    setApiKey,
     testConnection,
     resetToDefault
   }